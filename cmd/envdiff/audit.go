@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/GBerghoff/envdiff/internal/audit"
+	"github.com/GBerghoff/envdiff/internal/config"
+	"github.com/GBerghoff/envdiff/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditFile string
+	auditFix  bool
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit <file.json> [file.json...]",
+	Short: "Scan snapshot and diff files for leaked secrets",
+	Long: `Scan snapshot and diff files for secrets before sharing them.
+
+Every string is checked with the same name and value detectors that
+'envdiff snapshot' uses, including the secrets section of envdiff.yaml.
+Findings are reported with their JSON path and the rule that fired.
+
+Examples:
+  envdiff audit local.json             # Report leaked secrets
+  envdiff audit local.json diff.json   # Audit several files
+  envdiff audit local.json --fix       # Redact in place and recompute IDs
+
+Exit codes:
+  0  no findings (or all findings fixed with --fix)
+  1  secrets found
+  2  a file could not be read or parsed`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runAudit,
+}
+
+func init() {
+	auditCmd.Flags().StringVarP(&auditFile, "file", "f", "envdiff.yaml", "Path to optional config file for secret rules")
+	auditCmd.Flags().BoolVar(&auditFix, "fix", false, "Rewrite files with findings redacted")
+}
+
+func runAudit(cmd *cobra.Command, args []string) error {
+	// A missing default config is fine, but an explicitly requested one must exist
+	var secretsCfg config.SecretsConfig
+	cfg, err := config.Load(auditFile)
+	switch {
+	case err == nil:
+		secretsCfg = cfg.Secrets
+	case os.IsNotExist(err) && !cmd.Flags().Changed("file"):
+	default:
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	redactor, err := secrets.NewRedactor(secretRules(secretsCfg))
+	if err != nil {
+		return fmt.Errorf("invalid secrets config: %w", err)
+	}
+
+	unfixed := 0
+	for _, path := range args {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		var findings []audit.Finding
+		if auditFix {
+			fixed, f, err := audit.Fix(data, redactor)
+			if err != nil {
+				return fmt.Errorf("failed to fix %s: %w", path, err)
+			}
+			if len(f) > 0 {
+				if err := os.WriteFile(path, fixed, 0600); err != nil {
+					return fmt.Errorf("failed to write %s: %w", path, err)
+				}
+			}
+			findings = f
+		} else {
+			if findings, err = audit.Scan(data, redactor); err != nil {
+				return fmt.Errorf("failed to scan %s: %w", path, err)
+			}
+			unfixed += len(findings)
+		}
+
+		for _, f := range findings {
+			fmt.Printf("%s: %s (%s)\n", path, f.Path, f.Rule)
+		}
+		if auditFix && len(findings) > 0 {
+			fmt.Fprintf(os.Stderr, "Redacted %d value(s) in %s\n", len(findings), path)
+		}
+	}
+
+	if unfixed > 0 {
+		fmt.Fprintf(os.Stderr, "%d secret(s) found. Run 'envdiff audit --fix' before sharing.\n", unfixed)
+		return failWithFindings(cmd)
+	}
+	return nil
+}
@@ -53,23 +53,79 @@ func runCheck(cmd *cobra.Command, args []string) error {
 	requiredRuntimes := cfg.GetRequiredRuntimes()
 	var runtimesToProbe []collector.RuntimeDefinition
 
+	queued := make(map[string]bool)
+
 	// 1. Add requested runtimes from registry
 	for name := range requiredRuntimes {
 		if def, ok := collector.Registry[name]; ok {
 			runtimesToProbe = append(runtimesToProbe, def)
+			queued[name] = true
+		}
+	}
+
+	// 2. Add runtimes whose pinned version is checked. Pin files are
+	// looked up from the current directory.
+	pinRoot := ""
+	if len(cfg.Pinned) > 0 {
+		pinRoot = "."
+		for name := range pinnedToProbe(cfg.Pinned) {
+			if def, ok := collector.Registry[name]; ok && !queued[name] {
+				runtimesToProbe = append(runtimesToProbe, def)
+				queued[name] = true
+			}
+		}
+	}
+
+	// 3. Add runtimes checked for shadowed installs; "*" probes them all
+	for _, name := range cfg.Shadowed {
+		if name == "*" {
+			for name, def := range collector.Registry {
+				if !queued[name] {
+					runtimesToProbe = append(runtimesToProbe, def)
+					queued[name] = true
+				}
+			}
+			continue
+		}
+		if def, ok := collector.Registry[name]; ok && !queued[name] {
+			runtimesToProbe = append(runtimesToProbe, def)
+			queued[name] = true
 		}
 	}
 
-	// 2. Add custom runtimes from config
+	// 4. Add custom runtimes from config
 	for _, custom := range cfg.CustomRuntimes {
-		def, err := collector.NewRuntimeDefinition(custom.Name, custom.Command, custom.VersionRE, custom.Args)
+		def, err := customRuntimeDefinition(custom)
 		if err != nil {
 			return fmt.Errorf("invalid custom runtime %s: %w", custom.Name, err)
 		}
 		runtimesToProbe = append(runtimesToProbe, def)
 	}
 
-	if err := collector.CollectAll(snap, false, runtimesToProbe, cfg.Packages); err != nil {
+	// 5. Record any git settings the repo rules expect
+	var repoConfigKeys []string
+	for key := range cfg.Repo.Config {
+		repoConfigKeys = append(repoConfigKeys, key)
+	}
+
+	// 6. Filesystem rules may name the project's filesystem
+	projectRoot := pinRoot
+	if cfg.Filesystems.IsSet() {
+		projectRoot = "."
+	}
+
+	opts := collector.Options{
+		Runtimes:        runtimesToProbe,
+		Packages:        cfg.Packages,
+		VersionManagers: pinRoot != "",
+		ProjectRoot:     projectRoot,
+		Repo:            cfg.Repo.IsSet(),
+		RepoConfigKeys:  repoConfigKeys,
+		Filesystems:     cfg.Filesystems.IsSet(),
+		SysctlKeys:      cfg.SysctlKeys(),
+		Timeout:         probeTimeout,
+	}
+	if err := collector.CollectAll(cmd.Context(), snap, opts); err != nil {
 		return fmt.Errorf("failed to collect environment: %w", err)
 	}
 
@@ -80,7 +136,7 @@ func runCheck(cmd *cobra.Command, args []string) error {
 	if checkQuiet {
 		// Silent mode - just return exit code
 		if report.Failed > 0 {
-			os.Exit(1)
+			return failWithFindings(cmd)
 		}
 		return nil
 	}
@@ -96,8 +152,24 @@ func runCheck(cmd *cobra.Command, args []string) error {
 	}
 
 	if report.Failed > 0 {
-		os.Exit(1)
+		return failWithFindings(cmd)
 	}
 
 	return nil
 }
+
+// pinnedToProbe returns the runtimes named by the pinned rules, expanding
+// "*" to every runtime with a pin file
+func pinnedToProbe(pinned []string) map[string]bool {
+	names := make(map[string]bool)
+	for _, name := range pinned {
+		if name != "*" {
+			names[name] = true
+			continue
+		}
+		for runtime := range collector.FindPins(".") {
+			names[runtime] = true
+		}
+	}
+	return names
+}
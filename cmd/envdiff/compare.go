@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/GBerghoff/envdiff/internal/config"
 	"github.com/GBerghoff/envdiff/internal/diff"
 	"github.com/GBerghoff/envdiff/internal/snapshot"
 	"github.com/spf13/cobra"
@@ -13,6 +14,10 @@ import (
 
 var (
 	compareOutput string
+	compareFile   string
+	compareIgnore []string
+	compareFailOn []string
+	compareAllow  []string
 )
 
 var compareCmd = &cobra.Command{
@@ -23,16 +28,53 @@ var compareCmd = &cobra.Command{
 Examples:
   envdiff compare local.json ci.json              # Compare two snapshots
   envdiff compare local.json ci.json staging.json # Compare multiple
-  envdiff compare local.json ci.json -o diff.json # Save diff to file`,
+  envdiff compare local.json ci.json -o diff.json # Save diff to file
+  envdiff compare local.json ci.json --ignore 'LC_*' --ignore runtime.svn
+  envdiff compare golden.json agent.json --fail-on major  # Exit 1 on a major version bump
+  envdiff compare golden.json agent.json --fail-on runtime --fail-on 'severity>=high' --allow env.TZ
+
+Fields matching env.ignore in envdiff.yaml (or --file) are left out of the diff.
+
+--fail-on accepts: any, <section>, section:<name>, field:<section.name>,
+severity>=<level>, and major/minor/patch/prerelease. Repeated expressions are
+OR'd. --allow excludes matching fields (section.name globs) from the policy.
+
+Exit codes:
+  0  no difference matched --fail-on (or --fail-on not given)
+  1  at least one difference matched --fail-on
+  2  compare failed (bad arguments, unreadable snapshot or config)`,
 	Args: cobra.MinimumNArgs(2),
 	RunE: runCompare,
 }
 
 func init() {
 	compareCmd.Flags().StringVarP(&compareOutput, "output", "o", "", "Output file (default: stdout)")
+	compareCmd.Flags().StringVarP(&compareFile, "file", "f", "envdiff.yaml", "Path to optional config file for ignore patterns")
+	compareCmd.Flags().StringSliceVar(&compareIgnore, "ignore", nil, "Additional field patterns to ignore (glob, e.g. 'LC_*' or 'runtime.svn')")
+	compareCmd.Flags().StringSliceVar(&compareFailOn, "fail-on", nil, "Exit 1 when a difference matches: any, <section>, section:<name>, field:<section.name>, severity>=<level>, major/minor/patch")
+	compareCmd.Flags().StringSliceVar(&compareAllow, "allow", nil, "Known differences to exempt from --fail-on (glob, e.g. 'env.TZ' or 'runtime.*')")
 }
 
 func runCompare(cmd *cobra.Command, args []string) error {
+	opts := diff.Options{Ignore: compareIgnore}
+
+	policy, err := diff.ParsePolicy(compareFailOn, compareAllow)
+	if err != nil {
+		return err
+	}
+
+	// Load ignore patterns and list variables from config. A missing default file is fine,
+	// but an explicitly requested one must exist.
+	cfg, err := config.Load(compareFile)
+	switch {
+	case err == nil:
+		opts.Ignore = append(opts.Ignore, cfg.Env.Ignore...)
+		opts.ListSeparators = cfg.Env.ListSeparators()
+	case os.IsNotExist(err) && !cmd.Flags().Changed("file"):
+	default:
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
 	snapshots := make(map[string]*snapshot.Snapshot)
 
 	// Load all snapshots
@@ -53,7 +95,7 @@ func runCompare(cmd *cobra.Command, args []string) error {
 	}
 
 	// Compare
-	d := diff.Compare(snapshots)
+	d := diff.Compare(snapshots, opts)
 
 	// Output
 	output, err := d.ToJSON()
@@ -70,5 +112,15 @@ func runCompare(cmd *cobra.Command, args []string) error {
 		fmt.Print(string(output))
 	}
 
+	if policy.Empty() {
+		return nil
+	}
+	if violations := policy.Violations(d); len(violations) > 0 {
+		for _, v := range violations {
+			fmt.Fprintf(os.Stderr, "%s.%s: %s (fail-on %s)\n", v.Section, v.Field, v.Reason, v.Rule)
+		}
+		return failWithFindings(cmd)
+	}
+
 	return nil
 }
@@ -1,14 +1,41 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"time"
 
+	"github.com/GBerghoff/envdiff/internal/collector"
+	"github.com/GBerghoff/envdiff/internal/config"
+	"github.com/GBerghoff/envdiff/internal/secrets"
 	"github.com/spf13/cobra"
 )
 
 var version = "dev"
 
+// Exit codes are stable so scripts can tell findings apart from errors.
+// Success is 0. Only compare tells its own failures apart from findings;
+// other commands exit 1 for both.
+const (
+	exitFailed = 1 // checks failed, differences matched --fail-on or secrets were found
+	exitError  = 2 // compare itself failed
+)
+
+// errFindings ends a command with exitFailed once its report is written
+var errFindings = errors.New("findings reported")
+
+// failWithFindings returns errFindings without cobra printing it or the usage
+func failWithFindings(cmd *cobra.Command) error {
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	return errFindings
+}
+
+var probeTimeout time.Duration
+
 var rootCmd = &cobra.Command{
 	Use:   "envdiff",
 	Short: "Compare environments and surface the differences that matter",
@@ -23,16 +50,56 @@ Primary use cases:
 }
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cmd, err := rootCmd.ExecuteContextC(ctx)
+	if err == nil {
+		return
+	}
+	stop()
+	if errors.Is(err, errFindings) {
+		os.Exit(exitFailed)
+	}
+	fmt.Fprintln(os.Stderr, err)
+	if cmd == compareCmd {
+		os.Exit(exitError)
 	}
+	os.Exit(1)
 }
 
 func init() {
+	rootCmd.PersistentFlags().DurationVar(&probeTimeout, "timeout", collector.DefaultTimeout, "Timeout for each external probe (e.g. 'gcloud --version')")
+
 	rootCmd.AddCommand(snapshotCmd)
 	rootCmd.AddCommand(compareCmd)
 	rootCmd.AddCommand(renderCmd)
 	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(auditCmd)
 	rootCmd.AddCommand(initCmd)
 }
+
+// customRuntimeDefinition builds a collector definition from a custom_runtimes entry
+func customRuntimeDefinition(custom config.CustomRuntimeConfig) (collector.RuntimeDefinition, error) {
+	def, err := collector.NewRuntimeDefinition(custom.Name, custom.Command, custom.VersionRE, custom.Args)
+	if err != nil {
+		return def, err
+	}
+	if def.Timeout, err = custom.TimeoutDuration(); err != nil {
+		return def, err
+	}
+	return def, nil
+}
+
+// secretRules converts the secrets section of envdiff.yaml to detection rules
+func secretRules(cfg config.SecretsConfig) secrets.Rules {
+	return secrets.Rules{
+		Patterns:        cfg.Patterns,
+		Allow:           cfg.Allow,
+		DisableDefaults: cfg.DisableDefaults,
+		Entropy: secrets.EntropyRule{
+			MinLength:  cfg.Entropy.MinLength,
+			MinEntropy: cfg.Entropy.MinEntropy,
+		},
+	}
+}
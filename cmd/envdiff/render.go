@@ -11,8 +11,9 @@ import (
 )
 
 var (
-	renderMarkdown bool
-	renderOutput   string
+	renderMarkdown    bool
+	renderOutput      string
+	renderMinSeverity string
 )
 
 var renderCmd = &cobra.Command{
@@ -23,7 +24,8 @@ var renderCmd = &cobra.Command{
 Examples:
   envdiff render snapshot.json           # CLI output (default)
   envdiff render diff.json --md          # Markdown output
-  envdiff render diff.json --md -o report.md`,
+  envdiff render diff.json --md -o report.md
+  envdiff render diff.json --min-severity high   # Only differences that matter most`,
 	Args: cobra.ExactArgs(1),
 	RunE: runRender,
 }
@@ -31,6 +33,7 @@ Examples:
 func init() {
 	renderCmd.Flags().BoolVar(&renderMarkdown, "md", false, "Output as Markdown")
 	renderCmd.Flags().StringVarP(&renderOutput, "output", "o", "", "Output file (default: stdout)")
+	renderCmd.Flags().StringVar(&renderMinSeverity, "min-severity", "", "Hide differences below this severity: low, medium, high, critical")
 }
 
 func runRender(cmd *cobra.Command, args []string) error {
@@ -41,6 +44,13 @@ func runRender(cmd *cobra.Command, args []string) error {
 
 	var output string
 
+	var minSeverity diff.Severity
+	if renderMinSeverity != "" {
+		if minSeverity, err = diff.ParseSeverity(renderMinSeverity); err != nil {
+			return err
+		}
+	}
+
 	// Try to detect if it's a diff or snapshot
 	if isDiff(data) {
 		d, err := diff.FromJSON(data)
@@ -49,9 +59,11 @@ func runRender(cmd *cobra.Command, args []string) error {
 		}
 		if renderMarkdown {
 			renderer := render.NewMarkdown()
+			renderer.MinSeverity = minSeverity
 			output = renderer.RenderDiff(d)
 		} else {
 			renderer := render.NewCLI()
+			renderer.MinSeverity = minSeverity
 			output = renderer.RenderDiff(d)
 		}
 	} else {
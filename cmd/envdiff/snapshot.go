@@ -3,19 +3,28 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/GBerghoff/envdiff/internal/collector"
 	"github.com/GBerghoff/envdiff/internal/config"
 	"github.com/GBerghoff/envdiff/internal/render"
+	"github.com/GBerghoff/envdiff/internal/secrets"
 	"github.com/GBerghoff/envdiff/internal/snapshot"
 	"github.com/spf13/cobra"
 )
 
 var (
-	snapshotNoRedact bool
-	snapshotOutput   string
-	snapshotFormat   string
-	snapshotFile     string
+	snapshotNoRedact    bool
+	snapshotOutput      string
+	snapshotFormat      string
+	snapshotFile        string
+	snapshotRedact      string
+	snapshotSaltFile    string
+	snapshotNoInventory bool
+	snapshotNoToolchain bool
+	snapshotNoRepo      bool
+	snapshotPackages    string
+	snapshotProject     string
 )
 
 var snapshotCmd = &cobra.Command{
@@ -28,12 +37,35 @@ The snapshot includes:
   • Runtime versions (go, node, python, docker, etc.)
   • Environment variables (secrets auto-redacted)
   • Network info (/etc/hosts, listening ports)
+  • Effective resource limits (cgroup CPU, memory and pids limits,
+    ulimit -n, -u and -s)
+  • Kernel parameters (net.core.somaxconn, vm.max_map_count,
+    fs.inotify.max_user_watches, ... plus those under sysctl: in config)
+  • Language packages (pip, global npm, gems, cargo installs, $GOBIN)
+  • Version managers (asdf, mise, nvm, pyenv, rbenv, sdkman, goenv) and
+    versions pinned by .tool-versions, .nvmrc, .python-version, ...
+  • Project lockfiles (go.sum, package-lock.json, Cargo.lock, ...) and the
+    versions they resolve for direct dependencies
+  • Toolchain configuration (go env, npm, pip and git config)
+  • Git checkout state (HEAD, local changes, submodules, hooks, LFS)
+  • Filesystems holding the project, $TMPDIR, $HOME and the docker data
+    root (type, mount options, free space, case sensitivity)
 
 Examples:
   envdiff snapshot                    # Output JSON to stdout
   envdiff snapshot -o local.json      # Save to file
   envdiff snapshot --no-redact        # Include secret values
-  envdiff snapshot --format cli       # Pretty terminal output`,
+  envdiff snapshot --redact-mode hmac # Fingerprint secrets so they can be compared
+  envdiff snapshot --format cli       # Pretty terminal output
+  envdiff snapshot --no-inventory     # Skip pip, npm, gem, cargo and $GOBIN listings
+  envdiff snapshot --no-repo          # Skip the git checkout state
+  envdiff snapshot --packages=all     # Record every installed system package
+  envdiff snapshot --project ../app   # Read lockfiles from another directory
+
+With --redact-mode hmac, secrets are replaced by HMAC fingerprints keyed by a
+team-shared salt from --salt-file, $ENVDIFF_SECRET_SALT, or the file named by
+$ENVDIFF_SECRET_SALT_FILE. Snapshots taken with the same salt show whether a
+secret differs without revealing it.`,
 	RunE: runSnapshot,
 }
 
@@ -42,6 +74,13 @@ func init() {
 	snapshotCmd.Flags().StringVarP(&snapshotOutput, "output", "o", "", "Output file (default: stdout)")
 	snapshotCmd.Flags().StringVar(&snapshotFormat, "format", "json", "Output format: json, cli, md")
 	snapshotCmd.Flags().StringVarP(&snapshotFile, "file", "f", "envdiff.yaml", "Path to optional config file for custom runtimes")
+	snapshotCmd.Flags().StringVar(&snapshotRedact, "redact-mode", "placeholder", "How to redact secrets: placeholder or hmac")
+	snapshotCmd.Flags().StringVar(&snapshotSaltFile, "salt-file", "", "File holding the salt for --redact-mode hmac")
+	snapshotCmd.Flags().StringVar(&snapshotPackages, "packages", "", "System packages to record: all, or a comma-separated list (default: packages from config)")
+	snapshotCmd.Flags().StringVar(&snapshotProject, "project", ".", "Project root to read lockfiles from (empty to skip)")
+	snapshotCmd.Flags().BoolVar(&snapshotNoInventory, "no-inventory", false, "Skip language package inventories (pip, npm, gem, cargo, $GOBIN)")
+	snapshotCmd.Flags().BoolVar(&snapshotNoToolchain, "no-toolchain", false, "Skip toolchain configuration (go env, npm, pip and git config)")
+	snapshotCmd.Flags().BoolVar(&snapshotNoRepo, "no-repo", false, "Skip the state of the git checkout holding the project root")
 }
 
 func runSnapshot(cmd *cobra.Command, args []string) error {
@@ -57,20 +96,68 @@ func runSnapshot(cmd *cobra.Command, args []string) error {
 		runtimesToProbe = append(runtimesToProbe, def)
 	}
 
-	// 2. Add config from file if it exists
-	if cfg, err := config.Load(snapshotFile); err == nil {
+	// 2. Add config from file. A missing default file is fine, but an
+	// explicitly requested one must exist.
+	var secretsCfg config.SecretsConfig
+	var sysctlKeys []string
+	envLists := config.DefaultEnvLists
+	cfg, err := config.Load(snapshotFile)
+	switch {
+	case err == nil:
+		secretsCfg = cfg.Secrets
+		envLists = cfg.Env.ListSeparators()
 		packageNames = cfg.Packages
+		sysctlKeys = cfg.SysctlKeys()
 		for _, custom := range cfg.CustomRuntimes {
-			def, err := collector.NewRuntimeDefinition(custom.Name, custom.Command, custom.VersionRE, custom.Args)
-			if err == nil {
-				runtimesToProbe = append(runtimesToProbe, def)
+			def, err := customRuntimeDefinition(custom)
+			if err != nil {
+				return fmt.Errorf("invalid custom runtime %s: %w", custom.Name, err)
+			}
+			runtimesToProbe = append(runtimesToProbe, def)
+		}
+	case os.IsNotExist(err) && !cmd.Flags().Changed("file"):
+	default:
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// 3. --packages replaces the config's package list
+	allPackages := false
+	switch strings.TrimSpace(snapshotPackages) {
+	case "":
+	case "all":
+		allPackages = true
+	default:
+		packageNames = nil
+		for _, name := range strings.Split(snapshotPackages, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				packageNames = append(packageNames, name)
 			}
 		}
 	}
 
+	redactor, err := snapshotRedactor(secretsCfg)
+	if err != nil {
+		return err
+	}
+
 	// Run collectors
-	redact := !snapshotNoRedact
-	if err := collector.CollectAll(snap, redact, runtimesToProbe, packageNames); err != nil {
+	opts := collector.Options{
+		Redact:          !snapshotNoRedact,
+		Redactor:        redactor,
+		Runtimes:        runtimesToProbe,
+		Packages:        packageNames,
+		EnvLists:        envLists,
+		AllPackages:     allPackages,
+		ProjectRoot:     snapshotProject,
+		VersionManagers: true,
+		Inventories:     !snapshotNoInventory,
+		Toolchain:       !snapshotNoToolchain,
+		Repo:            !snapshotNoRepo,
+		Filesystems:     true,
+		SysctlKeys:      sysctlKeys,
+		Timeout:         probeTimeout,
+	}
+	if err := collector.CollectAll(cmd.Context(), snap, opts); err != nil {
 		return fmt.Errorf("failed to collect environment: %w", err)
 	}
 
@@ -81,7 +168,6 @@ func runSnapshot(cmd *cobra.Command, args []string) error {
 
 	// Format output
 	var output []byte
-	var err error
 
 	switch snapshotFormat {
 	case "json":
@@ -112,3 +198,30 @@ func runSnapshot(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// snapshotRedactor builds the redactor selected by --redact-mode,
+// using the secret rules from the config
+func snapshotRedactor(cfg config.SecretsConfig) (*secrets.Redactor, error) {
+	redactor, err := secrets.NewRedactor(secretRules(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("invalid secrets config: %w", err)
+	}
+
+	switch snapshotRedact {
+	case "placeholder":
+		return redactor, nil
+	case "hmac":
+		salt, err := secrets.LoadSalt(snapshotSaltFile)
+		if err != nil {
+			return nil, err
+		}
+		fingerprinter, err := secrets.NewFingerprinter(salt)
+		if err != nil {
+			return nil, err
+		}
+		redactor.Fingerprinter = fingerprinter
+		return redactor, nil
+	default:
+		return nil, fmt.Errorf("unknown redact mode: %s (use placeholder or hmac)", snapshotRedact)
+	}
+}
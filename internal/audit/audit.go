@@ -0,0 +1,220 @@
+// Package audit scans snapshot and diff files for secrets that escaped
+// redaction, such as snapshots taken with --no-redact or diffs that embed them.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/GBerghoff/envdiff/internal/diff"
+	"github.com/GBerghoff/envdiff/internal/secrets"
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+// Finding is a string in a file that the secret detectors flag
+type Finding struct {
+	Path string `json:"path"` // JSON path, e.g. $.env.GITHUB_PAT
+	Rule string `json:"rule"` // detector that fired, e.g. value:github-token
+}
+
+// Scan reports every string in a snapshot or diff that should be redacted
+func Scan(data []byte, r *secrets.Redactor) ([]Finding, error) {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	a := &auditor{redactor: r}
+	a.walk(doc, "$", "", nil)
+	return a.findings, nil
+}
+
+// Fix redacts every finding and returns the rewritten file. Snapshot IDs,
+// including those of snapshots embedded in a diff, are recomputed.
+func Fix(data []byte, r *secrets.Redactor) ([]byte, []Finding, error) {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	a := &auditor{redactor: r, fix: true}
+	doc = a.walk(doc, "$", "", nil)
+	if len(a.findings) == 0 {
+		return data, nil, nil
+	}
+
+	fixed, err := json.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Round-trip through the typed model so IDs hash the redacted content
+	if m, ok := doc.(map[string]any); ok && m["diffs"] != nil {
+		d, err := diff.FromJSON(fixed)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse diff: %w", err)
+		}
+		for _, snap := range d.Snapshots {
+			if err := snap.ComputeID(); err != nil {
+				return nil, nil, err
+			}
+		}
+		out, err := d.ToJSON()
+		return out, a.findings, err
+	}
+
+	snap, err := snapshot.FromJSON(fixed)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	if err := snap.ComputeID(); err != nil {
+		return nil, nil, err
+	}
+	out, err := snap.ToJSON()
+	return out, a.findings, err
+}
+
+type auditor struct {
+	redactor *secrets.Redactor
+	fix      bool
+	findings []Finding
+}
+
+// walk visits every string in v. name is the variable a string belongs to:
+// values under "values" and "majority" in a diff keep their field's name.
+// owner holds the redactions of the strings being walked, the snapshot for
+// env and the tool's config for toolchain settings, so that fixes can be
+// recorded there. walk returns v with any fixes applied.
+func (a *auditor) walk(v any, path, name string, owner map[string]any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for _, key := range sortedKeys(v) {
+			if key == "redactions" {
+				continue // variable names and rule ids, never values
+			}
+			key, cleanKey := a.checkKey(v, key, path, owner)
+			childName, childOwner := cleanKey, owner
+			switch {
+			case key == "values" || key == "majority":
+				childName = name
+			case name != "" && strings.HasSuffix(path, ".values"):
+				childName = name // node names under a field's values
+			case key == "env" && v["snapshot_id"] != nil:
+				childOwner = v
+			case key == "settings" && toolConfigPath.MatchString(path):
+				childOwner = v
+			}
+			v[key] = a.walk(v[key], path+pathKey(key), childName, childOwner)
+		}
+		return v
+
+	case []any:
+		for i := range v {
+			v[i] = a.walk(v[i], fmt.Sprintf("%s[%d]", path, i), name, nil)
+		}
+		return v
+
+	case string:
+		return a.check(v, path, name, owner)
+	}
+	return v
+}
+
+// check flags a string and returns its redacted form when fixing
+func (a *auditor) check(value, path, name string, owner map[string]any) any {
+	// Unset values, such as npm's empty defaults, hide nothing
+	if value == "" || secrets.IsRedacted(value) {
+		return value
+	}
+
+	// Name rules only apply to environment variables and toolchain settings,
+	// whose diff fields are named <tool>/<setting>
+	named := owner != nil || strings.HasPrefix(path, "$.diffs.env.") || strings.HasPrefix(path, "$.diffs.env[")
+	if strings.HasPrefix(path, "$.diffs.toolchain.") || strings.HasPrefix(path, "$.diffs.toolchain[") {
+		_, name, _ = strings.Cut(name, "/")
+		named = true
+	}
+	scrubbed, _ := a.redactor.ScrubURLs(name, value)
+
+	var rule string
+	var secret bool
+	if named {
+		rule, secret = a.redactor.Detect(name, scrubbed)
+	} else {
+		rule, secret = a.redactor.DetectValue(scrubbed)
+	}
+
+	replacement := secrets.RedactedValue
+	switch {
+	case secret:
+	case scrubbed != value:
+		rule, replacement = secrets.URLCredentialsRuleID, scrubbed
+	default:
+		return value
+	}
+
+	a.findings = append(a.findings, Finding{Path: path, Rule: rule})
+	if !a.fix {
+		return value
+	}
+	if owner != nil {
+		a.recordRedaction(owner, name, rule)
+	}
+	return replacement
+}
+
+// recordRedaction adds a fix to the redactions of the snapshot or tool
+// config that owns the redacted string
+func (a *auditor) recordRedaction(owner map[string]any, name, rule string) {
+	redactions, _ := owner["redactions"].(map[string]any)
+	if redactions == nil {
+		redactions = make(map[string]any)
+		owner["redactions"] = redactions
+	}
+	redactions[name] = rule
+}
+
+// toolConfigPath matches the path of a tool's config in a snapshot, whose
+// settings are named like environment variables
+var toolConfigPath = regexp.MustCompile(`\.toolchain\.[A-Za-z0-9_-]+$`)
+
+// checkKey flags credentials in URLs used as map keys, such as git's
+// url.<base>.insteadof settings. It returns the key after any fix, and the
+// key with credentials scrubbed, which name rules are applied to.
+func (a *auditor) checkKey(m map[string]any, key, path string, owner map[string]any) (string, string) {
+	scrubbed, ok := a.redactor.ScrubURLs(key, key)
+	if !ok {
+		return key, key
+	}
+	a.findings = append(a.findings, Finding{Path: path + pathKey(key), Rule: secrets.URLCredentialsRuleID})
+	if !a.fix {
+		return key, scrubbed
+	}
+	m[scrubbed] = m[key]
+	delete(m, key)
+	if owner != nil {
+		a.recordRedaction(owner, scrubbed, secrets.URLCredentialsRuleID)
+	}
+	return scrubbed, scrubbed
+}
+
+var plainKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// pathKey formats a map key as a JSON path segment
+func pathKey(key string) string {
+	if plainKey.MatchString(key) {
+		return "." + key
+	}
+	return "[" + strconv.Quote(key) + "]"
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
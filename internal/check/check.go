@@ -4,7 +4,11 @@ package check
 
 import (
 	"fmt"
+	"math"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
@@ -68,6 +72,47 @@ func Check(snap *snapshot.Snapshot, cfg *config.Config) *Report {
 		updateCounts(report, result.Status)
 	}
 
+	// Check that active runtimes match the versions pinned by the project
+	for _, name := range pinnedRuntimes(snap, cfg.Pinned) {
+		result := checkPinned(snap, name, cfg.Fix[name])
+		report.Results = append(report.Results, result)
+		updateCounts(report, result.Status)
+	}
+
+	// Check for runtime installs hidden behind an earlier PATH entry
+	for _, name := range shadowedRuntimes(snap, cfg.Shadowed) {
+		result := checkShadowed(snap, name)
+		report.Results = append(report.Results, result)
+		updateCounts(report, result.Status)
+	}
+
+	// Check the state of the git checkout
+	for _, result := range checkRepo(snap, cfg.Repo) {
+		report.Results = append(report.Results, result)
+		updateCounts(report, result.Status)
+	}
+
+	// Check free space and mount options of the recorded filesystems
+	for _, result := range checkFilesystems(snap, cfg.Filesystems) {
+		report.Results = append(report.Results, result)
+		updateCounts(report, result.Status)
+	}
+
+	// Check the effective CPU, memory and process limits
+	for _, result := range checkLimits(snap, cfg.Limits) {
+		report.Results = append(report.Results, result)
+		updateCounts(report, result.Status)
+	}
+
+	// Check kernel parameters against their constraints
+	for _, key := range cfg.SysctlKeys() {
+		if constraint := cfg.Sysctl[key]; constraint != "" {
+			result := checkSysctl(snap, key, constraint, cfg.Fix[key])
+			report.Results = append(report.Results, result)
+			updateCounts(report, result.Status)
+		}
+	}
+
 	// Check required system packages
 	for _, name := range cfg.Packages {
 		result := checkPackage(snap, name, cfg.Fix[name])
@@ -94,6 +139,13 @@ func checkPackage(snap *snapshot.Snapshot, name string, fix config.FixConfig) Re
 
 	version, exists := snap.Packages.Items[name]
 	if !exists {
+		// A failed probe says nothing about whether the package is installed
+		if perr := snap.ProbeError("package", name); perr != nil {
+			result.Status = StatusWarn
+			result.Message = probeErrorMessage(perr)
+			result.Actual = "(unknown)"
+			return result
+		}
 		result.Status = StatusFail
 		result.Message = "not installed"
 		result.Actual = "(missing)"
@@ -109,6 +161,615 @@ func checkPackage(snap *snapshot.Snapshot, name string, fix config.FixConfig) Re
 	return result
 }
 
+// pinnedRuntimes expands the pinned list, where "*" stands for every
+// runtime with a pin file
+func pinnedRuntimes(snap *snapshot.Snapshot, pinned []string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range pinned {
+		if name != "*" {
+			if !seen[name] {
+				names = append(names, name)
+				seen[name] = true
+			}
+			continue
+		}
+		all := make([]string, 0, len(snap.VersionManagers))
+		for runtime, info := range snap.VersionManagers {
+			if info.Pinned != "" && !seen[runtime] {
+				all = append(all, runtime)
+			}
+		}
+		sort.Strings(all)
+		for _, runtime := range all {
+			names = append(names, runtime)
+			seen[runtime] = true
+		}
+	}
+	return names
+}
+
+// managerHints suggest how to switch to the pinned version
+var managerHints = map[string]string{
+	"asdf":   "asdf install",
+	"mise":   "mise install",
+	"nvm":    "nvm install && nvm use",
+	"pyenv":  "pyenv install --skip-existing",
+	"rbenv":  "rbenv install --skip-existing",
+	"sdkman": "sdk env install",
+	"goenv":  "goenv install --skip-existing",
+}
+
+func checkPinned(snap *snapshot.Snapshot, name string, fix config.FixConfig) Result {
+	result := Result{
+		Category: "pinned",
+		Name:     name,
+	}
+
+	info := snap.VersionManagers[name]
+	if info == nil || info.Pinned == "" {
+		result.Status = StatusWarn
+		result.Message = "no pin file found"
+		return result
+	}
+	result.Expected = info.Pinned
+
+	runtime, exists := snap.Runtime[name]
+	if !exists || runtime == nil {
+		result.Status = StatusFail
+		result.Message = fmt.Sprintf("not installed, %s pins %s", info.PinFile, info.Pinned)
+		result.Actual = "(missing)"
+		result.FixHint = fix.Missing
+		if result.FixHint == "" {
+			result.FixHint = managerHints[info.Manager]
+		}
+		return result
+	}
+	result.Actual = runtime.Version
+
+	pin := normalizePin(info.Pinned)
+	if pin == "" || pin[0] < '0' || pin[0] > '9' {
+		result.Status = StatusWarn
+		result.Message = fmt.Sprintf("%s pins alias %q, cannot compare", info.PinFile, info.Pinned)
+		return result
+	}
+
+	if runtime.Version == pin || strings.HasPrefix(runtime.Version, pin+".") {
+		result.Status = StatusPass
+		result.Message = fmt.Sprintf("matches %s", info.PinFile)
+		if info.Manager != "" {
+			result.Message += fmt.Sprintf(" (via %s)", info.Manager)
+		}
+		return result
+	}
+
+	result.Status = StatusFail
+	result.Message = fmt.Sprintf("%s pins %s", info.PinFile, info.Pinned)
+	result.FixHint = fix.WrongVersion
+	if result.FixHint == "" {
+		result.FixHint = managerHints[info.Manager]
+	}
+	return result
+}
+
+// shadowedRuntimes expands the shadowed list, where "*" stands for every
+// runtime with more than one install on PATH
+func shadowedRuntimes(snap *snapshot.Snapshot, shadowed []string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range shadowed {
+		if name != "*" {
+			if !seen[name] {
+				names = append(names, name)
+				seen[name] = true
+			}
+			continue
+		}
+		all := make([]string, 0, len(snap.Runtime))
+		for runtime, info := range snap.Runtime {
+			if info != nil && len(info.Installations) > 1 && !seen[runtime] {
+				all = append(all, runtime)
+			}
+		}
+		sort.Strings(all)
+		for _, runtime := range all {
+			names = append(names, runtime)
+			seen[runtime] = true
+		}
+	}
+	return names
+}
+
+func checkShadowed(snap *snapshot.Snapshot, name string) Result {
+	result := Result{
+		Category: "shadowed",
+		Name:     name,
+	}
+
+	runtime, exists := snap.Runtime[name]
+	if !exists || runtime == nil {
+		result.Status = StatusWarn
+		result.Message = "not installed"
+		result.Actual = "(missing)"
+		return result
+	}
+	result.Actual = runtime.Version
+
+	if len(runtime.Installations) <= 1 {
+		result.Status = StatusPass
+		result.Message = fmt.Sprintf("only install on PATH: %s", runtime.Path)
+		return result
+	}
+
+	shadowed := make([]string, 0, len(runtime.Installations)-1)
+	for _, install := range runtime.Installations[1:] {
+		shadowed = append(shadowed, fmt.Sprintf("%s (%s)", install.Path, install.Version))
+	}
+	result.Status = StatusWarn
+	result.Message = fmt.Sprintf("%s shadows %s", runtime.Installations[0].Path, strings.Join(shadowed, ", "))
+	result.FixHint = "remove the unused installs or reorder PATH so the intended one comes first"
+	return result
+}
+
+// checkRepo checks the configured repo requirements, in a fixed order
+func checkRepo(snap *snapshot.Snapshot, cfg config.RepoConfig) []Result {
+	if !cfg.IsSet() {
+		return nil
+	}
+	repo := snap.Repo
+	if repo == nil {
+		return []Result{{
+			Category: "repo",
+			Name:     "repo",
+			Status:   StatusFail,
+			Message:  "not a git checkout",
+			Expected: "(git checkout)",
+			Actual:   "(missing)",
+		}}
+	}
+
+	var results []Result
+	if cfg.Clean {
+		result := Result{Category: "repo", Name: "clean", Expected: "clean"}
+		if repo.Dirty == 0 && repo.Untracked == 0 {
+			result.Status = StatusPass
+			result.Message = "no local changes"
+			result.Actual = "clean"
+		} else {
+			result.Status = StatusFail
+			result.Actual = fmt.Sprintf("%d changed, %d untracked", repo.Dirty, repo.Untracked)
+			result.Message = result.Actual
+			result.FixHint = "commit or stash local changes (git status)"
+		}
+		results = append(results, result)
+	}
+
+	if cfg.Branch != "" {
+		result := Result{Category: "repo", Name: "branch", Expected: cfg.Branch, Actual: repo.Branch}
+		switch {
+		case repo.Branch == cfg.Branch:
+			result.Status = StatusPass
+			result.Message = "on " + cfg.Branch
+		case repo.Branch == "":
+			result.Status = StatusFail
+			result.Message = "HEAD is detached"
+			result.Actual = "(detached)"
+		default:
+			result.Status = StatusFail
+			result.Message = "on " + repo.Branch
+		}
+		if result.Status == StatusFail {
+			result.FixHint = "git switch " + cfg.Branch
+		}
+		results = append(results, result)
+	}
+
+	if cfg.Submodules {
+		result := Result{Category: "repo", Name: "submodules", Expected: "(initialized)"}
+		var missing []string
+		for path, commit := range repo.Submodules {
+			if commit == "" {
+				missing = append(missing, path)
+			}
+		}
+		sort.Strings(missing)
+		if len(missing) == 0 {
+			result.Status = StatusPass
+			result.Message = fmt.Sprintf("%d initialized", len(repo.Submodules))
+			result.Actual = "(initialized)"
+		} else {
+			result.Status = StatusFail
+			result.Message = "not initialized: " + strings.Join(missing, ", ")
+			result.Actual = strings.Join(missing, ", ")
+			result.FixHint = "git submodule update --init --recursive"
+		}
+		results = append(results, result)
+	}
+
+	if cfg.LFS {
+		result := Result{Category: "repo", Name: "lfs", Expected: "(fetched)"}
+		switch {
+		case repo.LFS == nil:
+			result.Status = StatusPass
+			result.Message = "repository does not use LFS"
+			result.Actual = "(unused)"
+		case !repo.LFS.Installed:
+			result.Status = StatusFail
+			result.Message = "git-lfs is not installed"
+			result.Actual = "(missing)"
+			result.FixHint = "install git-lfs, then run git lfs install && git lfs pull"
+		case repo.LFS.Pointers > 0:
+			result.Status = StatusFail
+			result.Actual = fmt.Sprintf("%d of %d files are pointers", repo.LFS.Pointers, repo.LFS.Files)
+			result.Message = result.Actual
+			result.FixHint = "git lfs pull"
+		default:
+			result.Status = StatusPass
+			result.Message = fmt.Sprintf("%d files fetched", repo.LFS.Files)
+			result.Actual = "(fetched)"
+		}
+		results = append(results, result)
+	}
+
+	for _, hook := range cfg.Hooks {
+		result := Result{Category: "repo", Name: "hook/" + hook, Expected: "(installed)"}
+		installed := false
+		for _, h := range repo.Hooks {
+			installed = installed || h == hook
+		}
+		if installed {
+			result.Status = StatusPass
+			result.Message = "installed"
+			result.Actual = "(installed)"
+		} else {
+			result.Status = StatusFail
+			result.Message = "not installed"
+			result.Actual = "(missing)"
+		}
+		results = append(results, result)
+	}
+
+	keys := make([]string, 0, len(cfg.Config))
+	for key := range cfg.Config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		expected := cfg.Config[key]
+		result := Result{Category: "repo", Name: "config/" + key, Expected: expected}
+		value, ok := repo.Config[snapshot.CanonicalGitKey(key)]
+		switch {
+		case !ok:
+			result.Status = StatusFail
+			result.Message = "not set"
+			result.Actual = "(missing)"
+		case value == expected:
+			result.Status = StatusPass
+			result.Message = "matches"
+			result.Actual = value
+		default:
+			result.Status = StatusFail
+			result.Message = fmt.Sprintf("expected %s", expected)
+			result.Actual = value
+		}
+		if result.Status == StatusFail {
+			result.FixHint = fmt.Sprintf("git config %s %s", key, expected)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// expandRoles resolves per-role filesystem rules. "*" applies to every
+// recorded filesystem, and a rule naming the role itself takes precedence.
+func expandRoles[V any](snap *snapshot.Snapshot, rules map[string]V) map[string]V {
+	expanded := make(map[string]V)
+	if all, ok := rules["*"]; ok {
+		for role := range snap.Filesystems {
+			expanded[role] = all
+		}
+	}
+	for role, rule := range rules {
+		if role != "*" {
+			expanded[role] = rule
+		}
+	}
+	return expanded
+}
+
+// sortedRoles orders roles as snapshot.FilesystemRoles does, with unknown
+// roles last
+func sortedRoles[V any](rules map[string]V) []string {
+	rank := make(map[string]int, len(snapshot.FilesystemRoles))
+	for i, role := range snapshot.FilesystemRoles {
+		rank[role] = i + 1
+	}
+	roles := make([]string, 0, len(rules))
+	for role := range rules {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		ri, rj := rank[roles[i]], rank[roles[j]]
+		switch {
+		case ri == 0 && rj == 0:
+			return roles[i] < roles[j]
+		case ri == 0 || rj == 0:
+			return rj == 0
+		default:
+			return ri < rj
+		}
+	})
+	return roles
+}
+
+// checkFilesystems checks minimum free space, then forbidden mount options
+func checkFilesystems(snap *snapshot.Snapshot, cfg config.FilesystemConfig) []Result {
+	var results []Result
+	minFree := expandRoles(snap, cfg.MinFree)
+	for _, role := range sortedRoles(minFree) {
+		results = append(results, checkMinFree(snap, role, minFree[role]))
+	}
+	forbidden := expandRoles(snap, cfg.ForbiddenOptions)
+	for _, role := range sortedRoles(forbidden) {
+		results = append(results, checkMountOptions(snap, role, forbidden[role]))
+	}
+	return results
+}
+
+func checkMinFree(snap *snapshot.Snapshot, role, size string) Result {
+	result := Result{
+		Category: "filesystem",
+		Name:     role + "/free",
+		Expected: ">= " + size,
+	}
+
+	want, err := config.ParseSize(size)
+	if err != nil {
+		result.Status = StatusWarn
+		result.Message = err.Error()
+		return result
+	}
+	mount := snap.Filesystems[role]
+	if mount == nil {
+		result.Status = StatusWarn
+		result.Message = "filesystem not recorded"
+		result.Actual = "(unknown)"
+		return result
+	}
+
+	result.Actual = config.FormatSize(mount.FreeBytes)
+	if mount.FreeBytes >= want {
+		result.Status = StatusPass
+		result.Message = fmt.Sprintf("%s free on %s", result.Actual, mount.MountPoint)
+		return result
+	}
+	result.Status = StatusFail
+	result.Message = fmt.Sprintf("only %s free on %s", result.Actual, mount.MountPoint)
+	result.FixHint = fmt.Sprintf("free up space on %s", mount.MountPoint)
+	if role == snapshot.FilesystemDocker {
+		result.FixHint = "docker system prune"
+	}
+	return result
+}
+
+func checkMountOptions(snap *snapshot.Snapshot, role string, forbidden []string) Result {
+	result := Result{
+		Category: "filesystem",
+		Name:     role + "/options",
+		Expected: "not " + strings.Join(forbidden, ", "),
+	}
+
+	mount := snap.Filesystems[role]
+	if mount == nil {
+		result.Status = StatusWarn
+		result.Message = "filesystem not recorded"
+		result.Actual = "(unknown)"
+		return result
+	}
+	result.Actual = strings.Join(mount.Options, ",")
+
+	var found []string
+	for _, option := range forbidden {
+		if slices.Contains(mount.Options, option) {
+			found = append(found, option)
+		}
+	}
+	if len(found) == 0 {
+		result.Status = StatusPass
+		result.Message = fmt.Sprintf("%s mounted %s", mount.MountPoint, result.Actual)
+		return result
+	}
+	result.Status = StatusFail
+	result.Message = fmt.Sprintf("%s is mounted %s", mount.MountPoint, strings.Join(found, ", "))
+	if role == snapshot.FilesystemTmp {
+		result.FixHint = "point TMPDIR at a directory on a filesystem without " + strings.Join(found, ", ")
+	}
+	return result
+}
+
+// limitRule pairs a configured limit constraint with the recorded limit
+type limitRule struct {
+	Name       string
+	Constraint string
+	Parse      func(string) (float64, error)
+	Raw        float64 // 0 when not recorded
+	Value      float64 // Raw with unlimited as +Inf
+	Actual     string
+	Source     string
+	FixHint    func(numericConstraint) string
+}
+
+// checkLimits checks the effective resource limits that have a constraint
+func checkLimits(snap *snapshot.Snapshot, cfg config.LimitsConfig) []Result {
+	limits := snap.Limits
+	if limits == nil {
+		limits = &snapshot.LimitsInfo{} // snapshots from before limits were recorded
+	}
+	rules := []limitRule{
+		{"cpus", cfg.CPUs, parseNumber, limits.CPUs, limits.CPUs,
+			strconv.FormatFloat(limits.CPUs, 'f', -1, 64), limits.CPUSource,
+			func(numericConstraint) string { return "raise the CPU limit of the container or CI job" }},
+		{"memory", cfg.Memory, parseBytes, float64(limits.MemoryBytes), limitValue(limits.MemoryBytes),
+			config.FormatSizeLimit(limits.MemoryBytes), limits.MemorySource,
+			func(numericConstraint) string { return "raise the memory limit of the container or CI job" }},
+		{"pids", cfg.Pids, parseNumber, float64(limits.Pids), limitValue(limits.Pids),
+			config.FormatLimit(limits.Pids), "",
+			func(numericConstraint) string { return "raise the container's pids limit (docker run --pids-limit)" }},
+		{"open_files", cfg.OpenFiles, parseNumber, float64(limits.OpenFiles), limitValue(limits.OpenFiles),
+			config.FormatLimit(limits.OpenFiles), "",
+			func(c numericConstraint) string { return ulimitHint("n", c, 1) }},
+		{"processes", cfg.Processes, parseNumber, float64(limits.Processes), limitValue(limits.Processes),
+			config.FormatLimit(limits.Processes), "",
+			func(c numericConstraint) string { return ulimitHint("u", c, 1) }},
+		{"stack", cfg.Stack, parseBytes, float64(limits.StackBytes), limitValue(limits.StackBytes),
+			config.FormatSizeLimit(limits.StackBytes), "",
+			func(c numericConstraint) string { return ulimitHint("s", c, 1024) }},
+	}
+
+	var results []Result
+	for _, rule := range rules {
+		if rule.Constraint != "" {
+			results = append(results, checkLimit(rule))
+		}
+	}
+	return results
+}
+
+func checkLimit(rule limitRule) Result {
+	result := Result{
+		Category: "limits",
+		Name:     rule.Name,
+		Expected: rule.Constraint,
+	}
+
+	constraint, err := parseNumericConstraint(rule.Constraint, rule.Parse)
+	if err != nil {
+		result.Status = StatusWarn
+		result.Message = err.Error()
+		return result
+	}
+	if rule.Raw == 0 {
+		result.Status = StatusWarn
+		result.Message = "limit not recorded"
+		result.Actual = "(unknown)"
+		return result
+	}
+
+	result.Actual = rule.Actual
+	result.Message = "effective limit"
+	if rule.Source == snapshot.LimitCgroup {
+		result.Actual += " (cgroup)"
+		result.Message = "limited by the cgroup"
+	}
+	if constraint.allows(rule.Value) {
+		result.Status = StatusPass
+		return result
+	}
+	result.Status = StatusFail
+	result.FixHint = rule.FixHint(constraint)
+	return result
+}
+
+// ulimitHint suggests raising a soft limit to the lowest value, in units of
+// unit bytes, that meets a ">=" or ">" constraint
+func ulimitHint(flag string, c numericConstraint, unit float64) string {
+	if math.IsInf(c.Value, 1) {
+		return fmt.Sprintf("ulimit -%s unlimited", flag)
+	}
+	value := int64(math.Ceil(c.Value / unit))
+	if c.Op == ">" {
+		value++
+	}
+	return fmt.Sprintf("ulimit -%s %d", flag, value)
+}
+
+// checkSysctl checks a kernel parameter. A numeric constraint such as
+// ">= 524288" is compared as a number, anything else must match the value.
+func checkSysctl(snap *snapshot.Snapshot, key, constraint string, fix config.FixConfig) Result {
+	result := Result{
+		Category: "kernel",
+		Name:     key,
+		Expected: constraint,
+	}
+
+	actual, ok := snap.Sysctl[key]
+	if !ok {
+		result.Status = StatusWarn
+		result.Message = "kernel parameter not recorded"
+		result.Actual = "(unknown)"
+		return result
+	}
+	result.Actual = actual
+	result.Message = fmt.Sprintf("%s is %s", key, actual)
+
+	var target string
+	if numeric, err := parseNumericConstraint(constraint, parseNumber); err == nil {
+		value, err := strconv.ParseFloat(actual, 64)
+		if err != nil {
+			result.Status = StatusWarn
+			result.Message = fmt.Sprintf("value %q is not a number", actual)
+			return result
+		}
+		if numeric.allows(value) {
+			result.Status = StatusPass
+			return result
+		}
+		target = sysctlTarget(numeric)
+	} else if strings.ContainsAny(constraint[:1], "<>=!") {
+		result.Status = StatusWarn
+		result.Message = err.Error()
+		return result
+	} else {
+		target = strings.Join(strings.Fields(constraint), " ")
+		if actual == target {
+			result.Status = StatusPass
+			return result
+		}
+	}
+
+	result.Status = StatusFail
+	switch {
+	case fix.WrongVersion != "":
+		result.FixHint = fix.WrongVersion
+	case strings.Contains(target, " "):
+		result.FixHint = fmt.Sprintf("sudo sysctl -w %s=%q", key, target)
+	case target != "":
+		result.FixHint = fmt.Sprintf("sudo sysctl -w %s=%s", key, target)
+	}
+	return result
+}
+
+// sysctlTarget returns the value closest to the current one that meets a
+// numeric constraint, or "" when there is no single such value
+func sysctlTarget(c numericConstraint) string {
+	value := c.Value
+	switch c.Op {
+	case ">":
+		value++
+	case "<":
+		value--
+	case "!=":
+		return ""
+	}
+	if math.IsInf(value, 0) {
+		return ""
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// normalizePin reduces a pinned version to the form runtimes report:
+// "v18" and "ruby-3.2.2" lose their prefix, sdkman's "17.0.9-tem" its vendor
+func normalizePin(pin string) string {
+	pin = strings.TrimSpace(pin)
+	for _, prefix := range []string{"v", "ruby-", "python-", "go"} {
+		pin = strings.TrimPrefix(pin, prefix)
+	}
+	if i := strings.Index(pin, "-"); i > 0 {
+		pin = pin[:i]
+	}
+	return pin
+}
+
 func checkRuntime(snap *snapshot.Snapshot, name, constraint string, fix config.FixConfig) Result {
 	result := Result{
 		Category: "runtime",
@@ -129,6 +790,13 @@ func checkRuntime(snap *snapshot.Snapshot, name, constraint string, fix config.F
 
 	result.Actual = info.Version
 
+	// Installed, but the version probe failed or timed out
+	if perr := snap.ProbeError("runtime", name); perr != nil {
+		result.Status = StatusWarn
+		result.Message = probeErrorMessage(perr)
+		return result
+	}
+
 	// Handle wildcard - any version is fine
 	if constraint == "*" {
 		result.Status = StatusPass
@@ -224,6 +892,13 @@ func checkEnvExpected(snap *snapshot.Snapshot, name, expected string, fix config
 	return result
 }
 
+func probeErrorMessage(perr *snapshot.CollectionError) string {
+	if perr.TimedOut {
+		return "probe timed out"
+	}
+	return fmt.Sprintf("probe failed: %s", perr.Message)
+}
+
 func updateCounts(report *Report, status CheckStatus) {
 	switch status {
 	case StatusPass:
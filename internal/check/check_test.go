@@ -1,6 +1,7 @@
 package check
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/GBerghoff/envdiff/internal/config"
@@ -105,3 +106,295 @@ func TestShouldIgnore(t *testing.T) {
 		})
 	}
 }
+
+func TestCheck_ProbeTimeoutIsNotMissing(t *testing.T) {
+	snap := &snapshot.Snapshot{
+		Runtime: map[string]*snapshot.RuntimeInfo{
+			"gcloud": {Version: "unknown", Path: "/usr/bin/gcloud"},
+		},
+		Packages: &snapshot.PackageInfo{
+			Manager: "apt",
+			Items:   map[string]string{},
+		},
+		CollectionErrors: []snapshot.CollectionError{
+			{Collector: "runtime", Probe: "gcloud", TimedOut: true, Message: "timed out"},
+			{Collector: "package", Probe: "nginx", TimedOut: true, Message: "timed out"},
+		},
+	}
+
+	cfg := &config.Config{
+		Runtime:  map[string]string{"gcloud": ">= 400.0.0"},
+		Packages: []string{"nginx"},
+		Fix:      map[string]config.FixConfig{},
+	}
+
+	report := Check(snap, cfg)
+
+	if report.Failed != 0 {
+		t.Errorf("expected 0 failed, got %d", report.Failed)
+	}
+	if report.Warned != 2 {
+		t.Errorf("expected 2 warned, got %d", report.Warned)
+	}
+	for _, result := range report.Results {
+		if result.Message != "probe timed out" {
+			t.Errorf("%s: Message = %q, want %q", result.Name, result.Message, "probe timed out")
+		}
+	}
+}
+
+func TestCheck_Pinned(t *testing.T) {
+	snap := &snapshot.Snapshot{
+		Runtime: map[string]*snapshot.RuntimeInfo{
+			"node":   {Version: "20.11.0", Path: "/home/me/.nvm/versions/node/v20.11.0/bin/node"},
+			"python": {Version: "3.11.7", Path: "/home/me/.pyenv/shims/python3"},
+			"java":   {Version: "17.0.9", Path: "/home/me/.sdkman/candidates/java/current/bin/java"},
+			"ruby":   {Version: "3.3.0", Path: "/usr/bin/ruby"},
+		},
+		VersionManagers: map[string]*snapshot.VersionManagerInfo{
+			"node":   {Manager: "nvm", Pinned: "v18", PinFile: ".nvmrc"},
+			"python": {Manager: "pyenv", Pinned: "3.11", PinFile: ".python-version"},
+			"java":   {Manager: "sdkman", Pinned: "17.0.9-tem", PinFile: ".sdkmanrc"},
+			"ruby":   {Pinned: "lts", PinFile: ".ruby-version"},
+		},
+	}
+
+	cfg := &config.Config{
+		Pinned: []string{"*"},
+		Fix:    map[string]config.FixConfig{},
+	}
+
+	report := Check(snap, cfg)
+
+	want := map[string]CheckStatus{
+		"node":   StatusFail,
+		"python": StatusPass,
+		"java":   StatusPass,
+		"ruby":   StatusWarn,
+	}
+	if len(report.Results) != len(want) {
+		t.Fatalf("expected %d results, got %+v", len(want), report.Results)
+	}
+	for _, result := range report.Results {
+		if result.Status != want[result.Name] {
+			t.Errorf("%s: Status = %v, want %v (%s)", result.Name, result.Status, want[result.Name], result.Message)
+		}
+		if result.Name == "node" && result.FixHint != "nvm install && nvm use" {
+			t.Errorf("node: FixHint = %q, want the nvm hint", result.FixHint)
+		}
+	}
+}
+
+func TestCheck_Shadowed(t *testing.T) {
+	snap := &snapshot.Snapshot{
+		Runtime: map[string]*snapshot.RuntimeInfo{
+			"python": {Version: "3.11.7", Path: "/home/me/.pyenv/shims/python3", Installations: []snapshot.Installation{
+				{Path: "/home/me/.pyenv/shims/python3", Version: "3.11.7", PathIndex: 0},
+				{Path: "/usr/bin/python3", Version: "3.11.2", PathIndex: 3},
+			}},
+			"node": {Version: "20.11.0", Path: "/usr/bin/node", Installations: []snapshot.Installation{
+				{Path: "/usr/bin/node", Version: "20.11.0", PathIndex: 3},
+			}},
+		},
+	}
+
+	report := Check(snap, &config.Config{Shadowed: []string{"node", "*"}})
+
+	if len(report.Results) != 2 {
+		t.Fatalf("expected node and python, got %+v", report.Results)
+	}
+	node, python := report.Results[0], report.Results[1]
+	if node.Name != "node" || node.Status != StatusPass {
+		t.Errorf("node: %+v, want a pass", node)
+	}
+	if python.Name != "python" || python.Status != StatusWarn {
+		t.Fatalf("python: %+v, want a warning", python)
+	}
+	if !strings.Contains(python.Message, "/usr/bin/python3 (3.11.2)") {
+		t.Errorf("python: Message = %q, want the shadowed install", python.Message)
+	}
+	if report.Warned != 1 || report.Failed != 0 {
+		t.Errorf("Warned = %d, Failed = %d", report.Warned, report.Failed)
+	}
+}
+
+func TestCheck_Repo(t *testing.T) {
+	snap := &snapshot.Snapshot{Repo: &snapshot.RepoInfo{
+		Head: "1a2b3c4d5e6f", Branch: "feature", Dirty: 1,
+		Submodules: map[string]string{"vendor/lib": "0b1c2d3e4f5a", "docs/theme": ""},
+		Hooks:      []string{"pre-commit"},
+		Config:     map[string]string{"core.autocrlf": "input"},
+	}}
+	cfg := &config.Config{Repo: config.RepoConfig{
+		Clean:      true,
+		Branch:     "main",
+		Submodules: true,
+		Hooks:      []string{"pre-commit"},
+		Config:     map[string]string{"core.autoCRLF": "input"},
+	}}
+
+	report := Check(snap, cfg)
+
+	want := map[string]CheckStatus{
+		"clean":                StatusFail,
+		"branch":               StatusFail,
+		"submodules":           StatusFail,
+		"hook/pre-commit":      StatusPass,
+		"config/core.autoCRLF": StatusPass,
+	}
+	if len(report.Results) != len(want) {
+		t.Fatalf("got %+v", report.Results)
+	}
+	for _, result := range report.Results {
+		if result.Category != "repo" || result.Status != want[result.Name] {
+			t.Errorf("%s: %+v, want %s", result.Name, result, want[result.Name])
+		}
+	}
+	if report.Results[2].Actual != "docs/theme" {
+		t.Errorf("submodules Actual = %q, want the uninitialized one", report.Results[2].Actual)
+	}
+
+	// Repo rules outside a checkout fail once rather than per rule
+	report = Check(&snapshot.Snapshot{}, cfg)
+	if len(report.Results) != 1 || report.Results[0].Status != StatusFail {
+		t.Errorf("got %+v, want one failure", report.Results)
+	}
+
+	// No repo rules, no repo results
+	if report := Check(snap, &config.Config{}); len(report.Results) != 0 {
+		t.Errorf("got %+v, want no results", report.Results)
+	}
+}
+
+func TestCheck_Filesystems(t *testing.T) {
+	snap := &snapshot.Snapshot{Filesystems: map[string]*snapshot.Mount{
+		snapshot.FilesystemProject: {MountPoint: "/", FreeBytes: 50 << 30, Options: []string{"relatime", "rw"}},
+		snapshot.FilesystemTmp:     {MountPoint: "/tmp", FreeBytes: 512 << 20, Options: []string{"noexec", "nosuid", "rw"}},
+	}}
+	cfg := &config.Config{Filesystems: config.FilesystemConfig{
+		MinFree:          map[string]string{"*": "1GB", "project": "10GB", "docker": "20GB"},
+		ForbiddenOptions: map[string][]string{"*": {"ro"}, "tmp": {"noexec"}},
+	}}
+
+	report := Check(snap, cfg)
+
+	want := []struct {
+		name   string
+		status CheckStatus
+	}{
+		{"project/free", StatusPass},
+		{"tmp/free", StatusFail},
+		{"docker/free", StatusWarn},
+		{"project/options", StatusPass},
+		{"tmp/options", StatusFail},
+	}
+	if len(report.Results) != len(want) {
+		t.Fatalf("got %+v", report.Results)
+	}
+	for i, w := range want {
+		result := report.Results[i]
+		if result.Category != "filesystem" || result.Name != w.name || result.Status != w.status {
+			t.Errorf("result %d: %+v, want %s %s", i, result, w.name, w.status)
+		}
+	}
+	if tmp := report.Results[1]; tmp.Actual != "512.0MB" || tmp.Expected != ">= 1GB" {
+		t.Errorf("tmp/free: Actual = %q, Expected = %q", tmp.Actual, tmp.Expected)
+	}
+	if options := report.Results[4]; !strings.Contains(options.Message, "noexec") {
+		t.Errorf("tmp/options: Message = %q, want the forbidden option", options.Message)
+	}
+}
+
+func TestCheck_Limits(t *testing.T) {
+	snap := &snapshot.Snapshot{Limits: &snapshot.LimitsInfo{
+		CPUs:         2,
+		CPUSource:    snapshot.LimitCgroup,
+		MemoryBytes:  4 << 30,
+		MemorySource: snapshot.LimitCgroup,
+		Pids:         snapshot.Unlimited,
+		OpenFiles:    1024,
+		StackBytes:   snapshot.Unlimited,
+	}}
+	cfg := &config.Config{Limits: config.LimitsConfig{
+		CPUs:      ">= 2",
+		Memory:    ">= 8GB",
+		Pids:      ">= 4096",
+		OpenFiles: ">= 65536",
+		Processes: ">= 4096",
+		Stack:     ">= 8MB",
+	}}
+
+	report := Check(snap, cfg)
+
+	want := []struct {
+		name   string
+		status CheckStatus
+	}{
+		{"cpus", StatusPass},
+		{"memory", StatusFail},
+		{"pids", StatusPass},
+		{"open_files", StatusFail},
+		{"processes", StatusWarn},
+		{"stack", StatusPass},
+	}
+	if len(report.Results) != len(want) {
+		t.Fatalf("got %+v", report.Results)
+	}
+	for i, w := range want {
+		result := report.Results[i]
+		if result.Category != "limits" || result.Name != w.name || result.Status != w.status {
+			t.Errorf("result %d: %+v, want %s %s", i, result, w.name, w.status)
+		}
+	}
+	if memory := report.Results[1]; memory.Actual != "4.0GB (cgroup)" {
+		t.Errorf("memory: Actual = %q", memory.Actual)
+	}
+	if openFiles := report.Results[3]; openFiles.FixHint != "ulimit -n 65536" {
+		t.Errorf("open_files: FixHint = %q", openFiles.FixHint)
+	}
+}
+
+func TestCheck_Sysctl(t *testing.T) {
+	snap := &snapshot.Snapshot{Sysctl: map[string]string{
+		"fs.inotify.max_user_watches":     "8192",
+		"vm.max_map_count":                "262144",
+		"net.ipv4.ip_local_port_range":    "32768 60999",
+		"net.ipv4.tcp_congestion_control": "cubic",
+	}}
+	cfg := &config.Config{
+		Sysctl: map[string]string{
+			"fs.inotify.max_user_watches":     ">= 524288",
+			"vm.max_map_count":                ">= 262144",
+			"net.ipv4.ip_local_port_range":    "1024 65000",
+			"net.ipv4.tcp_congestion_control": "bbr",
+			"net.core.somaxconn":              ">= 1024",
+			"vm.swappiness":                   "", // recorded, not checked
+		},
+		Fix: map[string]config.FixConfig{
+			"net.ipv4.tcp_congestion_control": {WrongVersion: "modprobe tcp_bbr"},
+		},
+	}
+
+	report := Check(snap, cfg)
+
+	want := []struct {
+		name    string
+		status  CheckStatus
+		fixHint string
+	}{
+		{"fs.inotify.max_user_watches", StatusFail, "sudo sysctl -w fs.inotify.max_user_watches=524288"},
+		{"net.core.somaxconn", StatusWarn, ""},
+		{"net.ipv4.ip_local_port_range", StatusFail, `sudo sysctl -w net.ipv4.ip_local_port_range="1024 65000"`},
+		{"net.ipv4.tcp_congestion_control", StatusFail, "modprobe tcp_bbr"},
+		{"vm.max_map_count", StatusPass, ""},
+	}
+	if len(report.Results) != len(want) {
+		t.Fatalf("got %+v", report.Results)
+	}
+	for i, w := range want {
+		result := report.Results[i]
+		if result.Category != "kernel" || result.Name != w.name || result.Status != w.status || result.FixHint != w.fixHint {
+			t.Errorf("result %d: %+v, want %s %s %q", i, result, w.name, w.status, w.fixHint)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package check
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/GBerghoff/envdiff/internal/config"
+)
+
+// numericConstraint is a requirement on a number, such as ">= 524288" or
+// "<= 4GB". "unlimited" compares greater than any number.
+type numericConstraint struct {
+	Op    string
+	Value float64
+}
+
+// numericOperators are matched longest first; a constraint without one
+// requires equality
+var numericOperators = []string{">=", "<=", "==", "!=", ">", "<", "="}
+
+// parseNumericConstraint parses a constraint whose value is read by parse
+func parseNumericConstraint(s string, parse func(string) (float64, error)) (numericConstraint, error) {
+	s = strings.TrimSpace(s)
+	op := "="
+	for _, candidate := range numericOperators {
+		if rest, ok := strings.CutPrefix(s, candidate); ok {
+			op, s = candidate, strings.TrimSpace(rest)
+			break
+		}
+	}
+	if op == "==" {
+		op = "="
+	}
+	if strings.EqualFold(s, "unlimited") {
+		return numericConstraint{Op: op, Value: math.Inf(1)}, nil
+	}
+	value, err := parse(s)
+	if err != nil {
+		return numericConstraint{}, err
+	}
+	return numericConstraint{Op: op, Value: value}, nil
+}
+
+// parseNumber reads a plain number, e.g. "2" or "0.5"
+func parseNumber(s string) (float64, error) {
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return n, nil
+}
+
+// parseBytes reads a size such as "4GB"
+func parseBytes(s string) (float64, error) {
+	n, err := config.ParseSize(s)
+	return float64(n), err
+}
+
+// allows reports whether value satisfies the constraint
+func (c numericConstraint) allows(value float64) bool {
+	switch c.Op {
+	case ">=":
+		return value >= c.Value
+	case "<=":
+		return value <= c.Value
+	case ">":
+		return value > c.Value
+	case "<":
+		return value < c.Value
+	case "!=":
+		return value != c.Value
+	default:
+		return value == c.Value
+	}
+}
+
+// limitValue converts a recorded limit for comparison, where a negative
+// limit is unlimited
+func limitValue(n int64) float64 {
+	if n < 0 {
+		return math.Inf(1)
+	}
+	return float64(n)
+}
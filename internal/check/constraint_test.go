@@ -0,0 +1,45 @@
+package check
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNumericConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		parse      func(string) (float64, error)
+		value      float64
+		want       bool
+	}{
+		{">= 524288", parseNumber, 524288, true},
+		{">= 524288", parseNumber, 8192, false},
+		{"> 2", parseNumber, 2, false},
+		{"< 2", parseNumber, 1.5, true},
+		{"<= 0", parseNumber, 0, true},
+		{"!= 2", parseNumber, 2, false},
+		{"== 1", parseNumber, 1, true},
+		{"1", parseNumber, 1, true},
+		{">= 4GB", parseBytes, 4 << 30, true},
+		{">= 4GB", parseBytes, 2 << 30, false},
+		{">= 65536", parseNumber, math.Inf(1), true},
+		{"= unlimited", parseNumber, math.Inf(1), true},
+		{">= unlimited", parseNumber, 1 << 20, false},
+	}
+	for _, tt := range tests {
+		constraint, err := parseNumericConstraint(tt.constraint, tt.parse)
+		if err != nil {
+			t.Errorf("%q: %v", tt.constraint, err)
+			continue
+		}
+		if got := constraint.allows(tt.value); got != tt.want {
+			t.Errorf("%q allows %v = %v, want %v", tt.constraint, tt.value, got, tt.want)
+		}
+	}
+
+	for _, invalid := range []string{">= lots", ">=", "~> 2"} {
+		if _, err := parseNumericConstraint(invalid, parseNumber); err == nil {
+			t.Errorf("%q: expected an error", invalid)
+		}
+	}
+}
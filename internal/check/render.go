@@ -52,6 +52,12 @@ func (r *Report) RenderCLI() string {
 	runtimeResults := []Result{}
 	envResults := []Result{}
 	pkgResults := []Result{}
+	pinnedResults := []Result{}
+	shadowedResults := []Result{}
+	repoResults := []Result{}
+	filesystemResults := []Result{}
+	limitsResults := []Result{}
+	kernelResults := []Result{}
 
 	for _, result := range r.Results {
 		switch result.Category {
@@ -61,6 +67,18 @@ func (r *Report) RenderCLI() string {
 			envResults = append(envResults, result)
 		case "package":
 			pkgResults = append(pkgResults, result)
+		case "pinned":
+			pinnedResults = append(pinnedResults, result)
+		case "shadowed":
+			shadowedResults = append(shadowedResults, result)
+		case "repo":
+			repoResults = append(repoResults, result)
+		case "filesystem":
+			filesystemResults = append(filesystemResults, result)
+		case "limits":
+			limitsResults = append(limitsResults, result)
+		case "kernel":
+			kernelResults = append(kernelResults, result)
 		}
 	}
 
@@ -72,6 +90,54 @@ func (r *Report) RenderCLI() string {
 		}
 	}
 
+	// Render pinned versions section
+	if len(pinnedResults) > 0 {
+		b.WriteString(headerStyle.Render("PINNED VERSIONS") + "\n")
+		for _, result := range pinnedResults {
+			b.WriteString(renderResult(result))
+		}
+	}
+
+	// Render shadowed installs section
+	if len(shadowedResults) > 0 {
+		b.WriteString(headerStyle.Render("SHADOWED INSTALLS") + "\n")
+		for _, result := range shadowedResults {
+			b.WriteString(renderResult(result))
+		}
+	}
+
+	// Render repo section
+	if len(repoResults) > 0 {
+		b.WriteString(headerStyle.Render("REPOSITORY") + "\n")
+		for _, result := range repoResults {
+			b.WriteString(renderResult(result))
+		}
+	}
+
+	// Render filesystem section
+	if len(filesystemResults) > 0 {
+		b.WriteString(headerStyle.Render("FILESYSTEMS") + "\n")
+		for _, result := range filesystemResults {
+			b.WriteString(renderResult(result))
+		}
+	}
+
+	// Render limits section
+	if len(limitsResults) > 0 {
+		b.WriteString(headerStyle.Render("RESOURCE LIMITS") + "\n")
+		for _, result := range limitsResults {
+			b.WriteString(renderResult(result))
+		}
+	}
+
+	// Render kernel parameters section
+	if len(kernelResults) > 0 {
+		b.WriteString(headerStyle.Render("KERNEL PARAMETERS") + "\n")
+		for _, result := range kernelResults {
+			b.WriteString(renderResult(result))
+		}
+	}
+
 	// Render pkg section
 	if len(pkgResults) > 0 {
 		b.WriteString(headerStyle.Render("PACKAGES") + "\n")
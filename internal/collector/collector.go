@@ -3,31 +3,105 @@
 package collector
 
 import (
+	"context"
+	"time"
+
+	"github.com/GBerghoff/envdiff/internal/secrets"
 	"github.com/GBerghoff/envdiff/internal/snapshot"
 )
 
 // Collector is the interface for all environment collectors
 type Collector interface {
-	Collect(snap *snapshot.Snapshot) error
+	Name() string
+	Collect(ctx context.Context, snap *snapshot.Snapshot) error
+}
+
+// Options controls what CollectAll probes
+type Options struct {
+	Redact   bool
+	Redactor *secrets.Redactor // nil redacts with the [REDACTED] placeholder
+	Runtimes []RuntimeDefinition
+	Packages []string
+	// EnvLists maps list-valued variables such as PATH to their separator.
+	// Absolute entries that do not exist on disk are recorded.
+	EnvLists map[string]string
+	// AllPackages records every installed system package, not just Packages
+	AllPackages bool
+	// ProjectRoot is the project whose lockfiles are recorded. Empty skips them.
+	ProjectRoot string
+	// VersionManagers records version managers and pinned runtime versions,
+	// searching ProjectRoot for pin files
+	VersionManagers bool
+	// Inventories collects language package inventories (pip, npm, ...)
+	Inventories bool
+	// Toolchain records go env, npm, pip and git configuration
+	Toolchain bool
+	// Repo records the state of the git checkout holding ProjectRoot, or
+	// the working directory when ProjectRoot is empty
+	Repo bool
+	// RepoConfigKeys are extra git settings the repo state records
+	RepoConfigKeys []string
+	// Filesystems records the mounts holding ProjectRoot, the temporary
+	// directory, the home directory and the docker data root
+	Filesystems bool
+	// SysctlKeys are kernel parameters to record in addition to the
+	// default set
+	SysctlKeys []string
+	// Timeout bounds each external probe. Zero means DefaultTimeout.
+	Timeout time.Duration
 }
 
-// CollectAll runs all collectors and populates the snapshot
-func CollectAll(snap *snapshot.Snapshot, redact bool, customRuntimes []RuntimeDefinition, packageNames []string) error {
+// CollectAll runs all collectors and populates the snapshot.
+// It only fails if ctx is canceled; probe failures are recorded in the snapshot.
+func CollectAll(ctx context.Context, snap *snapshot.Snapshot, opts Options) error {
 	collectors := []Collector{
-		&SystemCollector{},
-		&RuntimeCollector{Definitions: customRuntimes},
-		&EnvCollector{Redact: redact},
-		&NetworkCollector{},
-		&PackageCollector{PackageNames: packageNames},
+		&SystemCollector{Timeout: opts.Timeout},
+		&ExecutionCollector{},
+		&RuntimeCollector{Definitions: opts.Runtimes, Timeout: opts.Timeout},
+		&EnvCollector{Redact: opts.Redact, Redactor: opts.Redactor, Lists: opts.EnvLists},
+		&NetworkCollector{Timeout: opts.Timeout},
+		&PackageCollector{PackageNames: opts.Packages, All: opts.AllPackages, Timeout: opts.Timeout},
+		// Reads the host's cores and memory, so it must run after SystemCollector
+		&LimitsCollector{},
+		&SysctlCollector{Keys: opts.SysctlKeys},
+	}
+	if opts.ProjectRoot != "" {
+		collectors = append(collectors, &ProjectCollector{Root: opts.ProjectRoot})
+	}
+	if opts.VersionManagers {
+		// Reads the runtime paths, so it must run after RuntimeCollector
+		collectors = append(collectors, &VersionManagerCollector{Root: opts.ProjectRoot})
+	}
+	if opts.Inventories {
+		collectors = append(collectors, &InventoryCollector{Timeout: opts.Timeout})
+	}
+	if opts.Toolchain {
+		collectors = append(collectors, &ToolchainCollector{Redact: opts.Redact, Redactor: opts.Redactor, Timeout: opts.Timeout})
+	}
+	if opts.Repo {
+		dir := opts.ProjectRoot
+		if dir == "" {
+			dir = "."
+		}
+		collectors = append(collectors, &RepoCollector{Dir: dir, ConfigKeys: opts.RepoConfigKeys, Redact: opts.Redact, Redactor: opts.Redactor, Timeout: opts.Timeout})
+	}
+	if opts.Filesystems {
+		collectors = append(collectors, &FilesystemCollector{Root: opts.ProjectRoot, Timeout: opts.Timeout})
 	}
 
 	for _, c := range collectors {
-		if err := c.Collect(snap); err != nil {
-			// Log error but continue with other collectors
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := c.Collect(ctx, snap); err != nil {
+			// Record the failure but continue with other collectors
 			// We want partial snapshots rather than failing completely
-			continue
+			snap.AddError(snapshot.CollectionError{
+				Collector: c.Name(),
+				Message:   err.Error(),
+			})
 		}
 	}
 
-	return nil
+	return ctx.Err()
 }
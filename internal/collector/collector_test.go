@@ -1,7 +1,12 @@
 package collector
 
 import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/GBerghoff/envdiff/internal/snapshot"
 )
@@ -9,7 +14,7 @@ import (
 func TestCollectAll_PopulatesSnapshot(t *testing.T) {
 	snap := snapshot.New()
 
-	err := CollectAll(snap, false, nil, nil)
+	err := CollectAll(context.Background(), snap, Options{})
 	if err != nil {
 		t.Fatalf("CollectAll() error = %v", err)
 	}
@@ -28,7 +33,7 @@ func TestCollectAll_PopulatesSnapshot(t *testing.T) {
 func TestCollectAll_WithRedaction(t *testing.T) {
 	snap := snapshot.New()
 
-	err := CollectAll(snap, true, nil, nil)
+	err := CollectAll(context.Background(), snap, Options{Redact: true})
 	if err != nil {
 		t.Fatalf("CollectAll() error = %v", err)
 	}
@@ -42,7 +47,7 @@ func TestSystemCollector_Collect(t *testing.T) {
 	snap := snapshot.New()
 	collector := &SystemCollector{}
 
-	err := collector.Collect(snap)
+	err := collector.Collect(context.Background(), snap)
 	if err != nil {
 		t.Fatalf("SystemCollector.Collect() error = %v", err)
 	}
@@ -68,7 +73,7 @@ func TestEnvCollector_Collect(t *testing.T) {
 	snap := snapshot.New()
 	collector := &EnvCollector{Redact: false}
 
-	err := collector.Collect(snap)
+	err := collector.Collect(context.Background(), snap)
 	if err != nil {
 		t.Fatalf("EnvCollector.Collect() error = %v", err)
 	}
@@ -85,7 +90,7 @@ func TestEnvCollector_CollectWithRedaction(t *testing.T) {
 	snap := snapshot.New()
 	collector := &EnvCollector{Redact: true}
 
-	err := collector.Collect(snap)
+	err := collector.Collect(context.Background(), snap)
 	if err != nil {
 		t.Fatalf("EnvCollector.Collect() error = %v", err)
 	}
@@ -95,11 +100,49 @@ func TestEnvCollector_CollectWithRedaction(t *testing.T) {
 	}
 }
 
+func TestEnvCollector_RecordsRedactionRule(t *testing.T) {
+	t.Setenv("ENVDIFF_TEST_WEBHOOK", "https://hooks.slack.com/services/T000/B000/XXXXXXXX")
+	snap := snapshot.New()
+	collector := &EnvCollector{Redact: true}
+
+	if err := collector.Collect(context.Background(), snap); err != nil {
+		t.Fatalf("EnvCollector.Collect() error = %v", err)
+	}
+
+	if snap.Env["ENVDIFF_TEST_WEBHOOK"] != "[REDACTED]" {
+		t.Errorf("webhook value should be redacted, got %q", snap.Env["ENVDIFF_TEST_WEBHOOK"])
+	}
+	if rule := snap.Redactions["ENVDIFF_TEST_WEBHOOK"]; rule != "value:slack-webhook" {
+		t.Errorf("Redactions[ENVDIFF_TEST_WEBHOOK] = %q, want value:slack-webhook", rule)
+	}
+}
+
+func TestEnvCollector_RecordsMissingPaths(t *testing.T) {
+	existing := t.TempDir()
+	missing := filepath.Join(existing, "gone")
+	t.Setenv("ENVDIFF_TEST_PATH", strings.Join([]string{existing, missing, "relative/bin"}, ":"))
+	t.Setenv("ENVDIFF_TEST_FLAGS", "-mod=mod /not/a/path")
+
+	snap := snapshot.New()
+	collector := &EnvCollector{Lists: map[string]string{"ENVDIFF_TEST_PATH": ":", "ENVDIFF_TEST_FLAGS": " "}}
+	if err := collector.Collect(context.Background(), snap); err != nil {
+		t.Fatalf("EnvCollector.Collect() error = %v", err)
+	}
+
+	got := snap.MissingPaths["ENVDIFF_TEST_PATH"]
+	if len(got) != 1 || got[0] != missing {
+		t.Errorf("MissingPaths[ENVDIFF_TEST_PATH] = %v, want only %s", got, missing)
+	}
+	if got := snap.MissingPaths["ENVDIFF_TEST_FLAGS"]; len(got) != 1 || got[0] != "/not/a/path" {
+		t.Errorf("MissingPaths[ENVDIFF_TEST_FLAGS] = %v, want only the absolute entry", got)
+	}
+}
+
 func TestRuntimeCollector_Collect(t *testing.T) {
 	snap := snapshot.New()
 	collector := &RuntimeCollector{}
 
-	err := collector.Collect(snap)
+	err := collector.Collect(context.Background(), snap)
 	if err != nil {
 		t.Fatalf("RuntimeCollector.Collect() error = %v", err)
 	}
@@ -113,7 +156,7 @@ func TestNetworkCollector_Collect(t *testing.T) {
 	snap := snapshot.New()
 	collector := &NetworkCollector{}
 
-	err := collector.Collect(snap)
+	err := collector.Collect(context.Background(), snap)
 	if err != nil {
 		t.Fatalf("NetworkCollector.Collect() error = %v", err)
 	}
@@ -122,3 +165,42 @@ func TestNetworkCollector_Collect(t *testing.T) {
 		t.Error("Network should be populated")
 	}
 }
+
+func TestNetworkCollector_NoPortTools(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("ss and netstat are only probed on linux")
+	}
+	t.Setenv("PATH", t.TempDir())
+	snap := snapshot.New()
+	if err := (&NetworkCollector{}).Collect(context.Background(), snap); err != nil {
+		t.Fatalf("NetworkCollector.Collect() error = %v", err)
+	}
+	if len(snap.CollectionErrors) != 0 {
+		t.Errorf("missing tools should be skipped, got errors %+v", snap.CollectionErrors)
+	}
+	if snap.Network.ListeningPorts != nil {
+		t.Errorf("ListeningPorts = %v, want nil", snap.Network.ListeningPorts)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"abcdef", 3, "abc…"},
+		{"héllo", 2, "h…"}, // é is two bytes; cutting inside it backs off
+		{"héllo", 3, "hé…"},
+	}
+	for _, tt := range tests {
+		got := truncate(tt.s, tt.n)
+		if got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("truncate(%q, %d) = %q is not valid UTF-8", tt.s, tt.n, got)
+		}
+	}
+}
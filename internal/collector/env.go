@@ -1,7 +1,9 @@
 package collector
 
 import (
+	"context"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/GBerghoff/envdiff/internal/secrets"
@@ -11,10 +13,17 @@ import (
 // EnvCollector gathers environment variables
 type EnvCollector struct {
 	Redact bool
+	// Redactor overrides the default placeholder redaction
+	Redactor *secrets.Redactor
+	// Lists maps list-valued variables to their separator
+	Lists map[string]string
 }
 
+// Name identifies the collector in collection errors
+func (c *EnvCollector) Name() string { return "env" }
+
 // Collect gathers environment variables
-func (c *EnvCollector) Collect(snap *snapshot.Snapshot) error {
+func (c *EnvCollector) Collect(_ context.Context, snap *snapshot.Snapshot) error {
 	env := make(map[string]string)
 
 	for _, e := range os.Environ() {
@@ -25,10 +34,44 @@ func (c *EnvCollector) Collect(snap *snapshot.Snapshot) error {
 	}
 
 	if c.Redact {
-		snap.Env = secrets.RedactEnv(env)
+		redactor := c.Redactor
+		if redactor == nil {
+			redactor = &secrets.Redactor{}
+		}
+		snap.Env, snap.Redactions = redactor.RedactEnv(env)
+		if len(snap.Redactions) == 0 {
+			snap.Redactions = nil
+		}
 	} else {
 		snap.Env = env
 	}
 
+	for name, sep := range c.Lists {
+		// A redacted value must not leak through its entries
+		if value, ok := env[name]; ok && snap.Env[name] == value {
+			if missing := missingEntries(value, sep); len(missing) > 0 {
+				if snap.MissingPaths == nil {
+					snap.MissingPaths = make(map[string][]string)
+				}
+				snap.MissingPaths[name] = missing
+			}
+		}
+	}
+
 	return nil
 }
+
+// missingEntries returns the absolute paths in a list that do not exist.
+// Other entries, such as the flags in GOFLAGS, are not checked.
+func missingEntries(value, sep string) []string {
+	var missing []string
+	for _, entry := range strings.Split(value, sep) {
+		if !filepath.IsAbs(entry) {
+			continue
+		}
+		if _, err := os.Stat(entry); os.IsNotExist(err) {
+			missing = append(missing, entry)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+// DefaultTimeout bounds a single external probe when no timeout is configured
+const DefaultTimeout = 10 * time.Second
+
+// waitDelay is how long a killed probe's children may hold its output open
+const waitDelay = time.Second
+
+// maxStderrLen caps how much command output is kept in a collection error
+const maxStderrLen = 512
+
+// probeContext bounds a single probe by timeout, or DefaultTimeout if unset
+func probeContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// probeCommand builds an external command that is killed when ctx is done.
+// WaitDelay keeps wrapper scripts (gcloud, npm) from blocking on orphaned children.
+func probeCommand(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = waitDelay
+	return cmd
+}
+
+// commandError describes a failed external command for the snapshot.
+// out is the captured output, used when the error carries no stderr of its own.
+// ctx is the probe's context, used to tell timeouts apart from real failures.
+func commandError(ctx context.Context, collector, probe string, cmd *exec.Cmd, out []byte, err error) *snapshot.CollectionError {
+	cerr := &snapshot.CollectionError{
+		Collector: collector,
+		Probe:     probe,
+		Command:   strings.Join(cmd.Args, " "),
+		Message:   err.Error(),
+	}
+
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		cerr.TimedOut = true
+		cerr.Message = "timed out"
+		return cerr
+	case context.Canceled:
+		cerr.Message = "canceled"
+		return cerr
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		cerr.ExitCode = exitErr.ExitCode()
+		if len(exitErr.Stderr) > 0 {
+			out = exitErr.Stderr
+		}
+	}
+	cerr.Stderr = truncate(strings.TrimSpace(string(out)), maxStderrLen)
+
+	return cerr
+}
+
+// isExitError reports whether err means the command ran and exited non-zero,
+// as opposed to failing to start at all
+func isExitError(err error) bool {
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr)
+}
+
+// truncate cuts s to at most n bytes, backing off to a rune boundary so
+// multi-byte output is not split
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "…"
+}
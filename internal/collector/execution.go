@@ -0,0 +1,139 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+// ExecutionCollector detects where the snapshot is taken: a Docker, Podman
+// or Kubernetes container, WSL or a virtual machine, and the CI job running
+// envdiff. It sets snap.ExecutionContext and snap.CollectedVia.
+type ExecutionCollector struct {
+	// Getenv reads the CI variables; nil means os.Getenv
+	Getenv func(string) string
+}
+
+// Name identifies the collector in collection errors
+func (c *ExecutionCollector) Name() string { return "execution" }
+
+// Files container runtimes and the kernel leave behind
+var (
+	dockerEnvPath    = "/.dockerenv"
+	containerEnvPath = "/run/.containerenv" // podman
+	initCgroupPath   = "/proc/1/cgroup"
+	procVersionPath  = "/proc/version"
+	dmiDir           = "/sys/class/dmi/id"
+)
+
+// Collect fills snap.ExecutionContext, leaving it nil on a plain host
+func (c *ExecutionCollector) Collect(_ context.Context, snap *snapshot.Snapshot) error {
+	getenv := c.Getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+
+	execution := &snapshot.ExecutionContext{Container: detectContainer(getenv)}
+	execution.VM, execution.VMProduct = detectVM(getenv)
+	execution.CI, execution.JobURL, execution.RunnerImage = detectCI(getenv)
+
+	if *execution != (snapshot.ExecutionContext{}) {
+		snap.ExecutionContext = execution
+	}
+	snap.CollectedVia = snap.ExecutionContext.Via()
+	return nil
+}
+
+// detectContainer checks the marker files Docker and Podman create, then the
+// cgroup of PID 1, which names the runtime on cgroup v1 hosts
+func detectContainer(getenv func(string) string) string {
+	cgroup := readFileString(initCgroupPath)
+	switch {
+	case getenv("KUBERNETES_SERVICE_HOST") != "" || strings.Contains(cgroup, "kubepods"):
+		return snapshot.ContainerKubernetes
+	case fileExists(containerEnvPath) || getenv("container") == "podman" || strings.Contains(cgroup, "libpod"):
+		return snapshot.ContainerPodman
+	case fileExists(dockerEnvPath) || strings.Contains(cgroup, "/docker"):
+		return snapshot.ContainerDocker
+	}
+	return ""
+}
+
+// dmiHypervisors maps substrings of the DMI product name or vendor to the
+// hypervisor they identify
+var dmiHypervisors = []struct {
+	Match      string
+	Hypervisor string
+}{
+	{"VirtualBox", "virtualbox"},
+	{"VMware", "vmware"},
+	{"KVM", "kvm"},
+	{"QEMU", "qemu"},
+	{"Xen", "xen"},
+	{"Parallels", "parallels"},
+	{"Virtual Machine", "hyper-v"}, // Microsoft's product name
+	{"Google Compute Engine", "gce"},
+	{"Amazon EC2", "ec2"},
+}
+
+// detectVM recognizes WSL from its kernel and environment, and other virtual
+// machines from the DMI product name and vendor. It returns the hypervisor
+// and the product name.
+func detectVM(getenv func(string) string) (vm, product string) {
+	if getenv("WSL_DISTRO_NAME") != "" || strings.Contains(strings.ToLower(readFileString(procVersionPath)), "microsoft") {
+		return snapshot.VMWSL, ""
+	}
+	product = readFileString(filepath.Join(dmiDir, "product_name"))
+	vendor := readFileString(filepath.Join(dmiDir, "sys_vendor"))
+	for _, h := range dmiHypervisors {
+		if strings.Contains(product, h.Match) || strings.Contains(vendor, h.Match) {
+			return h.Hypervisor, product
+		}
+	}
+	return "", ""
+}
+
+// detectCI recognizes a CI provider from the variables it sets on every job,
+// returning a link to the job and the image it runs on where the provider
+// exposes them
+func detectCI(getenv func(string) string) (provider, jobURL, runnerImage string) {
+	switch {
+	case getenv("GITHUB_ACTIONS") == "true":
+		if server, repo, run := getenv("GITHUB_SERVER_URL"), getenv("GITHUB_REPOSITORY"), getenv("GITHUB_RUN_ID"); server != "" && repo != "" && run != "" {
+			jobURL = server + "/" + repo + "/actions/runs/" + run
+			if attempt := getenv("GITHUB_RUN_ATTEMPT"); attempt != "" {
+				jobURL += "/attempts/" + attempt
+			}
+		}
+		// Hosted runners name their image, e.g. ubuntu22 20240101.1
+		runnerImage = strings.TrimSpace(getenv("ImageOS") + " " + getenv("ImageVersion"))
+		return snapshot.CIGitHubActions, jobURL, runnerImage
+	case getenv("GITLAB_CI") == "true":
+		return snapshot.CIGitLab, getenv("CI_JOB_URL"), getenv("CI_JOB_IMAGE")
+	case getenv("BUILDKITE") == "true":
+		if build, job := getenv("BUILDKITE_BUILD_URL"), getenv("BUILDKITE_JOB_ID"); build != "" && job != "" {
+			jobURL = build + "#" + job
+		}
+		return snapshot.CIBuildkite, jobURL, getenv("BUILDKITE_PLUGIN_DOCKER_IMAGE")
+	case getenv("JENKINS_URL") != "":
+		return snapshot.CIJenkins, getenv("BUILD_URL"), ""
+	}
+	return "", "", ""
+}
+
+// readFileString returns a file's trimmed contents, or "" if it cannot be read
+func readFileString(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
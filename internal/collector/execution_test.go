@@ -0,0 +1,171 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+// useExecutionFiles points detection at files in a temporary directory, so
+// the machine running the tests does not leak into the result
+func useExecutionFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	old := []string{dockerEnvPath, containerEnvPath, initCgroupPath, procVersionPath, dmiDir}
+	dockerEnvPath = filepath.Join(dir, ".dockerenv")
+	containerEnvPath = filepath.Join(dir, "run/.containerenv")
+	initCgroupPath = filepath.Join(dir, "proc/1/cgroup")
+	procVersionPath = filepath.Join(dir, "proc/version")
+	dmiDir = filepath.Join(dir, "dmi")
+	t.Cleanup(func() {
+		dockerEnvPath, containerEnvPath, initCgroupPath, procVersionPath, dmiDir = old[0], old[1], old[2], old[3], old[4]
+	})
+}
+
+func envLookup(env map[string]string) func(string) string {
+	return func(key string) string { return env[key] }
+}
+
+func TestExecutionCollector_Collect(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		env   map[string]string
+		want  snapshot.ExecutionContext
+		via   string
+	}{
+		{
+			name: "plain host",
+			files: map[string]string{
+				"proc/1/cgroup": "0::/init.scope\n",
+				"proc/version":  "Linux version 6.5.0-generic",
+			},
+			via: snapshot.CollectedLocal,
+		},
+		{
+			name:  "docker",
+			files: map[string]string{".dockerenv": ""},
+			want:  snapshot.ExecutionContext{Container: snapshot.ContainerDocker},
+			via:   snapshot.ContainerDocker,
+		},
+		{
+			name:  "docker on a cgroup v1 host",
+			files: map[string]string{"proc/1/cgroup": "12:pids:/docker/4f1a\n"},
+			want:  snapshot.ExecutionContext{Container: snapshot.ContainerDocker},
+			via:   snapshot.ContainerDocker,
+		},
+		{
+			name:  "podman",
+			files: map[string]string{"run/.containerenv": ""},
+			want:  snapshot.ExecutionContext{Container: snapshot.ContainerPodman},
+			via:   snapshot.ContainerPodman,
+		},
+		{
+			name:  "kubernetes",
+			files: map[string]string{".dockerenv": ""},
+			env:   map[string]string{"KUBERNETES_SERVICE_HOST": "10.0.0.1"},
+			want:  snapshot.ExecutionContext{Container: snapshot.ContainerKubernetes},
+			via:   snapshot.ContainerKubernetes,
+		},
+		{
+			name:  "wsl",
+			files: map[string]string{"proc/version": "Linux version 5.15.133.1-microsoft-standard-WSL2"},
+			want:  snapshot.ExecutionContext{VM: snapshot.VMWSL},
+			via:   snapshot.VMWSL,
+		},
+		{
+			name:  "vm",
+			files: map[string]string{"dmi/product_name": "VirtualBox\n", "dmi/sys_vendor": "innotek GmbH\n"},
+			want:  snapshot.ExecutionContext{VM: "virtualbox", VMProduct: "VirtualBox"},
+			via:   "virtualbox",
+		},
+		{
+			name:  "github actions",
+			files: map[string]string{"dmi/product_name": "Virtual Machine", "dmi/sys_vendor": "Microsoft Corporation"},
+			env: map[string]string{
+				"GITHUB_ACTIONS":     "true",
+				"GITHUB_SERVER_URL":  "https://github.com",
+				"GITHUB_REPOSITORY":  "acme/app",
+				"GITHUB_RUN_ID":      "42",
+				"GITHUB_RUN_ATTEMPT": "2",
+				"ImageOS":            "ubuntu22",
+				"ImageVersion":       "20240101.1",
+			},
+			want: snapshot.ExecutionContext{
+				VM:          "hyper-v",
+				VMProduct:   "Virtual Machine",
+				CI:          snapshot.CIGitHubActions,
+				JobURL:      "https://github.com/acme/app/actions/runs/42/attempts/2",
+				RunnerImage: "ubuntu22 20240101.1",
+			},
+			via: snapshot.CIGitHubActions,
+		},
+		{
+			name:  "gitlab ci",
+			files: map[string]string{".dockerenv": ""},
+			env: map[string]string{
+				"GITLAB_CI":    "true",
+				"CI_JOB_URL":   "https://gitlab.com/acme/app/-/jobs/7",
+				"CI_JOB_IMAGE": "golang:1.22",
+			},
+			want: snapshot.ExecutionContext{
+				Container:   snapshot.ContainerDocker,
+				CI:          snapshot.CIGitLab,
+				JobURL:      "https://gitlab.com/acme/app/-/jobs/7",
+				RunnerImage: "golang:1.22",
+			},
+			via: snapshot.CIGitLab,
+		},
+		{
+			name: "jenkins",
+			env:  map[string]string{"JENKINS_URL": "https://ci.example.com/", "BUILD_URL": "https://ci.example.com/job/app/9/"},
+			want: snapshot.ExecutionContext{CI: snapshot.CIJenkins, JobURL: "https://ci.example.com/job/app/9/"},
+			via:  snapshot.CIJenkins,
+		},
+		{
+			name: "buildkite",
+			env: map[string]string{
+				"BUILDKITE":           "true",
+				"BUILDKITE_BUILD_URL": "https://buildkite.com/acme/app/builds/3",
+				"BUILDKITE_JOB_ID":    "0190-abcd",
+			},
+			want: snapshot.ExecutionContext{CI: snapshot.CIBuildkite, JobURL: "https://buildkite.com/acme/app/builds/3#0190-abcd"},
+			via:  snapshot.CIBuildkite,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useExecutionFiles(t, tt.files)
+			snap := snapshot.New()
+
+			collector := &ExecutionCollector{Getenv: envLookup(tt.env)}
+			if err := collector.Collect(context.Background(), snap); err != nil {
+				t.Fatal(err)
+			}
+
+			var got snapshot.ExecutionContext
+			if snap.ExecutionContext != nil {
+				got = *snap.ExecutionContext
+			}
+			if got != tt.want {
+				t.Errorf("ExecutionContext = %+v, want %+v", got, tt.want)
+			}
+			if snap.CollectedVia != tt.via {
+				t.Errorf("CollectedVia = %q, want %q", snap.CollectedVia, tt.via)
+			}
+		})
+	}
+}
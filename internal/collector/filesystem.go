@@ -0,0 +1,112 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+// FilesystemCollector records the filesystems holding the project root,
+// the temporary directory, the home directory and the docker data root:
+// type, mount options, free space and inodes, and case sensitivity
+type FilesystemCollector struct {
+	Root    string // project root; empty skips it
+	Timeout time.Duration
+}
+
+// Name identifies the collector in collection errors
+func (c *FilesystemCollector) Name() string { return "filesystem" }
+
+// errStatfsUnsupported is returned by statMount on platforms without statfs
+var errStatfsUnsupported = errors.New("filesystem statistics are not supported on this platform")
+
+// defaultDockerRoot is used when the docker daemon cannot be asked
+const defaultDockerRoot = "/var/lib/docker"
+
+// Collect fills snap.Filesystems for each role whose directory exists
+func (c *FilesystemCollector) Collect(ctx context.Context, snap *snapshot.Snapshot) error {
+	dirs := map[string]string{
+		snapshot.FilesystemProject: c.Root,
+		snapshot.FilesystemTmp:     os.TempDir(),
+		snapshot.FilesystemHome:    os.Getenv("HOME"),
+		snapshot.FilesystemDocker:  c.dockerRoot(ctx),
+	}
+
+	for _, role := range snapshot.FilesystemRoles {
+		dir := dirs[role]
+		if dir == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+
+		mount, err := statMount(dir)
+		if errors.Is(err, errStatfsUnsupported) {
+			return nil
+		}
+		if err != nil {
+			snap.AddError(snapshot.CollectionError{Collector: c.Name(), Probe: role, Message: err.Error()})
+			continue
+		}
+		mount.Path = dir
+		mount.CaseSensitive = caseSensitive(dir)
+
+		if snap.Filesystems == nil {
+			snap.Filesystems = make(map[string]*snapshot.Mount)
+		}
+		snap.Filesystems[role] = mount
+	}
+	return nil
+}
+
+// dockerRoot asks the docker daemon for its data root, falling back to the
+// default location. A daemon that is not running is not an error.
+func (c *FilesystemCollector) dockerRoot(ctx context.Context) string {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return ""
+	}
+	ctx, cancel := probeContext(ctx, c.Timeout)
+	defer cancel()
+
+	out, err := probeCommand(ctx, "docker", "info", "--format", "{{.DockerRootDir}}").Output()
+	if root := strings.TrimSpace(string(out)); err == nil && filepath.IsAbs(root) {
+		return root
+	}
+	if _, err := os.Stat(defaultDockerRoot); err == nil {
+		return defaultDockerRoot
+	}
+	return ""
+}
+
+// caseSensitive creates a lower-case temporary file in dir and looks it up
+// by its upper-case name. It returns nil when dir is not writable.
+func caseSensitive(dir string) *bool {
+	f, err := os.CreateTemp(dir, ".envdiff-case-*")
+	if err != nil {
+		return nil
+	}
+	name := f.Name()
+	_ = f.Close()
+	defer func() { _ = os.Remove(name) }()
+
+	lower, err := os.Stat(name)
+	if err != nil {
+		return nil
+	}
+	sensitive := true
+	upper, err := os.Stat(filepath.Join(dir, strings.ToUpper(filepath.Base(name))))
+	if err == nil && os.SameFile(lower, upper) {
+		sensitive = false
+	}
+	return &sensitive
+}
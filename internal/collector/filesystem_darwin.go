@@ -0,0 +1,73 @@
+//go:build darwin
+
+package collector
+
+import (
+	"fmt"
+	"sort"
+	"syscall"
+
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+// darwinMountFlags names the statfs flags from <sys/mount.h>, using the
+// Linux option names where there is one so check rules work on both
+var darwinMountFlags = []struct {
+	Flag uint32
+	Name string
+}{
+	{0x00000004, "noexec"},
+	{0x00000008, "nosuid"},
+	{0x00000010, "nodev"},
+	{0x00000400, "quarantine"},
+	{0x00001000, "local"},
+	{0x00100000, "nobrowse"},
+	{0x00200000, "noowners"},
+	{0x00800000, "journaled"},
+	{0x10000000, "noatime"},
+}
+
+// mntReadOnly is MNT_RDONLY
+const mntReadOnly = 0x00000001
+
+// statMount describes the filesystem holding dir. On macOS statfs reports
+// the mount point, type and flags along with space and inodes.
+func statMount(dir string) (*snapshot.Mount, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return nil, fmt.Errorf("statfs %s: %w", dir, err)
+	}
+
+	options := []string{"rw"}
+	if st.Flags&mntReadOnly != 0 {
+		options[0] = "ro"
+	}
+	for _, f := range darwinMountFlags {
+		if st.Flags&f.Flag != 0 {
+			options = append(options, f.Name)
+		}
+	}
+	sort.Strings(options)
+
+	return &snapshot.Mount{
+		MountPoint:  cString(st.Mntonname[:]),
+		FSType:      cString(st.Fstypename[:]),
+		Options:     options,
+		TotalBytes:  st.Blocks * uint64(st.Bsize),
+		FreeBytes:   st.Bavail * uint64(st.Bsize),
+		TotalInodes: st.Files,
+		FreeInodes:  st.Ffree,
+	}, nil
+}
+
+// cString converts a NUL-terminated C char array
+func cString(chars []int8) string {
+	b := make([]byte, 0, len(chars))
+	for _, c := range chars {
+		if c == 0 {
+			break
+		}
+		b = append(b, byte(c))
+	}
+	return string(b)
+}
@@ -0,0 +1,120 @@
+//go:build linux
+
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+// mountInfoPath lists the mounts visible to this process
+const mountInfoPath = "/proc/self/mountinfo"
+
+// statMount describes the filesystem holding dir. Space and inodes come from
+// statfs, the mount point, type and options from /proc/self/mountinfo.
+func statMount(dir string) (*snapshot.Mount, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return nil, fmt.Errorf("statfs %s: %w", dir, err)
+	}
+	mount := &snapshot.Mount{
+		TotalBytes:  st.Blocks * uint64(st.Bsize),
+		FreeBytes:   st.Bavail * uint64(st.Bsize),
+		TotalInodes: st.Files,
+		FreeInodes:  st.Ffree,
+	}
+
+	data, err := os.ReadFile(mountInfoPath)
+	if err != nil {
+		return mount, nil // space is still worth recording
+	}
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		real = dir
+	}
+	if entry := findMount(parseMountInfo(string(data)), real); entry != nil {
+		mount.MountPoint = entry.MountPoint
+		mount.FSType = entry.FSType
+		mount.Options = entry.Options
+	}
+	return mount, nil
+}
+
+// mountEntry is one line of /proc/self/mountinfo
+type mountEntry struct {
+	MountPoint string
+	FSType     string
+	Options    []string
+}
+
+// parseMountInfo parses /proc/self/mountinfo lines such as
+// "36 35 98:0 / /tmp rw,nosuid,noexec shared:1 - tmpfs tmpfs rw,size=1024k".
+// The per-mount options come before the optional fields, the filesystem
+// type after the "-" separator.
+func parseMountInfo(data string) []mountEntry {
+	var entries []mountEntry
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		sep := -1
+		for i, field := range fields {
+			if field == "-" {
+				sep = i
+				break
+			}
+		}
+		if sep < 6 || sep+1 >= len(fields) {
+			continue
+		}
+		options := strings.Split(fields[5], ",")
+		sort.Strings(options)
+		entries = append(entries, mountEntry{
+			MountPoint: unescapeMountPath(fields[4]),
+			FSType:     fields[sep+1],
+			Options:    options,
+		})
+	}
+	return entries
+}
+
+// findMount returns the mount holding path: the one with the longest mount
+// point containing it, and the last of several stacked on the same point
+func findMount(entries []mountEntry, path string) *mountEntry {
+	var best *mountEntry
+	for i, entry := range entries {
+		mp := entry.MountPoint
+		if path != mp && mp != "/" && !strings.HasPrefix(path, mp+"/") {
+			continue
+		}
+		if best == nil || len(mp) >= len(best.MountPoint) {
+			best = &entries[i]
+		}
+	}
+	return best
+}
+
+// unescapeMountPath decodes the octal escapes mountinfo uses for spaces,
+// tabs, newlines and backslashes in paths, e.g. "\040"
+func unescapeMountPath(path string) string {
+	if !strings.Contains(path, `\`) {
+		return path
+	}
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+4 <= len(path) {
+			if n, err := strconv.ParseUint(path[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+const testMountInfo = `22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw,errors=remount-ro
+25 22 0:22 / /tmp rw,nosuid,nodev,noexec shared:5 - tmpfs tmpfs rw,size=2097152k
+26 22 0:23 / /tmp rw,nosuid,nodev shared:6 - tmpfs tmpfs rw,size=8388608k
+30 22 8:2 / /home/me/My\040Projects rw,noatime - xfs /dev/sda2 rw
+31 22 8:3 / /var/lib/docker rw master:1 - overlay overlay rw
+`
+
+func TestParseMountInfo(t *testing.T) {
+	entries := parseMountInfo(testMountInfo)
+	if len(entries) != 5 {
+		t.Fatalf("got %d entries, want 5", len(entries))
+	}
+
+	tests := []struct {
+		path, mountPoint, fsType, options string
+	}{
+		{"/usr/bin", "/", "ext4", "relatime,rw"},
+		// The second mount stacked on /tmp hides the first
+		{"/tmp/build", "/tmp", "tmpfs", "nodev,nosuid,rw"},
+		{"/home/me/My Projects/app", "/home/me/My Projects", "xfs", "noatime,rw"},
+		{"/var/lib/dockerfile", "/", "ext4", "relatime,rw"},
+	}
+	for _, tt := range tests {
+		entry := findMount(entries, tt.path)
+		if entry == nil {
+			t.Errorf("%s: no mount found", tt.path)
+			continue
+		}
+		options := strings.Join(entry.Options, ",")
+		if entry.MountPoint != tt.mountPoint || entry.FSType != tt.fsType || options != tt.options {
+			t.Errorf("%s: got %s %s %s, want %s %s %s", tt.path,
+				entry.MountPoint, entry.FSType, options, tt.mountPoint, tt.fsType, tt.options)
+		}
+	}
+}
+
+func TestFilesystemCollector_Collect(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("TMPDIR", root)
+	t.Setenv("HOME", root)
+
+	snap := snapshot.New()
+	if err := (&FilesystemCollector{Root: root}).Collect(context.Background(), snap); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	for _, role := range []string{snapshot.FilesystemProject, snapshot.FilesystemTmp, snapshot.FilesystemHome} {
+		mount := snap.Filesystems[role]
+		if mount == nil {
+			t.Fatalf("%s: not recorded", role)
+		}
+		if mount.Path != root || mount.MountPoint == "" || mount.FSType == "" || mount.TotalBytes == 0 {
+			t.Errorf("%s: incomplete mount %+v", role, mount)
+		}
+		if mount.CaseSensitive == nil {
+			t.Errorf("%s: case sensitivity should be probed in a writable directory", role)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package collector
+
+import "github.com/GBerghoff/envdiff/internal/snapshot"
+
+// statMount is not implemented here, so filesystems are left out
+func statMount(string) (*snapshot.Mount, error) {
+	return nil, errStatfsUnsupported
+}
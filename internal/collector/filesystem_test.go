@@ -0,0 +1,25 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCaseSensitive_CleansUp(t *testing.T) {
+	dir := t.TempDir()
+	if caseSensitive(dir) == nil {
+		t.Fatal("expected a result for a writable directory")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("probe file left behind: %s", entries[0].Name())
+	}
+
+	if caseSensitive(filepath.Join(dir, "missing")) != nil {
+		t.Error("a directory that cannot be written should give nil")
+	}
+}
@@ -0,0 +1,273 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"debug/buildinfo"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+// InventoryCollector gathers packages installed by language package managers:
+// pip for the active python3, global npm packages, gems, cargo installs and
+// Go binaries in $GOBIN. Ecosystems whose tool is not installed are skipped.
+type InventoryCollector struct {
+	Timeout time.Duration
+}
+
+// Name identifies the collector in collection errors
+func (c *InventoryCollector) Name() string { return "inventory" }
+
+// inventoryProbe lists the packages of one ecosystem. A nil inventory means
+// the ecosystem's tool is not installed.
+type inventoryProbe func(c *InventoryCollector, ctx context.Context) (snapshot.Inventory, *snapshot.CollectionError)
+
+var inventoryProbes = map[string]inventoryProbe{
+	snapshot.InventoryPip:   (*InventoryCollector).pip,
+	snapshot.InventoryNpm:   (*InventoryCollector).npm,
+	snapshot.InventoryGem:   (*InventoryCollector).gem,
+	snapshot.InventoryCargo: (*InventoryCollector).cargo,
+	snapshot.InventoryGoBin: (*InventoryCollector).gobin,
+}
+
+// Collect gathers every ecosystem's inventory in parallel
+func (c *InventoryCollector) Collect(ctx context.Context, snap *snapshot.Snapshot) error {
+	var waitGroup sync.WaitGroup
+	var mutex sync.Mutex
+
+	for ecosystem, probe := range inventoryProbes {
+		waitGroup.Add(1)
+		go func(ecosystem string, probe inventoryProbe) {
+			defer waitGroup.Done()
+			inventory, cerr := probe(c, ctx)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if inventory != nil {
+				if snap.Inventories == nil {
+					snap.Inventories = make(map[string]snapshot.Inventory)
+				}
+				snap.Inventories[ecosystem] = inventory
+			}
+			if cerr != nil {
+				snap.AddError(*cerr)
+			}
+		}(ecosystem, probe)
+	}
+	waitGroup.Wait()
+	return nil
+}
+
+// run executes a listing command. It returns nil output and no error when
+// the command is not installed.
+func (c *InventoryCollector) run(ctx context.Context, name string, args ...string) ([]byte, *exec.Cmd, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, nil, nil
+	}
+	cmd := probeCommand(ctx, name, args...)
+	out, err := cmd.Output()
+	return out, cmd, err
+}
+
+func (c *InventoryCollector) pip(ctx context.Context) (snapshot.Inventory, *snapshot.CollectionError) {
+	ctx, cancel := probeContext(ctx, c.Timeout)
+	defer cancel()
+
+	out, cmd, err := c.run(ctx, "python3", "-m", "pip", "list", "--format=json", "--disable-pip-version-check")
+	if cmd == nil {
+		return nil, nil
+	}
+	if err != nil {
+		if ctx.Err() == nil && exitStderrContains(err, "No module named pip") {
+			return nil, nil // python3 without pip
+		}
+		return nil, commandError(ctx, c.Name(), snapshot.InventoryPip, cmd, out, err)
+	}
+	inventory, perr := parsePipList(out)
+	if perr != nil {
+		return nil, parseError(c.Name(), snapshot.InventoryPip, cmd, perr)
+	}
+	return inventory, nil
+}
+
+func (c *InventoryCollector) npm(ctx context.Context) (snapshot.Inventory, *snapshot.CollectionError) {
+	ctx, cancel := probeContext(ctx, c.Timeout)
+	defer cancel()
+
+	out, cmd, err := c.run(ctx, "npm", "ls", "--global", "--depth=0", "--json")
+	if cmd == nil {
+		return nil, nil
+	}
+	// npm ls exits non-zero for extraneous or invalid packages but still
+	// prints the tree, so only give up if there is nothing to parse
+	inventory, perr := parseNpmList(out)
+	if perr != nil {
+		if err != nil {
+			return nil, commandError(ctx, c.Name(), snapshot.InventoryNpm, cmd, out, err)
+		}
+		return nil, parseError(c.Name(), snapshot.InventoryNpm, cmd, perr)
+	}
+	return inventory, nil
+}
+
+func (c *InventoryCollector) gem(ctx context.Context) (snapshot.Inventory, *snapshot.CollectionError) {
+	ctx, cancel := probeContext(ctx, c.Timeout)
+	defer cancel()
+
+	out, cmd, err := c.run(ctx, "gem", "list", "--local")
+	if cmd == nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, commandError(ctx, c.Name(), snapshot.InventoryGem, cmd, out, err)
+	}
+	return parseGemList(out), nil
+}
+
+func (c *InventoryCollector) cargo(ctx context.Context) (snapshot.Inventory, *snapshot.CollectionError) {
+	ctx, cancel := probeContext(ctx, c.Timeout)
+	defer cancel()
+
+	out, cmd, err := c.run(ctx, "cargo", "install", "--list")
+	if cmd == nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, commandError(ctx, c.Name(), snapshot.InventoryCargo, cmd, out, err)
+	}
+	return parseCargoList(out), nil
+}
+
+// gobin reads module versions from the build info embedded in Go binaries,
+// so it needs neither the go command nor a network connection
+func (c *InventoryCollector) gobin(_ context.Context) (snapshot.Inventory, *snapshot.CollectionError) {
+	dir := goBinDir()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil // No GOBIN, nothing installed
+	}
+
+	inventory := make(snapshot.Inventory)
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := buildinfo.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue // Not a Go binary
+		}
+		version := info.Main.Version
+		if version == "" {
+			version = "(devel)"
+		}
+		inventory[entry.Name()] = version
+	}
+	return inventory, nil
+}
+
+// goBinDir returns $GOBIN, or the bin directory of the first $GOPATH entry
+func goBinDir() string {
+	if dir := os.Getenv("GOBIN"); dir != "" {
+		return dir
+	}
+	if paths := filepath.SplitList(os.Getenv("GOPATH")); len(paths) > 0 && paths[0] != "" {
+		return filepath.Join(paths[0], "bin")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, "go", "bin")
+}
+
+// parsePipList parses the output of pip list --format=json
+func parsePipList(out []byte) (snapshot.Inventory, error) {
+	var packages []struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(out, &packages); err != nil {
+		return nil, err
+	}
+	inventory := make(snapshot.Inventory, len(packages))
+	for _, p := range packages {
+		inventory[strings.ToLower(p.Name)] = p.Version
+	}
+	return inventory, nil
+}
+
+// parseNpmList parses the output of npm ls --global --depth=0 --json
+func parseNpmList(out []byte) (snapshot.Inventory, error) {
+	var tree struct {
+		Dependencies map[string]struct {
+			Version string `json:"version"`
+		} `json:"dependencies"`
+	}
+	if err := json.Unmarshal(out, &tree); err != nil {
+		return nil, err
+	}
+	inventory := make(snapshot.Inventory, len(tree.Dependencies))
+	for name, dep := range tree.Dependencies {
+		inventory[name] = dep.Version
+	}
+	return inventory, nil
+}
+
+// gemLine matches "rake (13.1.0, 12.3.3)" and "json (default: 2.7.1)"
+var gemLine = regexp.MustCompile(`^(\S+) \((.+)\)$`)
+
+// parseGemList parses gem list --local, keeping the newest installed version
+func parseGemList(out []byte) snapshot.Inventory {
+	inventory := make(snapshot.Inventory)
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() {
+		m := gemLine.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if m == nil {
+			continue
+		}
+		newest, _, _ := strings.Cut(m[2], ",")
+		inventory[m[1]] = strings.TrimPrefix(strings.TrimSpace(newest), "default: ")
+	}
+	return inventory
+}
+
+// cargoLine matches "ripgrep v14.1.0:" and "tool v0.1.0 (/src/tool):"
+var cargoLine = regexp.MustCompile(`^(\S+) v(\S+?)(?: \(.*\))?:$`)
+
+// parseCargoList parses cargo install --list. Indented lines name the
+// binaries of the crate above them and are skipped.
+func parseCargoList(out []byte) snapshot.Inventory {
+	inventory := make(snapshot.Inventory)
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() {
+		if m := cargoLine.FindStringSubmatch(scanner.Text()); m != nil {
+			inventory[m[1]] = m[2]
+		}
+	}
+	return inventory
+}
+
+// exitStderrContains reports whether a command exited non-zero with s in its stderr
+func exitStderrContains(err error, s string) bool {
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && strings.Contains(string(exitErr.Stderr), s)
+}
+
+// parseError describes a command whose output could not be parsed
+func parseError(collector, probe string, cmd *exec.Cmd, err error) *snapshot.CollectionError {
+	return &snapshot.CollectionError{
+		Collector: collector,
+		Probe:     probe,
+		Command:   strings.Join(cmd.Args, " "),
+		Message:   fmt.Sprintf("unexpected output: %v", err),
+	}
+}
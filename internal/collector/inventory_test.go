@@ -0,0 +1,115 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+func TestParsePipList(t *testing.T) {
+	out := []byte(`[{"name": "requests", "version": "2.31.0"}, {"name": "PyYAML", "version": "6.0.1"}]`)
+	inventory, err := parsePipList(out)
+	if err != nil {
+		t.Fatalf("parsePipList() error = %v", err)
+	}
+	if inventory["requests"] != "2.31.0" {
+		t.Errorf("requests = %q, want 2.31.0", inventory["requests"])
+	}
+	if inventory["pyyaml"] != "6.0.1" {
+		t.Errorf("names should be lowercased, got %v", inventory)
+	}
+
+	if _, err := parsePipList([]byte("WARNING: not json")); err == nil {
+		t.Error("expected an error for non-JSON output")
+	}
+}
+
+func TestParseNpmList(t *testing.T) {
+	out := []byte(`{
+  "name": "lib",
+  "dependencies": {
+    "npm": {"version": "10.2.4"},
+    "typescript": {"version": "5.3.3", "overridden": false}
+  }
+}`)
+	inventory, err := parseNpmList(out)
+	if err != nil {
+		t.Fatalf("parseNpmList() error = %v", err)
+	}
+	if len(inventory) != 2 || inventory["typescript"] != "5.3.3" {
+		t.Errorf("unexpected inventory %v", inventory)
+	}
+}
+
+func TestParseGemList(t *testing.T) {
+	out := []byte(`
+*** LOCAL GEMS ***
+
+bundler (2.5.3, default: 2.4.19)
+json (default: 2.7.1)
+rake (13.1.0)
+`)
+	inventory := parseGemList(out)
+	want := snapshot.Inventory{"bundler": "2.5.3", "json": "2.7.1", "rake": "13.1.0"}
+	if len(inventory) != len(want) {
+		t.Fatalf("got %v, want %v", inventory, want)
+	}
+	for name, version := range want {
+		if inventory[name] != version {
+			t.Errorf("%s = %q, want %q", name, inventory[name], version)
+		}
+	}
+}
+
+func TestParseCargoList(t *testing.T) {
+	out := []byte(`cargo-edit v0.12.2:
+    cargo-add
+    cargo-rm
+ripgrep v14.1.0:
+    rg
+mytool v0.1.0 (/home/me/src/mytool):
+    mytool
+`)
+	inventory := parseCargoList(out)
+	want := snapshot.Inventory{"cargo-edit": "0.12.2", "ripgrep": "14.1.0", "mytool": "0.1.0"}
+	if len(inventory) != len(want) {
+		t.Fatalf("got %v, want %v", inventory, want)
+	}
+	for name, version := range want {
+		if inventory[name] != version {
+			t.Errorf("%s = %q, want %q", name, inventory[name], version)
+		}
+	}
+}
+
+func TestInventoryCollector_GoBin(t *testing.T) {
+	// The test binary is itself a Go binary with build info
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skip("cannot locate test binary")
+	}
+	data, err := os.ReadFile(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "mytool"), data, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("not a binary"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOBIN", dir)
+
+	inventory, cerr := (&InventoryCollector{}).gobin(context.Background())
+	if cerr != nil {
+		t.Fatalf("gobin() error = %v", cerr)
+	}
+	if len(inventory) != 1 || inventory["mytool"] == "" {
+		t.Errorf("expected only mytool with a version, got %v", inventory)
+	}
+}
@@ -0,0 +1,203 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+// LimitsCollector records the effective CPU, memory and process limits:
+// cgroup v1 or v2 limits where they are lower than the host's resources,
+// and the soft rlimits for open files, processes and stack size. It reads
+// the host's cores and memory from snap.System, so it runs after
+// SystemCollector.
+type LimitsCollector struct{}
+
+// Name identifies the collector in collection errors
+func (c *LimitsCollector) Name() string { return "limits" }
+
+// Where the process's cgroups are read from
+var (
+	cgroupRoot     = "/sys/fs/cgroup"
+	procSelfCgroup = "/proc/self/cgroup"
+)
+
+// cgroupUnlimited is the smallest memory.limit_in_bytes cgroup v1 reports
+// for "no limit", a page-aligned max int64
+const cgroupUnlimited = 1 << 62
+
+// Collect fills snap.Limits
+func (c *LimitsCollector) Collect(_ context.Context, snap *snapshot.Snapshot) error {
+	limits := &snapshot.LimitsInfo{
+		CPUs:         float64(snap.System.CPUCores),
+		CPUSource:    snapshot.LimitHost,
+		MemoryBytes:  snap.System.MemoryBytes,
+		MemorySource: snapshot.LimitHost,
+		Pids:         snapshot.Unlimited,
+	}
+	if limits.CPUs == 0 {
+		limits.CPUs = float64(runtime.NumCPU())
+	}
+
+	if cgroup, ok := readCgroupLimits(); ok {
+		limits.CgroupVersion = cgroup.Version
+		if cgroup.CPUs > 0 && cgroup.CPUs < limits.CPUs {
+			limits.CPUs, limits.CPUSource = cgroup.CPUs, snapshot.LimitCgroup
+		}
+		if cgroup.Memory > 0 && (limits.MemoryBytes == 0 || cgroup.Memory < limits.MemoryBytes) {
+			limits.MemoryBytes, limits.MemorySource = cgroup.Memory, snapshot.LimitCgroup
+		}
+		if cgroup.Pids > 0 {
+			limits.Pids = cgroup.Pids
+		}
+	}
+
+	limits.OpenFiles, limits.Processes, limits.StackBytes = readRlimits()
+	snap.Limits = limits
+	return nil
+}
+
+// cgroupLimits holds the limits read from the cgroup hierarchy; zero means
+// no limit
+type cgroupLimits struct {
+	Version int
+	CPUs    float64
+	Memory  int64
+	Pids    int64
+}
+
+// readCgroupLimits reads the limits of the process's cgroup and its
+// ancestors, keeping the lowest of each. ok is false outside Linux or when
+// cgroups are not mounted.
+func readCgroupLimits() (cgroupLimits, bool) {
+	data, err := os.ReadFile(procSelfCgroup)
+	if err != nil {
+		return cgroupLimits{}, false
+	}
+	paths := parseProcCgroup(string(data))
+
+	// A hybrid host mounts cgroup2 under unified/, with the limits in v1
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err == nil {
+		limits := cgroupLimits{Version: 2}
+		for _, dir := range cgroupDirs(cgroupRoot, paths[""]) {
+			limits.CPUs = lowerFloat(limits.CPUs, parseCPUMax(readCgroupFile(dir, "cpu.max")))
+			limits.Memory = lowerInt(limits.Memory, parseCgroupValue(readCgroupFile(dir, "memory.max")))
+			limits.Pids = lowerInt(limits.Pids, parseCgroupValue(readCgroupFile(dir, "pids.max")))
+		}
+		return limits, true
+	}
+
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "memory")); err != nil {
+		return cgroupLimits{}, false
+	}
+	limits := cgroupLimits{Version: 1}
+	for _, dir := range cgroupDirs(filepath.Join(cgroupRoot, "cpu"), paths["cpu"]) {
+		quota := parseCgroupValue(readCgroupFile(dir, "cpu.cfs_quota_us"))
+		period := parseCgroupValue(readCgroupFile(dir, "cpu.cfs_period_us"))
+		if quota > 0 && period > 0 {
+			limits.CPUs = lowerFloat(limits.CPUs, float64(quota)/float64(period))
+		}
+	}
+	for _, dir := range cgroupDirs(filepath.Join(cgroupRoot, "memory"), paths["memory"]) {
+		limits.Memory = lowerInt(limits.Memory, parseCgroupValue(readCgroupFile(dir, "memory.limit_in_bytes")))
+	}
+	for _, dir := range cgroupDirs(filepath.Join(cgroupRoot, "pids"), paths["pids"]) {
+		limits.Pids = lowerInt(limits.Pids, parseCgroupValue(readCgroupFile(dir, "pids.max")))
+	}
+	return limits, true
+}
+
+// parseProcCgroup maps each controller in /proc/self/cgroup to the
+// process's cgroup path. Lines look like "4:memory:/docker/abc" for v1 and
+// "0::/user.slice" for v2, stored under "".
+func parseProcCgroup(data string) map[string]string {
+	paths := make(map[string]string)
+	for _, line := range strings.Split(data, "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[1] == "" {
+			paths[""] = parts[2]
+			continue
+		}
+		for _, controller := range strings.Split(parts[1], ",") {
+			paths[controller] = parts[2]
+		}
+	}
+	return paths
+}
+
+// cgroupDirs lists the directory of the cgroup at path under root and
+// those of its ancestors up to root. Inside a container the path is often
+// the host's and does not exist, leaving only root, which is the
+// container's own cgroup there.
+func cgroupDirs(root, path string) []string {
+	dir := filepath.Join(root, path)
+	if _, err := os.Stat(dir); err != nil {
+		return []string{root}
+	}
+	var dirs []string
+	for {
+		dirs = append(dirs, dir)
+		if dir == root || !strings.HasPrefix(dir, root) {
+			return dirs
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+func readCgroupFile(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// parseCPUMax parses cgroup v2 cpu.max, "<quota> <period>" or "max <period>",
+// into cores. It returns 0 for no limit.
+func parseCPUMax(value string) float64 {
+	fields := strings.Fields(value)
+	if len(fields) != 2 || fields[0] == "max" {
+		return 0
+	}
+	quota, err1 := strconv.ParseFloat(fields[0], 64)
+	period, err2 := strconv.ParseFloat(fields[1], 64)
+	if err1 != nil || err2 != nil || period <= 0 {
+		return 0
+	}
+	return quota / period
+}
+
+// parseCgroupValue parses a limit such as memory.max or pids.max. "max",
+// -1 (v1 CPU quota) and v1's near-max-int64 memory value all mean no limit,
+// returned as 0.
+func parseCgroupValue(value string) int64 {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 || n >= cgroupUnlimited {
+		return 0
+	}
+	return n
+}
+
+// lowerInt returns the lower of two limits, where 0 is no limit
+func lowerInt(a, b int64) int64 {
+	if a == 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// lowerFloat returns the lower of two CPU limits, where 0 is no limit
+func lowerFloat(a, b float64) float64 {
+	if a == 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package collector
+
+// readRlimits reports no rlimits where getrlimit does not exist
+func readRlimits() (openFiles, processes, stack int64) {
+	return 0, 0, 0
+}
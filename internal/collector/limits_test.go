@@ -0,0 +1,150 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+func TestParseProcCgroup(t *testing.T) {
+	paths := parseProcCgroup("12:pids:/docker/abc\n4:cpu,cpuacct:/docker/abc\n1:name=systemd:/init.scope\n0::/user.slice\n")
+
+	want := map[string]string{
+		"pids":         "/docker/abc",
+		"cpu":          "/docker/abc",
+		"cpuacct":      "/docker/abc",
+		"name=systemd": "/init.scope",
+		"":             "/user.slice",
+	}
+	if len(paths) != len(want) {
+		t.Fatalf("got %v", paths)
+	}
+	for controller, path := range want {
+		if paths[controller] != path {
+			t.Errorf("%q = %q, want %q", controller, paths[controller], path)
+		}
+	}
+}
+
+func TestParseCgroupLimits(t *testing.T) {
+	cpuTests := map[string]float64{
+		"200000 100000": 2,
+		"50000 100000":  0.5,
+		"max 100000":    0,
+		"":              0,
+	}
+	for value, want := range cpuTests {
+		if got := parseCPUMax(value); got != want {
+			t.Errorf("parseCPUMax(%q) = %v, want %v", value, got, want)
+		}
+	}
+
+	valueTests := map[string]int64{
+		"4294967296":          4294967296,
+		"max":                 0,
+		"-1":                  0,
+		"9223372036854771712": 0,
+	}
+	for value, want := range valueTests {
+		if got := parseCgroupValue(value); got != want {
+			t.Errorf("parseCgroupValue(%q) = %d, want %d", value, got, want)
+		}
+	}
+}
+
+// useCgroupFiles points the collector at a fake cgroup mount and
+// /proc/self/cgroup for the rest of the test
+func useCgroupFiles(t *testing.T, procCgroup string, files map[string]string) {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	proc := filepath.Join(t.TempDir(), "cgroup")
+	if err := os.WriteFile(proc, []byte(procCgroup), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldRoot, oldProc := cgroupRoot, procSelfCgroup
+	cgroupRoot, procSelfCgroup = root, proc
+	t.Cleanup(func() { cgroupRoot, procSelfCgroup = oldRoot, oldProc })
+}
+
+func TestReadCgroupLimits_V2(t *testing.T) {
+	useCgroupFiles(t, "0::/ci/job\n", map[string]string{
+		"cgroup.controllers": "cpu memory pids",
+		"ci/memory.max":      "8589934592\n",
+		"ci/cpu.max":         "max 100000\n",
+		"ci/job/cpu.max":     "200000 100000\n",
+		"ci/job/memory.max":  "max\n",
+		"ci/job/pids.max":    "512\n",
+		"other/job/pids.max": "1\n",
+	})
+
+	limits, ok := readCgroupLimits()
+	if !ok {
+		t.Fatal("expected cgroup limits")
+	}
+	want := cgroupLimits{Version: 2, CPUs: 2, Memory: 8 << 30, Pids: 512}
+	if limits != want {
+		t.Errorf("got %+v, want %+v", limits, want)
+	}
+}
+
+func TestReadCgroupLimits_V1(t *testing.T) {
+	// The process's path is the host's, which does not exist inside the
+	// container, so the controller roots are read instead
+	useCgroupFiles(t, "5:memory:/docker/abc\n4:cpu,cpuacct:/docker/abc\n3:pids:/docker/abc\n", map[string]string{
+		"cpu/cpu.cfs_quota_us":         "150000\n",
+		"cpu/cpu.cfs_period_us":        "100000\n",
+		"memory/memory.limit_in_bytes": "4294967296\n",
+		"pids/pids.max":                "max\n",
+	})
+
+	limits, ok := readCgroupLimits()
+	if !ok {
+		t.Fatal("expected cgroup limits")
+	}
+	want := cgroupLimits{Version: 1, CPUs: 1.5, Memory: 4 << 30}
+	if limits != want {
+		t.Errorf("got %+v, want %+v", limits, want)
+	}
+}
+
+func TestLimitsCollector_Collect(t *testing.T) {
+	useCgroupFiles(t, "0::/\n", map[string]string{
+		"cgroup.controllers": "cpu memory pids",
+		"cpu.max":            "200000 100000\n",
+		"memory.max":         "137438953472\n", // above the host's memory
+	})
+	snap := snapshot.New()
+	snap.System = snapshot.SystemInfo{CPUCores: 16, MemoryGB: 63, MemoryBytes: 64<<30 - 1<<20}
+
+	collector := &LimitsCollector{}
+	if err := collector.Collect(context.Background(), snap); err != nil {
+		t.Fatal(err)
+	}
+
+	limits := snap.Limits
+	if limits == nil {
+		t.Fatal("expected limits")
+	}
+	if limits.CPUs != 2 || limits.CPUSource != snapshot.LimitCgroup {
+		t.Errorf("cpus = %v from %s, want 2 from the cgroup", limits.CPUs, limits.CPUSource)
+	}
+	if limits.MemoryBytes != 64<<30-1<<20 || limits.MemorySource != snapshot.LimitHost {
+		t.Errorf("memory = %d from %s, want the host's exact memory", limits.MemoryBytes, limits.MemorySource)
+	}
+	if limits.Pids != snapshot.Unlimited {
+		t.Errorf("pids = %d, want unlimited", limits.Pids)
+	}
+}
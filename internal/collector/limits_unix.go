@@ -0,0 +1,37 @@
+//go:build linux || darwin
+
+package collector
+
+import (
+	"math"
+	"runtime"
+	"syscall"
+
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+// rlimitNproc is RLIMIT_NPROC, which the syscall package only names on Linux
+func rlimitNproc() int {
+	if runtime.GOOS == "darwin" {
+		return 7
+	}
+	return 6
+}
+
+// readRlimits returns the soft limits behind ulimit -n, -u and -s
+func readRlimits() (openFiles, processes, stack int64) {
+	return getrlimit(syscall.RLIMIT_NOFILE), getrlimit(rlimitNproc()), getrlimit(syscall.RLIMIT_STACK)
+}
+
+// getrlimit returns a soft limit, snapshot.Unlimited for RLIM_INFINITY, or
+// 0 if it cannot be read
+func getrlimit(resource int) int64 {
+	var rl syscall.Rlimit
+	if err := syscall.Getrlimit(resource, &rl); err != nil {
+		return 0
+	}
+	if rl.Cur >= math.MaxInt64 {
+		return snapshot.Unlimited
+	}
+	return int64(rl.Cur)
+}
@@ -0,0 +1,478 @@
+package collector
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseGoSum reports the versions go.mod requires directly. With a tidy
+// module these are the selected versions; go.sum itself does not say which
+// modules are direct, so only the fingerprint comes from it.
+func parseGoSum(dir string, _ []byte) (map[string]string, error) {
+	data, err := readManifest(dir, "go.mod")
+	if data == nil {
+		return nil, err
+	}
+
+	deps := make(map[string]string)
+	inBlock := false
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "require (":
+			inBlock = true
+			continue
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case strings.HasPrefix(line, "require "):
+			line = strings.TrimPrefix(line, "require ")
+		case !inBlock:
+			continue
+		}
+		if strings.Contains(line, "// indirect") {
+			continue
+		}
+		if fields := strings.Fields(line); len(fields) >= 2 {
+			deps[fields[0]] = fields[1]
+		}
+	}
+	return deps, nil
+}
+
+// parsePackageLock reads package-lock.json. Lockfile v2 and v3 record the
+// project's own dependencies under packages[""]; v1 needs package.json.
+func parsePackageLock(dir string, data []byte) (map[string]string, error) {
+	var lock struct {
+		Packages map[string]struct {
+			Version              string            `json:"version"`
+			Dependencies         map[string]string `json:"dependencies"`
+			DevDependencies      map[string]string `json:"devDependencies"`
+			OptionalDependencies map[string]string `json:"optionalDependencies"`
+		} `json:"packages"`
+		Dependencies map[string]struct {
+			Version string `json:"version"`
+		} `json:"dependencies"`
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+
+	deps := make(map[string]string)
+	if root, ok := lock.Packages[""]; ok {
+		for _, ranges := range []map[string]string{root.Dependencies, root.DevDependencies, root.OptionalDependencies} {
+			for name := range ranges {
+				if pkg, ok := lock.Packages["node_modules/"+name]; ok {
+					deps[name] = pkg.Version
+				}
+			}
+		}
+		return deps, nil
+	}
+
+	direct, err := packageJSONDeps(dir)
+	for name := range direct {
+		if dep, ok := lock.Dependencies[name]; ok {
+			deps[name] = dep.Version
+		}
+	}
+	return deps, err
+}
+
+// parseYarnLock reads yarn.lock in the classic (v1) or berry format. Entries
+// are keyed by the ranges that resolved to them, so the ranges in
+// package.json select the direct dependencies.
+func parseYarnLock(dir string, data []byte) (map[string]string, error) {
+	direct, err := packageJSONDeps(dir)
+	if direct == nil {
+		return nil, err
+	}
+
+	resolved := make(map[string]string) // "name@range" -> version
+	var descriptors []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			descriptors = descriptors[:0]
+			for _, d := range strings.Split(strings.TrimSuffix(line, ":"), ",") {
+				descriptors = append(descriptors, strings.Trim(strings.TrimSpace(d), `"`))
+			}
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "version ") && !strings.HasPrefix(trimmed, "version:") {
+			continue
+		}
+		version := strings.Trim(strings.TrimSpace(trimmed[len("version")+1:]), `"`)
+		for _, d := range descriptors {
+			resolved[d] = version
+		}
+	}
+
+	deps := make(map[string]string)
+	for name, spec := range direct {
+		for _, key := range []string{name + "@" + spec, name + "@npm:" + spec} {
+			if version, ok := resolved[key]; ok {
+				deps[name] = version
+				break
+			}
+		}
+	}
+	return deps, nil
+}
+
+// parsePnpmLock reads pnpm-lock.yaml. Version 5 maps names straight to
+// versions; later versions nest them with the specifier, under importers
+// for version 9.
+func parsePnpmLock(_ string, data []byte) (map[string]string, error) {
+	type importer struct {
+		Dependencies         map[string]any `yaml:"dependencies"`
+		DevDependencies      map[string]any `yaml:"devDependencies"`
+		OptionalDependencies map[string]any `yaml:"optionalDependencies"`
+	}
+	var lock struct {
+		Importers map[string]importer `yaml:"importers"`
+		importer  `yaml:",inline"`
+	}
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+
+	root := lock.importer
+	if project, ok := lock.Importers["."]; ok {
+		root = project
+	}
+
+	deps := make(map[string]string)
+	for _, group := range []map[string]any{root.Dependencies, root.DevDependencies, root.OptionalDependencies} {
+		for name, v := range group {
+			var version string
+			switch v := v.(type) {
+			case string:
+				version = v
+			case map[string]any:
+				version, _ = v["version"].(string)
+			}
+			// Drop peer dependency suffixes: 1.0.0(react@18.2.0) or 1.0.0_react@18.2.0
+			if i := strings.IndexAny(version, "(_"); i > 0 {
+				version = version[:i]
+			}
+			if version != "" {
+				deps[name] = version
+			}
+		}
+	}
+	return deps, nil
+}
+
+// parsePoetryLock reads poetry.lock, using pyproject.toml to pick out the
+// direct dependencies
+func parsePoetryLock(dir string, data []byte) (map[string]string, error) {
+	direct, err := pyprojectDeps(dir)
+	if direct == nil {
+		return nil, err
+	}
+
+	deps := make(map[string]string)
+	for _, pkg := range parseTOMLPackages(data) {
+		name := normalizePythonName(pkg.Name)
+		if direct[name] {
+			deps[name] = pkg.Version
+		}
+	}
+	return deps, nil
+}
+
+// requirementName ends where a version specifier, extra or marker begins
+var requirementName = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(\[[^\]]*\])?\s*(.*)$`)
+
+// parseRequirements reads a pip requirements file. Pinned requirements
+// (name==1.2.3) record the version, others their specifier, e.g. ">=2.0".
+func parseRequirements(_ string, data []byte) (map[string]string, error) {
+	deps := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(line), "\\"))
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue // options such as -r, -e, --hash
+		}
+		if i := strings.Index(line, ";"); i >= 0 {
+			line = line[:i] // environment marker
+		}
+		if i := strings.Index(line, " --"); i >= 0 {
+			line = line[:i] // per-requirement options such as --hash
+		}
+		m := requirementName.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		spec := strings.ReplaceAll(m[3], " ", "")
+		switch {
+		case strings.HasPrefix(spec, "==="):
+			spec = spec[3:]
+		case strings.HasPrefix(spec, "==") && !strings.Contains(spec, ","):
+			spec = spec[2:]
+		case spec == "":
+			spec = "*"
+		}
+		deps[normalizePythonName(m[1])] = spec
+	}
+	return deps, nil
+}
+
+// parseCargoLock reads Cargo.lock. Workspace members are the packages
+// without a source, and their dependency lists name the direct dependencies
+// as "name" or, when several versions are locked, "name version".
+func parseCargoLock(_ string, data []byte) (map[string]string, error) {
+	packages := parseTOMLPackages(data)
+	versions := make(map[string][]string)
+	members := make(map[string]bool)
+	for _, pkg := range packages {
+		versions[pkg.Name] = append(versions[pkg.Name], pkg.Version)
+		if pkg.Source == "" {
+			members[pkg.Name] = true
+		}
+	}
+
+	deps := make(map[string]string)
+	for _, pkg := range packages {
+		if pkg.Source != "" {
+			continue
+		}
+		for _, dep := range pkg.Dependencies {
+			fields := strings.Fields(dep)
+			name := fields[0]
+			if members[name] {
+				continue
+			}
+			switch {
+			case len(fields) >= 2:
+				deps[name] = fields[1]
+			case len(versions[name]) == 1:
+				deps[name] = versions[name][0]
+			}
+		}
+	}
+	return deps, nil
+}
+
+// parseGemfileLock reads Gemfile.lock: the DEPENDENCIES section lists the
+// direct gems, the specs of the GEM, GIT and PATH sections their versions
+func parseGemfileLock(_ string, data []byte) (map[string]string, error) {
+	specs := make(map[string]string)
+	var direct []string
+	section := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			section = line
+			continue
+		}
+		switch {
+		case section == "DEPENDENCIES" && strings.HasPrefix(line, "  ") && !strings.HasPrefix(line, "   "):
+			name, _, _ := strings.Cut(strings.TrimSpace(line), " ")
+			direct = append(direct, strings.TrimSuffix(name, "!"))
+		case section != "DEPENDENCIES" && strings.HasPrefix(line, "    ") && !strings.HasPrefix(line, "     "):
+			name, version, ok := strings.Cut(strings.TrimSpace(line), " ")
+			if ok {
+				specs[name] = strings.Trim(version, "()")
+			}
+		}
+	}
+
+	deps := make(map[string]string)
+	for _, name := range direct {
+		if version, ok := specs[name]; ok {
+			deps[name] = version
+		}
+	}
+	return deps, nil
+}
+
+// readManifest reads a file next to a lockfile. A missing file is not an
+// error and returns nil data.
+func readManifest(dir, name string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// packageJSONDeps returns the version ranges package.json declares for its
+// direct dependencies, or nil if there is no package.json
+func packageJSONDeps(dir string) (map[string]string, error) {
+	data, err := readManifest(dir, "package.json")
+	if data == nil {
+		return nil, err
+	}
+	var manifest struct {
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	deps := make(map[string]string)
+	for _, group := range []map[string]string{manifest.Dependencies, manifest.DevDependencies, manifest.OptionalDependencies} {
+		for name, spec := range group {
+			deps[name] = spec
+		}
+	}
+	return deps, nil
+}
+
+// pyprojectDeps returns the normalized names of the dependencies declared in
+// pyproject.toml, from [tool.poetry.*dependencies] tables or the PEP 621
+// [project] dependencies array, or nil if there is no pyproject.toml
+func pyprojectDeps(dir string) (map[string]bool, error) {
+	data, err := readManifest(dir, "pyproject.toml")
+	if data == nil {
+		return nil, err
+	}
+
+	deps := make(map[string]bool)
+	table := ""
+	inArray := false
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if inArray {
+			for _, requirement := range tomlStrings(line) {
+				if m := requirementName.FindStringSubmatch(requirement); m != nil {
+					deps[normalizePythonName(m[1])] = true
+				}
+			}
+			inArray = !closesArray(line)
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			table = strings.Trim(line, "[] ")
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"`)
+		switch {
+		case table == "tool.poetry.dependencies" || table == "tool.poetry.dev-dependencies" ||
+			(strings.HasPrefix(table, "tool.poetry.group.") && strings.HasSuffix(table, ".dependencies")):
+			if key != "python" {
+				deps[normalizePythonName(key)] = true
+			}
+		case table == "project" && key == "dependencies":
+			for _, requirement := range tomlStrings(value) {
+				if m := requirementName.FindStringSubmatch(requirement); m != nil {
+					deps[normalizePythonName(m[1])] = true
+				}
+			}
+			inArray = !closesArray(value)
+		}
+	}
+	return deps, scanner.Err()
+}
+
+// tomlPackage is a [[package]] entry of Cargo.lock or poetry.lock
+type tomlPackage struct {
+	Name         string
+	Version      string
+	Source       string
+	Dependencies []string
+}
+
+// parseTOMLPackages reads the [[package]] entries of a lockfile. It handles
+// only what Cargo and Poetry write: string values and arrays of strings.
+func parseTOMLPackages(data []byte) []tomlPackage {
+	var packages []tomlPackage
+	var current *tomlPackage
+	arrayKey := ""
+	inArray := false
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if inArray {
+			if current != nil && arrayKey == "dependencies" {
+				current.Dependencies = append(current.Dependencies, tomlStrings(line)...)
+			}
+			inArray = !closesArray(line)
+			continue
+		}
+		if strings.HasPrefix(line, "[") && !strings.Contains(line, "=") {
+			if line == "[[package]]" {
+				packages = append(packages, tomlPackage{})
+				current = &packages[len(packages)-1]
+			} else {
+				current = nil // a subtable such as [package.dependencies]
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || current == nil {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if strings.HasPrefix(value, "[") {
+			arrayKey = key
+			if key == "dependencies" {
+				current.Dependencies = append(current.Dependencies, tomlStrings(value)...)
+			}
+			inArray = !closesArray(value)
+			continue
+		}
+		switch key {
+		case "name":
+			current.Name = strings.Trim(value, `"`)
+		case "version":
+			current.Version = strings.Trim(value, `"`)
+		case "source":
+			current.Source = strings.Trim(value, `"`)
+		}
+	}
+	return packages
+}
+
+var tomlString = regexp.MustCompile(`"([^"]*)"`)
+
+// closesArray reports whether a line ends a TOML array. Brackets inside
+// strings, such as extras in "requests[socks]", do not count.
+func closesArray(line string) bool {
+	return strings.Contains(tomlString.ReplaceAllString(line, ""), "]")
+}
+
+// tomlStrings returns the quoted strings on a line
+func tomlStrings(line string) []string {
+	var values []string
+	for _, m := range tomlString.FindAllStringSubmatch(line, -1) {
+		values = append(values, m[1])
+	}
+	return values
+}
+
+var pythonNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// normalizePythonName normalizes a Python package name as PEP 503 does
+func normalizePythonName(name string) string {
+	return pythonNameSeparators.ReplaceAllString(strings.ToLower(name), "-")
+}
@@ -0,0 +1,335 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+// writeProject creates a project root holding the given files
+func writeProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func assertDeps(t *testing.T, got, want map[string]string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for name, version := range want {
+		if got[name] != version {
+			t.Errorf("%s = %q, want %q", name, got[name], version)
+		}
+	}
+}
+
+const testPackageJSON = `{
+  "dependencies": {"lodash": "^4.17.20", "@types/node": "^20.0.0"},
+  "devDependencies": {"typescript": "~5.3.0"}
+}`
+
+func TestParseGoSum(t *testing.T) {
+	dir := writeProject(t, map[string]string{"go.mod": `module example.com/app
+
+go 1.22
+
+require github.com/spf13/cobra v1.8.0
+
+require (
+	gopkg.in/yaml.v3 v3.0.1
+	github.com/spf13/pflag v1.0.5 // indirect
+)
+`})
+	deps, err := parseGoSum(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDeps(t, deps, map[string]string{"github.com/spf13/cobra": "v1.8.0", "gopkg.in/yaml.v3": "v3.0.1"})
+}
+
+func TestParsePackageLock(t *testing.T) {
+	v3 := `{
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"dependencies": {"lodash": "^4.17.20"}, "devDependencies": {"typescript": "~5.3.0"}},
+    "node_modules/lodash": {"version": "4.17.21"},
+    "node_modules/typescript": {"version": "5.3.3"},
+    "node_modules/transitive": {"version": "1.0.0"}
+  }
+}`
+	deps, err := parsePackageLock(t.TempDir(), []byte(v3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDeps(t, deps, map[string]string{"lodash": "4.17.21", "typescript": "5.3.3"})
+
+	// Version 1 has no root entry, so package.json names the direct dependencies
+	v1 := `{"lockfileVersion": 1, "dependencies": {"lodash": {"version": "4.17.20"}, "transitive": {"version": "1.0.0"}}}`
+	dir := writeProject(t, map[string]string{"package.json": testPackageJSON})
+	deps, err = parsePackageLock(dir, []byte(v1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDeps(t, deps, map[string]string{"lodash": "4.17.20"})
+}
+
+func TestParseYarnLock(t *testing.T) {
+	dir := writeProject(t, map[string]string{"package.json": testPackageJSON})
+
+	classic := `# yarn lockfile v1
+
+"@types/node@^20.0.0":
+  version "20.11.5"
+  resolved "https://registry.yarnpkg.com/@types/node/-/node-20.11.5.tgz"
+
+lodash@^4.17.20, lodash@^4.17.21:
+  version "4.17.21"
+
+typescript@~5.3.0:
+  version "5.3.3"
+`
+	deps, err := parseYarnLock(dir, []byte(classic))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDeps(t, deps, map[string]string{"@types/node": "20.11.5", "lodash": "4.17.21", "typescript": "5.3.3"})
+
+	berry := `__metadata:
+  version: 8
+
+"lodash@npm:^4.17.20":
+  version: 4.17.21
+  resolution: "lodash@npm:4.17.21"
+`
+	deps, err = parseYarnLock(dir, []byte(berry))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDeps(t, deps, map[string]string{"lodash": "4.17.21"})
+}
+
+func TestParsePnpmLock(t *testing.T) {
+	v9 := `lockfileVersion: '9.0'
+importers:
+  .:
+    dependencies:
+      react-dom:
+        specifier: ^18.2.0
+        version: 18.2.0(react@18.2.0)
+    devDependencies:
+      typescript:
+        specifier: ~5.3.0
+        version: 5.3.3
+`
+	deps, err := parsePnpmLock("", []byte(v9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDeps(t, deps, map[string]string{"react-dom": "18.2.0", "typescript": "5.3.3"})
+
+	v5 := `lockfileVersion: 5.4
+dependencies:
+  lodash: 4.17.21
+`
+	deps, err = parsePnpmLock("", []byte(v5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDeps(t, deps, map[string]string{"lodash": "4.17.21"})
+}
+
+func TestParsePoetryLock(t *testing.T) {
+	dir := writeProject(t, map[string]string{"pyproject.toml": `[tool.poetry]
+name = "app"
+
+[tool.poetry.dependencies]
+python = "^3.11"
+requests = "^2.31"
+Flask_Login = "*"
+
+[tool.poetry.group.dev.dependencies]
+pytest = "^8.0"
+`})
+	lock := `[[package]]
+name = "requests"
+version = "2.31.0"
+description = "Python HTTP for Humans."
+files = [
+    {file = "requests-2.31.0.tar.gz", hash = "sha256:abc"},
+]
+
+[package.dependencies]
+certifi = ">=2017.4.17"
+
+[[package]]
+name = "certifi"
+version = "2024.2.2"
+
+[[package]]
+name = "flask-login"
+version = "0.6.3"
+
+[[package]]
+name = "pytest"
+version = "8.0.1"
+
+[metadata]
+lock-version = "2.0"
+`
+	deps, err := parsePoetryLock(dir, []byte(lock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDeps(t, deps, map[string]string{"requests": "2.31.0", "flask-login": "0.6.3", "pytest": "8.0.1"})
+}
+
+func TestPyprojectDeps_PEP621(t *testing.T) {
+	dir := writeProject(t, map[string]string{"pyproject.toml": `[project]
+name = "app"
+dependencies = [
+    "requests[socks]>=2.31",
+    "click==8.1.7; python_version >= '3.8'",
+]
+
+[project.optional-dependencies]
+dev = ["pytest"]
+`})
+	deps, err := pyprojectDeps(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deps) != 2 || !deps["requests"] || !deps["click"] {
+		t.Errorf("got %v, want requests and click", deps)
+	}
+}
+
+func TestParseRequirements(t *testing.T) {
+	reqs := `# Pinned
+requests==2.31.0
+Django>=4.2,<5
+numpy
+-r base.txt
+-e git+https://github.com/org/pkg.git#egg=pkg
+uvicorn[standard]==0.27.0 ; python_version >= "3.8"
+cryptography==42.0.2 \
+    --hash=sha256:abc
+`
+	deps, err := parseRequirements("", []byte(reqs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDeps(t, deps, map[string]string{
+		"requests":     "2.31.0",
+		"django":       ">=4.2,<5",
+		"numpy":        "*",
+		"uvicorn":      "0.27.0",
+		"cryptography": "42.0.2",
+	})
+}
+
+func TestParseCargoLock(t *testing.T) {
+	lock := `version = 3
+
+[[package]]
+name = "app"
+version = "0.1.0"
+dependencies = [
+ "rand 0.8.5",
+ "serde",
+ "util",
+]
+
+[[package]]
+name = "util"
+version = "0.1.0"
+
+[[package]]
+name = "rand"
+version = "0.7.3"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+
+[[package]]
+name = "rand"
+version = "0.8.5"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+
+[[package]]
+name = "serde"
+version = "1.0.196"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+`
+	deps, err := parseCargoLock("", []byte(lock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDeps(t, deps, map[string]string{"rand": "0.8.5", "serde": "1.0.196"})
+}
+
+func TestParseGemfileLock(t *testing.T) {
+	lock := `GEM
+  remote: https://rubygems.org/
+  specs:
+    actionpack (7.1.3)
+      rack (>= 2.2.4)
+    rack (3.0.9)
+    rails (7.1.3)
+      actionpack (= 7.1.3)
+
+PLATFORMS
+  x86_64-linux
+
+DEPENDENCIES
+  rack (~> 3.0)
+  rails
+
+BUNDLED WITH
+   2.5.5
+`
+	deps, err := parseGemfileLock("", []byte(lock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDeps(t, deps, map[string]string{"rack": "3.0.9", "rails": "7.1.3"})
+}
+
+func TestProjectCollector_Collect(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"package.json":         testPackageJSON,
+		"yarn.lock":            "lodash@^4.17.20:\n  version \"4.17.21\"\n",
+		"requirements-dev.txt": "pytest==8.0.1\n",
+		"README.md":            "not a lockfile",
+	})
+
+	snap := snapshot.New()
+	if err := (&ProjectCollector{Root: dir}).Collect(context.Background(), snap); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if snap.Project == nil {
+		t.Fatal("expected a project section")
+	}
+	if len(snap.Project.Lockfiles) != 2 {
+		t.Fatalf("expected yarn.lock and requirements-dev.txt, got %v", snap.Project.Lockfiles)
+	}
+	yarn := snap.Project.Lockfiles["yarn.lock"]
+	if len(yarn.SHA256) != 64 || yarn.Dependencies["lodash"] != "4.17.21" {
+		t.Errorf("unexpected yarn.lock entry %+v", yarn)
+	}
+
+	empty := snapshot.New()
+	if err := (&ProjectCollector{Root: t.TempDir()}).Collect(context.Background(), empty); err != nil {
+		t.Fatal(err)
+	}
+	if empty.Project != nil {
+		t.Error("a root without lockfiles should leave the project section out")
+	}
+}
@@ -2,34 +2,58 @@ package collector
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"os"
 	"os/exec"
 	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/GBerghoff/envdiff/internal/snapshot"
 )
 
 // NetworkCollector gathers network-related information
-type NetworkCollector struct{}
+type NetworkCollector struct {
+	Timeout time.Duration
+}
+
+// Name identifies the collector in collection errors
+func (c *NetworkCollector) Name() string { return "network" }
 
 // Collect gathers network information
-func (c *NetworkCollector) Collect(snap *snapshot.Snapshot) error {
+func (c *NetworkCollector) Collect(ctx context.Context, snap *snapshot.Snapshot) error {
+	hosts, cerr := c.getHosts()
+	if cerr != nil {
+		snap.AddError(*cerr)
+	}
+	ports, cerr := c.getListeningPorts(ctx)
+	if cerr != nil {
+		snap.AddError(*cerr)
+	}
+
 	snap.Network = &snapshot.NetworkInfo{
-		Hosts:          c.getHosts(),
-		ListeningPorts: c.getListeningPorts(),
+		Hosts:          hosts,
+		ListeningPorts: ports,
 	}
 	return nil
 }
 
-func (c *NetworkCollector) getHosts() map[string]string {
+func (c *NetworkCollector) getHosts() (map[string]string, *snapshot.CollectionError) {
 	hosts := make(map[string]string)
 
 	file, err := os.Open("/etc/hosts")
 	if err != nil {
-		return hosts
+		if os.IsNotExist(err) {
+			return hosts, nil
+		}
+		return hosts, &snapshot.CollectionError{
+			Collector: c.Name(),
+			Probe:     "hosts",
+			Message:   err.Error(),
+		}
 	}
 	defer func() { _ = file.Close() }()
 
@@ -54,30 +78,46 @@ func (c *NetworkCollector) getHosts() map[string]string {
 		}
 	}
 
-	return hosts
+	return hosts, nil
 }
 
-func (c *NetworkCollector) getListeningPorts() []int {
+func (c *NetworkCollector) getListeningPorts(ctx context.Context) ([]int, *snapshot.CollectionError) {
 	switch runtime.GOOS {
 	case "linux":
-		return c.getLinuxListeningPorts()
+		return c.getLinuxListeningPorts(ctx)
 	case "darwin":
-		return c.getMacListeningPorts()
+		return c.getMacListeningPorts(ctx)
 	default:
-		return []int{}
+		return []int{}, nil
 	}
 }
 
-func (c *NetworkCollector) getLinuxListeningPorts() []int {
+func (c *NetworkCollector) getLinuxListeningPorts(ctx context.Context) ([]int, *snapshot.CollectionError) {
 	var ports []int
 
-	// Try ss first, fall back to netstat
-	out, err := exec.Command("ss", "-tlnp").Output()
-	if err != nil {
-		out, err = exec.Command("netstat", "-tlnp").Output()
-		if err != nil {
-			return ports
+	ctx, cancel := probeContext(ctx, c.Timeout)
+	defer cancel()
+
+	// Try ss first, fall back to netstat. With neither installed there is
+	// nothing to report.
+	var cmd *exec.Cmd
+	var out []byte
+	var err error
+	for _, tool := range []string{"ss", "netstat"} {
+		if _, lookErr := exec.LookPath(tool); lookErr != nil {
+			continue
 		}
+		cmd = probeCommand(ctx, tool, "-tlnp")
+		out, err = cmd.Output()
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+	}
+	if cmd == nil {
+		return nil, nil
+	}
+	if err != nil {
+		return ports, commandError(ctx, c.Name(), "listening_ports", cmd, out, err)
 	}
 
 	// Parse output for listening ports
@@ -96,15 +136,24 @@ func (c *NetworkCollector) getLinuxListeningPorts() []int {
 		}
 	}
 
-	return ports
+	return ports, nil
 }
 
-func (c *NetworkCollector) getMacListeningPorts() []int {
+func (c *NetworkCollector) getMacListeningPorts(ctx context.Context) ([]int, *snapshot.CollectionError) {
 	var ports []int
 
-	out, err := exec.Command("lsof", "-iTCP", "-sTCP:LISTEN", "-nP").Output()
+	ctx, cancel := probeContext(ctx, c.Timeout)
+	defer cancel()
+
+	cmd := probeCommand(ctx, "lsof", "-iTCP", "-sTCP:LISTEN", "-nP")
+	out, err := cmd.Output()
 	if err != nil {
-		return ports
+		// lsof exits 1 with no output when nothing is listening
+		var exitErr *exec.ExitError
+		if ctx.Err() == nil && errors.As(err, &exitErr) && len(exitErr.Stderr) == 0 && len(out) == 0 {
+			return ports, nil
+		}
+		return ports, commandError(ctx, c.Name(), "listening_ports", cmd, out, err)
 	}
 
 	// Parse lsof output
@@ -122,5 +171,5 @@ func (c *NetworkCollector) getMacListeningPorts() []int {
 		}
 	}
 
-	return ports
+	return ports, nil
 }
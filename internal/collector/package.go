@@ -1,10 +1,12 @@
 package collector
 
 import (
+	"context"
 	"os/exec"
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/GBerghoff/envdiff/internal/snapshot"
 )
@@ -12,11 +14,28 @@ import (
 // PackageCollector gathers information about installed system packages
 type PackageCollector struct {
 	PackageNames []string
+	// All records every installed package with one listing command
+	// instead of querying PackageNames one by one
+	All     bool
+	Timeout time.Duration
 }
 
+// Name identifies the collector in collection errors
+func (c *PackageCollector) Name() string { return "package" }
+
 // Collect gathers package information
-func (c *PackageCollector) Collect(snap *snapshot.Snapshot) error {
-	if len(c.PackageNames) == 0 {
+func (c *PackageCollector) Collect(ctx context.Context, snap *snapshot.Snapshot) error {
+	if len(c.PackageNames) == 0 && !c.All {
+		return nil
+	}
+
+	// Reading the package database directly needs no fork per package and
+	// works in minimal images without dpkg-query
+	if info, ok := readPackageDB(); ok {
+		if !c.All {
+			filterPackages(info, c.PackageNames)
+		}
+		snap.Packages = info
 		return nil
 	}
 
@@ -30,6 +49,25 @@ func (c *PackageCollector) Collect(snap *snapshot.Snapshot) error {
 		Items:   make(map[string]string),
 	}
 
+	// apk has no cheap per-package version query, so it always lists
+	if c.All || manager == "apk" {
+		items, cerr := c.listPackages(ctx, manager)
+		if cerr != nil {
+			snap.AddError(*cerr)
+			return nil
+		}
+		if c.All {
+			snap.Packages.Items = items
+			return nil
+		}
+		for _, name := range c.PackageNames {
+			if version, ok := items[name]; ok {
+				snap.Packages.Items[name] = version
+			}
+		}
+		return nil
+	}
+
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 
@@ -37,11 +75,14 @@ func (c *PackageCollector) Collect(snap *snapshot.Snapshot) error {
 		wg.Add(1)
 		go func(pkgName string) {
 			defer wg.Done()
-			version := c.checkPackage(manager, pkgName)
+			version, cerr := c.checkPackage(ctx, manager, pkgName)
+			mu.Lock()
+			defer mu.Unlock()
 			if version != "" {
-				mu.Lock()
 				snap.Packages.Items[pkgName] = version
-				mu.Unlock()
+			}
+			if cerr != nil {
+				snap.AddError(*cerr)
 			}
 		}(name)
 	}
@@ -66,31 +107,48 @@ func (c *PackageCollector) detectManager() string {
 		if _, err := exec.LookPath("yum"); err == nil {
 			return "yum"
 		}
+		if _, err := exec.LookPath("apk"); err == nil {
+			return "apk"
+		}
+		if _, err := exec.LookPath("pacman"); err == nil {
+			return "pacman"
+		}
 	}
 	return ""
 }
 
-func (c *PackageCollector) checkPackage(manager, name string) string {
+// checkPackage returns the installed version of a package, or "" if it is not
+// installed. Errors are only returned when the query itself could not run.
+func (c *PackageCollector) checkPackage(ctx context.Context, manager, name string) (string, *snapshot.CollectionError) {
+	ctx, cancel := probeContext(ctx, c.Timeout)
+	defer cancel()
+
 	var cmd *exec.Cmd
 	switch manager {
 	case "brew":
-		cmd = exec.Command("brew", "list", "--versions", name)
+		cmd = probeCommand(ctx, "brew", "list", "--versions", name)
 	case "apt":
-		cmd = exec.Command("dpkg-query", "-W", "-f=${Version}", name)
+		cmd = probeCommand(ctx, "dpkg-query", "-W", "-f=${Version}", name)
 	case "dnf", "yum":
-		cmd = exec.Command("rpm", "-q", "--queryformat", "%{VERSION}", name)
+		cmd = probeCommand(ctx, "rpm", "-q", "--queryformat", "%{VERSION}", name)
+	case "pacman":
+		cmd = probeCommand(ctx, "pacman", "-Q", name)
 	default:
-		return ""
+		return "", nil
 	}
 
 	out, err := cmd.Output()
 	if err != nil {
-		return "" // Not installed or error
+		// A killed probe is also an exit error, so check for a timeout first
+		if ctx.Err() == nil && isExitError(err) {
+			return "", nil // Not installed
+		}
+		return "", commandError(ctx, c.Name(), name, cmd, out, err)
 	}
 
 	version := strings.TrimSpace(string(out))
-	if manager == "brew" && version != "" {
-		// brew list --versions returns "pkgname version"
+	if (manager == "brew" || manager == "pacman") && version != "" {
+		// brew list --versions and pacman -Q return "pkgname version"
 		parts := strings.Fields(version)
 		if len(parts) >= 2 {
 			version = parts[1]
@@ -98,8 +156,81 @@ func (c *PackageCollector) checkPackage(manager, name string) string {
 	}
 
 	if version == "" {
-		return "installed" // Fallback if we can't get version but command succeeded
+		return "installed", nil // Fallback if we can't get version but command succeeded
+	}
+
+	return version, nil
+}
+
+// packageListing is the command that prints every installed package in one
+// pass, one package per line
+var packageListing = map[string][]string{
+	"apt":    {"dpkg-query", "-W", "-f=${db:Status-Abbrev}\t${Package}\t${Version}\n"},
+	"dnf":    {"rpm", "-qa", "--queryformat", "%{NAME}\t%{VERSION}\n"},
+	"yum":    {"rpm", "-qa", "--queryformat", "%{NAME}\t%{VERSION}\n"},
+	"brew":   {"brew", "list", "--versions"},
+	"apk":    {"apk", "info", "-v"},
+	"pacman": {"pacman", "-Q"},
+}
+
+// listPackages returns every installed package and its version
+func (c *PackageCollector) listPackages(ctx context.Context, manager string) (map[string]string, *snapshot.CollectionError) {
+	ctx, cancel := probeContext(ctx, c.Timeout)
+	defer cancel()
+
+	args := packageListing[manager]
+	cmd := probeCommand(ctx, args[0], args[1:]...)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, commandError(ctx, c.Name(), "all", cmd, out, err)
 	}
+	return parsePackageListing(manager, string(out)), nil
+}
 
-	return version
+// parsePackageListing parses the output of a packageListing command
+func parsePackageListing(manager, out string) map[string]string {
+	items := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		switch manager {
+		case "apt":
+			// dpkg also lists removed packages whose config files remain
+			status, rest, _ := strings.Cut(line, "\t")
+			if !strings.HasPrefix(status, "ii") {
+				continue
+			}
+			if name, version, ok := strings.Cut(rest, "\t"); ok {
+				items[name] = version
+			}
+		case "dnf", "yum":
+			if name, version, ok := strings.Cut(line, "\t"); ok {
+				items[name] = version
+			}
+		case "apk":
+			if name, version, ok := splitApkPackage(strings.TrimSpace(line)); ok {
+				items[name] = version
+			}
+		default:
+			// brew and pacman print "name version"; brew may list several
+			// installed versions, of which we keep the first like checkPackage
+			if fields := strings.Fields(line); len(fields) >= 2 {
+				items[fields[0]] = fields[1]
+			}
+		}
+	}
+	return items
+}
+
+// splitApkPackage splits "musl-1.2.4-r2" into "musl" and "1.2.4-r2". The
+// version is always the last two dash-separated parts, since names may
+// contain dashes and digits but every version ends in a -rN release.
+func splitApkPackage(s string) (string, string, bool) {
+	release := strings.LastIndex(s, "-")
+	if release <= 0 || !strings.HasPrefix(s[release+1:], "r") {
+		return "", "", false
+	}
+	version := strings.LastIndex(s[:release], "-")
+	if version <= 0 {
+		return "", "", false
+	}
+	return s[:version], s[version+1:], true
 }
@@ -0,0 +1,60 @@
+package collector
+
+import "testing"
+
+func TestParsePackageListing(t *testing.T) {
+	tests := []struct {
+		manager string
+		out     string
+		want    map[string]string
+	}{
+		{
+			manager: "apt",
+			out:     "ii \tgit\t1:2.39.5-0+deb12u2\nrc \told-lib\t1.0\nii \tcurl\t7.88.1-10\n",
+			want:    map[string]string{"git": "1:2.39.5-0+deb12u2", "curl": "7.88.1-10"},
+		},
+		{
+			manager: "dnf",
+			out:     "bash\t5.2.26\nopenssl-libs\t3.1.4\n",
+			want:    map[string]string{"bash": "5.2.26", "openssl-libs": "3.1.4"},
+		},
+		{
+			manager: "brew",
+			out:     "git 2.44.0\nnode 21.7.1 20.11.1\n",
+			want:    map[string]string{"git": "2.44.0", "node": "21.7.1"},
+		},
+		{
+			manager: "apk",
+			out:     "musl-1.2.4-r2\nlibcrypto3-3.1.4-r5\npy3-setuptools-69.0.3-r0\n",
+			want:    map[string]string{"musl": "1.2.4-r2", "libcrypto3": "3.1.4-r5", "py3-setuptools": "69.0.3-r0"},
+		},
+		{
+			manager: "pacman",
+			out:     "bash 5.2.026-2\nlinux-api-headers 6.4-1\n",
+			want:    map[string]string{"bash": "5.2.026-2", "linux-api-headers": "6.4-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.manager, func(t *testing.T) {
+			got := parsePackageListing(tt.manager, tt.out)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for name, version := range tt.want {
+				if got[name] != version {
+					t.Errorf("%s = %q, want %q", name, got[name], version)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitApkPackage(t *testing.T) {
+	if _, _, ok := splitApkPackage("installed-but-unversioned"); ok {
+		t.Error("expected no match without a -rN release")
+	}
+	if _, _, ok := splitApkPackage(""); ok {
+		t.Error("expected no match for an empty line")
+	}
+}
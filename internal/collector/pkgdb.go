@@ -0,0 +1,169 @@
+package collector
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+// Package databases read without running the package manager. The RPM
+// database is SQLite or Berkeley DB, so rpm-based systems still use rpm -q.
+var (
+	dpkgStatusPath   = "/var/lib/dpkg/status"
+	apkInstalledPath = "/lib/apk/db/installed"
+)
+
+// dbPackage is one installed package from a package database
+type dbPackage struct {
+	Name    string
+	Version string
+	Arch    string
+}
+
+// readPackageDB reads the installed packages from the dpkg or apk database.
+// ok is false when neither file is readable and the caller should fall back
+// to querying the package manager.
+func readPackageDB() (*snapshot.PackageInfo, bool) {
+	sources := []struct {
+		manager string
+		path    string
+		parse   func(io.Reader) ([]dbPackage, error)
+	}{
+		{"apt", dpkgStatusPath, parseDpkgStatus},
+		{"apk", apkInstalledPath, parseApkInstalled},
+	}
+
+	for _, source := range sources {
+		f, err := os.Open(source.path)
+		if err != nil {
+			continue
+		}
+		packages, err := source.parse(f)
+		_ = f.Close()
+		if err != nil {
+			continue
+		}
+
+		info := &snapshot.PackageInfo{
+			Manager: source.manager,
+			Items:   make(map[string]string, len(packages)),
+			Arch:    make(map[string]string, len(packages)),
+			Source:  source.path,
+		}
+		for _, p := range packages {
+			// Multi-arch packages appear once per architecture
+			if arch, seen := info.Arch[p.Name]; seen {
+				arches := append(strings.Split(arch, ","), p.Arch)
+				sort.Strings(arches)
+				info.Arch[p.Name] = strings.Join(arches, ",")
+				continue
+			}
+			info.Items[p.Name] = p.Version
+			info.Arch[p.Name] = p.Arch
+		}
+		return info, true
+	}
+	return nil, false
+}
+
+// filterPackages keeps only the named packages
+func filterPackages(info *snapshot.PackageInfo, names []string) {
+	items := make(map[string]string, len(names))
+	arch := make(map[string]string, len(names))
+	for _, name := range names {
+		if version, ok := info.Items[name]; ok {
+			items[name] = version
+			arch[name] = info.Arch[name]
+		}
+	}
+	info.Items, info.Arch = items, arch
+}
+
+// parseDpkgStatus parses /var/lib/dpkg/status: RFC 822 style stanzas
+// separated by blank lines. Packages that are removed but keep their config
+// files, or are half-installed, are skipped.
+func parseDpkgStatus(r io.Reader) ([]dbPackage, error) {
+	var packages []dbPackage
+	var current dbPackage
+	installed := false
+
+	flush := func() {
+		if installed && current.Name != "" {
+			packages = append(packages, current)
+		}
+		current, installed = dbPackage{}, false
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			continue // continuation of a multi-line field
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Package":
+			current.Name = value
+		case "Version":
+			current.Version = value
+		case "Architecture":
+			current.Arch = value
+		case "Status":
+			// "<want> <error> <status>", e.g. "install ok installed"
+			fields := strings.Fields(value)
+			installed = len(fields) == 3 && fields[2] == "installed"
+		}
+	}
+	flush()
+	return packages, scanner.Err()
+}
+
+// parseApkInstalled parses the apk installed database, where each package
+// is a block of single-letter fields (P: name, V: version, A: arch)
+func parseApkInstalled(r io.Reader) ([]dbPackage, error) {
+	var packages []dbPackage
+	var current dbPackage
+
+	flush := func() {
+		if current.Name != "" {
+			packages = append(packages, current)
+		}
+		current = dbPackage{}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		if len(line) < 2 || line[1] != ':' {
+			continue
+		}
+		switch line[0] {
+		case 'P':
+			current.Name = line[2:]
+		case 'V':
+			current.Version = line[2:]
+		case 'A':
+			current.Arch = line[2:]
+		}
+	}
+	flush()
+	return packages, scanner.Err()
+}
@@ -0,0 +1,91 @@
+package collector
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+// usePackageDBs points the package database readers at test fixtures
+func usePackageDBs(t *testing.T, dpkg, apk string) {
+	t.Helper()
+	oldDpkg, oldApk := dpkgStatusPath, apkInstalledPath
+	dpkgStatusPath, apkInstalledPath = dpkg, apk
+	t.Cleanup(func() { dpkgStatusPath, apkInstalledPath = oldDpkg, oldApk })
+}
+
+func TestReadPackageDB_Dpkg(t *testing.T) {
+	usePackageDBs(t, filepath.Join("testdata", "dpkg-status"), filepath.Join("testdata", "missing"))
+
+	info, ok := readPackageDB()
+	if !ok {
+		t.Fatal("expected the dpkg status file to be read")
+	}
+	if info.Manager != "apt" {
+		t.Errorf("Manager = %q, want apt", info.Manager)
+	}
+
+	want := map[string]string{"adduser": "3.134", "libc6": "2.36-9+deb12u7", "git": "1:2.39.5-0+deb12u2"}
+	if len(info.Items) != len(want) {
+		t.Fatalf("Items = %v, want %v (removed and half-installed packages skipped)", info.Items, want)
+	}
+	for name, version := range want {
+		if info.Items[name] != version {
+			t.Errorf("%s = %q, want %q", name, info.Items[name], version)
+		}
+	}
+	if info.Arch["libc6"] != "amd64,i386" {
+		t.Errorf("libc6 arch = %q, want amd64,i386", info.Arch["libc6"])
+	}
+	if info.Arch["adduser"] != "all" {
+		t.Errorf("adduser arch = %q, want all", info.Arch["adduser"])
+	}
+}
+
+func TestReadPackageDB_Apk(t *testing.T) {
+	usePackageDBs(t, filepath.Join("testdata", "missing"), filepath.Join("testdata", "apk-installed"))
+
+	info, ok := readPackageDB()
+	if !ok {
+		t.Fatal("expected the apk database to be read")
+	}
+	if info.Manager != "apk" {
+		t.Errorf("Manager = %q, want apk", info.Manager)
+	}
+	if info.Items["musl"] != "1.2.4-r2" || info.Arch["musl"] != "x86_64" {
+		t.Errorf("musl = %q/%q, want 1.2.4-r2/x86_64", info.Items["musl"], info.Arch["musl"])
+	}
+	if info.Items["py3-setuptools"] != "69.0.3-r0" {
+		t.Errorf("py3-setuptools = %q, want 69.0.3-r0", info.Items["py3-setuptools"])
+	}
+}
+
+func TestReadPackageDB_Unreadable(t *testing.T) {
+	usePackageDBs(t, filepath.Join("testdata", "missing"), filepath.Join("testdata", "missing"))
+
+	if _, ok := readPackageDB(); ok {
+		t.Error("expected fallback when no database is readable")
+	}
+}
+
+func TestPackageCollector_ReadsDatabase(t *testing.T) {
+	usePackageDBs(t, filepath.Join("testdata", "dpkg-status"), filepath.Join("testdata", "missing"))
+
+	snap := snapshot.New()
+	collector := &PackageCollector{PackageNames: []string{"git", "old-lib", "nonexistent"}}
+	if err := collector.Collect(context.Background(), snap); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	if snap.Packages == nil || len(snap.Packages.Items) != 1 {
+		t.Fatalf("expected only git, got %+v", snap.Packages)
+	}
+	if snap.Packages.Items["git"] != "1:2.39.5-0+deb12u2" {
+		t.Errorf("git = %q", snap.Packages.Items["git"])
+	}
+	if len(snap.CollectionErrors) != 0 {
+		t.Errorf("unexpected collection errors: %v", snap.CollectionErrors)
+	}
+}
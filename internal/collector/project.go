@@ -0,0 +1,97 @@
+package collector
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+// ProjectCollector fingerprints the dependency lockfiles in a project root
+// and extracts the versions they resolve for direct dependencies
+type ProjectCollector struct {
+	Root string
+}
+
+// Name identifies the collector in collection errors
+func (c *ProjectCollector) Name() string { return "project" }
+
+// lockfileParser extracts direct dependency versions from a lockfile.
+// dir is the project root, for parsers that read the matching manifest
+// (go.mod, package.json, pyproject.toml) to tell direct from transitive.
+type lockfileParser func(dir string, data []byte) (map[string]string, error)
+
+// lockfileParsers maps lockfile names, or glob patterns, to their parsers
+var lockfileParsers = map[string]lockfileParser{
+	"go.sum":            parseGoSum,
+	"package-lock.json": parsePackageLock,
+	"yarn.lock":         parseYarnLock,
+	"pnpm-lock.yaml":    parsePnpmLock,
+	"poetry.lock":       parsePoetryLock,
+	"requirements*.txt": parseRequirements,
+	"Cargo.lock":        parseCargoLock,
+	"Gemfile.lock":      parseGemfileLock,
+}
+
+// Collect records every lockfile found in the project root. A root without
+// lockfiles leaves the project section out of the snapshot.
+func (c *ProjectCollector) Collect(_ context.Context, snap *snapshot.Snapshot) error {
+	root, err := filepath.Abs(c.Root)
+	if err != nil {
+		return err
+	}
+
+	lockfiles := make(map[string]*snapshot.Lockfile)
+	for _, pattern := range sortedParserPatterns() {
+		matches, _ := filepath.Glob(filepath.Join(root, pattern))
+		for _, path := range matches {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				if !os.IsNotExist(err) {
+					snap.AddError(c.lockfileError(path, err))
+				}
+				continue
+			}
+
+			name := filepath.Base(path)
+			sum := sha256.Sum256(data)
+			lockfile := &snapshot.Lockfile{SHA256: hex.EncodeToString(sum[:])}
+			deps, err := lockfileParsers[pattern](root, data)
+			if err != nil {
+				// Keep the fingerprint, it still shows the file differs
+				snap.AddError(c.lockfileError(path, err))
+			}
+			if len(deps) > 0 {
+				lockfile.Dependencies = deps
+			}
+			lockfiles[name] = lockfile
+		}
+	}
+
+	if len(lockfiles) > 0 {
+		snap.Project = &snapshot.ProjectInfo{Root: root, Lockfiles: lockfiles}
+	}
+	return nil
+}
+
+func (c *ProjectCollector) lockfileError(path string, err error) snapshot.CollectionError {
+	return snapshot.CollectionError{
+		Collector: c.Name(),
+		Probe:     filepath.Base(path),
+		Message:   fmt.Sprintf("failed to parse %s: %v", path, err),
+	}
+}
+
+func sortedParserPatterns() []string {
+	patterns := make([]string, 0, len(lockfileParsers))
+	for pattern := range lockfileParsers {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	return patterns
+}
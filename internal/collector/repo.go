@@ -0,0 +1,288 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GBerghoff/envdiff/internal/secrets"
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+// RepoCollector records the state of the git checkout containing Dir:
+// HEAD, local changes, submodules, hooks, LFS content and the git settings
+// that change what a checkout holds. A directory outside any git repository,
+// or a machine without git, leaves the repo section out.
+type RepoCollector struct {
+	Dir string
+	// ConfigKeys are git settings to record in addition to repoConfigKeys
+	ConfigKeys []string
+	// Redact applies the secret rules to the recorded settings, which may
+	// include credentials such as http.extraheader
+	Redact bool
+	// Redactor overrides the default placeholder redaction
+	Redactor *secrets.Redactor
+	Timeout  time.Duration
+}
+
+// Name identifies the collector in collection errors
+func (c *RepoCollector) Name() string { return "repo" }
+
+// repoConfigKeys are the git settings that change the files a checkout
+// holds or which hooks run. They are read with the repository's own config.
+var repoConfigKeys = []string{
+	"core.autocrlf", "core.eol", "core.safecrlf", "core.symlinks", "core.ignorecase",
+	"core.filemode", "core.precomposeunicode", "core.longpaths", "core.hookspath",
+	"core.sparsecheckout", "submodule.recurse", "lfs.fetchexclude", "lfs.fetchinclude",
+}
+
+// Collect fills snap.Repo
+func (c *RepoCollector) Collect(ctx context.Context, snap *snapshot.Snapshot) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil
+	}
+	out, cerr := c.git(ctx, "toplevel", "rev-parse", "--show-toplevel")
+	if cerr != nil {
+		if cerr.ExitCode != 0 && strings.Contains(cerr.Stderr, "not a git repository") {
+			return nil
+		}
+		snap.AddError(*cerr)
+		return nil
+	}
+
+	repo := &snapshot.RepoInfo{Root: strings.TrimSpace(string(out))}
+	probes := []func(context.Context, *snapshot.RepoInfo) *snapshot.CollectionError{
+		c.head, c.status, c.dirtyHash, c.submodules, c.hooks, c.lfs, c.config,
+	}
+	for _, probe := range probes {
+		if cerr := probe(ctx, repo); cerr != nil {
+			snap.AddError(*cerr)
+		}
+	}
+	snap.Repo = repo
+	return nil
+}
+
+// git runs a git command in the repository
+func (c *RepoCollector) git(ctx context.Context, probe string, args ...string) ([]byte, *snapshot.CollectionError) {
+	ctx, cancel := probeContext(ctx, c.Timeout)
+	defer cancel()
+
+	cmd := probeCommand(ctx, "git", append([]string{"-C", c.Dir}, args...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		return out, commandError(ctx, c.Name(), probe, cmd, out, err)
+	}
+	return out, nil
+}
+
+// head records the commit and branch. A repository without commits has
+// neither, and a detached HEAD has no branch.
+func (c *RepoCollector) head(ctx context.Context, repo *snapshot.RepoInfo) *snapshot.CollectionError {
+	if out, cerr := c.git(ctx, "head", "rev-parse", "--verify", "-q", "HEAD"); cerr == nil {
+		repo.Head = strings.TrimSpace(string(out))
+	} else if cerr.ExitCode == 0 {
+		return cerr
+	}
+	if out, cerr := c.git(ctx, "branch", "symbolic-ref", "-q", "--short", "HEAD"); cerr == nil {
+		repo.Branch = strings.TrimSpace(string(out))
+	} else if cerr.ExitCode == 0 {
+		return cerr
+	}
+	return nil
+}
+
+// status counts changed and untracked files
+func (c *RepoCollector) status(ctx context.Context, repo *snapshot.RepoInfo) *snapshot.CollectionError {
+	out, cerr := c.git(ctx, "status", "status", "--porcelain=v1", "-z", "--untracked-files=all")
+	if cerr != nil {
+		return cerr
+	}
+	repo.Dirty, repo.Untracked = parseStatus(out)
+	return nil
+}
+
+// parseStatus counts the entries of `git status --porcelain=v1 -z`. Each is
+// "XY path", followed by the original path for renames and copies.
+func parseStatus(out []byte) (dirty, untracked int) {
+	fields := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	for i := 0; i < len(fields); i++ {
+		entry := fields[i]
+		if len(entry) < 3 {
+			continue
+		}
+		switch code := entry[:2]; {
+		case code == "??":
+			untracked++
+		case code == "!!":
+		default:
+			dirty++
+			if code[0] == 'R' || code[0] == 'C' {
+				i++ // skip the original path
+			}
+		}
+	}
+	return dirty, untracked
+}
+
+// dirtyHash fingerprints the changes to tracked files, so two checkouts with
+// the same number of changed files can still be told apart
+func (c *RepoCollector) dirtyHash(ctx context.Context, repo *snapshot.RepoInfo) *snapshot.CollectionError {
+	if repo.Dirty == 0 || repo.Head == "" {
+		return nil
+	}
+	out, cerr := c.git(ctx, "diff", "diff", "HEAD", "--binary", "--no-color", "--no-ext-diff")
+	if cerr != nil {
+		return cerr
+	}
+	sum := sha256.Sum256(out)
+	repo.DirtyHash = hex.EncodeToString(sum[:])
+	return nil
+}
+
+// submodules records the commit checked out in each submodule
+func (c *RepoCollector) submodules(ctx context.Context, repo *snapshot.RepoInfo) *snapshot.CollectionError {
+	if _, err := os.Stat(filepath.Join(repo.Root, ".gitmodules")); err != nil {
+		return nil
+	}
+	out, cerr := c.git(ctx, "submodules", "submodule", "status", "--recursive")
+	if cerr != nil {
+		return cerr
+	}
+	repo.Submodules = parseSubmoduleStatus(out)
+	return nil
+}
+
+// parseSubmoduleStatus parses `git submodule status` lines such as
+// "+1a2b3c... vendor/lib (v1.2.0-3-g1a2b3c)". A "-" prefix marks a submodule
+// that is not initialized, so nothing is checked out.
+func parseSubmoduleStatus(out []byte) map[string]string {
+	submodules := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		if len(line) < 2 {
+			continue
+		}
+		fields := strings.Fields(line[1:])
+		if len(fields) < 2 {
+			continue
+		}
+		commit := fields[0]
+		if line[0] == '-' {
+			commit = ""
+		}
+		submodules[fields[1]] = commit
+	}
+	if len(submodules) == 0 {
+		return nil
+	}
+	return submodules
+}
+
+// hooks lists the executable hooks in the hooks directory, which
+// core.hooksPath may move out of .git
+func (c *RepoCollector) hooks(ctx context.Context, repo *snapshot.RepoInfo) *snapshot.CollectionError {
+	out, cerr := c.git(ctx, "hooks", "rev-parse", "--git-path", "hooks")
+	if cerr != nil {
+		return cerr
+	}
+	dir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(c.Dir, dir)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), ".sample") {
+			continue
+		}
+		if info, err := entry.Info(); err == nil && info.Mode()&0111 != 0 {
+			repo.Hooks = append(repo.Hooks, entry.Name())
+		}
+	}
+	sort.Strings(repo.Hooks)
+	return nil
+}
+
+// lfs counts LFS files and those whose content was never fetched. Without
+// git-lfs installed every LFS file is a pointer, but they cannot be listed.
+func (c *RepoCollector) lfs(ctx context.Context, repo *snapshot.RepoInfo) *snapshot.CollectionError {
+	attributes, err := os.ReadFile(filepath.Join(repo.Root, ".gitattributes"))
+	if err != nil || !bytes.Contains(attributes, []byte("filter=lfs")) {
+		return nil
+	}
+	repo.LFS = &snapshot.LFSInfo{}
+	if _, err := exec.LookPath("git-lfs"); err != nil {
+		return nil
+	}
+	repo.LFS.Installed = true
+
+	out, cerr := c.git(ctx, "lfs", "lfs", "ls-files")
+	if cerr != nil {
+		return cerr
+	}
+	repo.LFS.Files, repo.LFS.Pointers = parseLFSFiles(out)
+	return nil
+}
+
+// parseLFSFiles parses `git lfs ls-files` lines such as "4d7a214614 * path",
+// where "*" means the content is present and "-" that only the pointer is
+func parseLFSFiles(out []byte) (files, pointers int) {
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		files++
+		if fields[1] == "-" {
+			pointers++
+		}
+	}
+	return files, pointers
+}
+
+// config records the effective value of each setting in repoConfigKeys
+// and ConfigKeys
+func (c *RepoCollector) config(ctx context.Context, repo *snapshot.RepoInfo) *snapshot.CollectionError {
+	var keys []string
+	for _, list := range [][]string{repoConfigKeys, c.ConfigKeys} {
+		for _, key := range list {
+			keys = append(keys, regexp.QuoteMeta(snapshot.CanonicalGitKey(key)))
+		}
+	}
+	pattern := `^(` + strings.Join(keys, "|") + `)$`
+	out, cerr := c.git(ctx, "config", "config", "-z", "--get-regexp", pattern)
+	if cerr != nil {
+		if cerr.ExitCode == 1 {
+			return nil // none of the keys are set
+		}
+		return cerr
+	}
+	settings := make(map[string]string)
+	for _, record := range strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00") {
+		key, value, ok := strings.Cut(record, "\n")
+		if !ok {
+			value = "true"
+		}
+		settings[scrubGitURLKey(key)] = value // a later file overrides an earlier one
+	}
+	if len(settings) == 0 {
+		return nil
+	}
+	repo.Config = settings
+	if c.Redact {
+		if rules := redactSettings(c.Redactor, settings, nil); len(rules) > 0 {
+			repo.Redactions = rules
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GBerghoff/envdiff/internal/secrets"
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+func TestParseStatus(t *testing.T) {
+	records := []string{
+		" M main.go",
+		"M  go.mod",
+		"R  new.go", "old.go",
+		"?? notes.txt",
+		"?? tmp/out.log",
+	}
+	dirty, untracked := parseStatus([]byte(strings.Join(records, "\x00") + "\x00"))
+	if dirty != 3 || untracked != 2 {
+		t.Errorf("parseStatus() = %d dirty, %d untracked, want 3 and 2", dirty, untracked)
+	}
+
+	if dirty, untracked := parseStatus(nil); dirty != 0 || untracked != 0 {
+		t.Errorf("a clean checkout gave %d dirty, %d untracked", dirty, untracked)
+	}
+}
+
+func TestParseSubmoduleStatus(t *testing.T) {
+	out := []byte(" 1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b vendor/lib (v1.2.0)\n" +
+		"+0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c third_party/proto (heads/main)\n" +
+		"-9f8e7d6c5b4a39281706f5e4d3c2b1a098765432 docs/theme\n")
+	submodules := parseSubmoduleStatus(out)
+	want := map[string]string{
+		"vendor/lib":        "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b",
+		"third_party/proto": "0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c",
+		"docs/theme":        "",
+	}
+	if len(submodules) != len(want) {
+		t.Fatalf("got %v, want %v", submodules, want)
+	}
+	for path, commit := range want {
+		if submodules[path] != commit {
+			t.Errorf("%s = %q, want %q", path, submodules[path], commit)
+		}
+	}
+}
+
+func TestParseLFSFiles(t *testing.T) {
+	out := []byte("4d7a214614 * assets/logo.png\n" +
+		"8c1f0e2a3b - assets/video.mp4\n" +
+		"e3b0c44298 * data/model.bin\n")
+	if files, pointers := parseLFSFiles(out); files != 3 || pointers != 1 {
+		t.Errorf("parseLFSFiles() = %d files, %d pointers, want 3 and 1", files, pointers)
+	}
+}
+
+func TestRepoCollector_Collect(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=envdiff", "-c", "user.email=envdiff@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	write := func(name, content string, mode os.FileMode) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q", "-b", "main")
+	git("config", "core.autocrlf", "input")
+	write("main.go", "package main\n", 0644)
+	git("add", "main.go")
+	git("commit", "-q", "-m", "initial")
+	write("main.go", "package main\n\nfunc main() {}\n", 0644)
+	write("notes.txt", "todo\n", 0644)
+	write(".git/hooks/pre-commit", "#!/bin/sh\n", 0755)
+
+	snap := snapshot.New()
+	if err := (&RepoCollector{Dir: dir}).Collect(context.Background(), snap); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if len(snap.CollectionErrors) > 0 {
+		t.Fatalf("unexpected collection errors %+v", snap.CollectionErrors)
+	}
+	repo := snap.Repo
+	if repo == nil {
+		t.Fatal("expected a repo section")
+	}
+	if repo.Branch != "main" || len(repo.Head) != 40 {
+		t.Errorf("Branch = %q, Head = %q", repo.Branch, repo.Head)
+	}
+	if repo.Dirty != 1 || repo.Untracked != 1 || len(repo.DirtyHash) != 64 {
+		t.Errorf("Dirty = %d, Untracked = %d, DirtyHash = %q", repo.Dirty, repo.Untracked, repo.DirtyHash)
+	}
+	if len(repo.Hooks) != 1 || repo.Hooks[0] != "pre-commit" {
+		t.Errorf("Hooks = %v, want the installed pre-commit hook only", repo.Hooks)
+	}
+	if repo.Config["core.autocrlf"] != "input" {
+		t.Errorf("Config = %v, want core.autocrlf", repo.Config)
+	}
+
+	outside := snapshot.New()
+	if err := (&RepoCollector{Dir: t.TempDir()}).Collect(context.Background(), outside); err != nil {
+		t.Fatal(err)
+	}
+	if outside.Repo != nil || len(outside.CollectionErrors) > 0 {
+		t.Errorf("a directory outside a repository should be skipped, got %+v, %+v", outside.Repo, outside.CollectionErrors)
+	}
+}
+
+func TestRepoCollector_RedactsConfig(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	header := "AUTHORIZATION: basic " + "aHVudGVyMg=="
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "http.extraHeader", header},
+		{"config", "remote.origin.url", "https://ci:" + "hunter2" + "@git.example.com/app.git"},
+		{"config", "url.https://Git.Example.com/.insteadOf", "git@git.example.com:"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	snap := snapshot.New()
+	c := &RepoCollector{
+		Dir:        dir,
+		ConfigKeys: []string{"http.extraHeader", "remote.origin.url", "url.https://Git.Example.com/.insteadOf"},
+		Redact:     true,
+	}
+	if err := c.Collect(context.Background(), snap); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	config := snap.Repo.Config
+
+	if config["http.extraheader"] != secrets.RedactedValue {
+		t.Errorf("http.extraheader = %q, want redacted", config["http.extraheader"])
+	}
+	if got := config["remote.origin.url"]; got != "https://"+secrets.RedactedValue+"@git.example.com/app.git" {
+		t.Errorf("remote.origin.url = %q, want the credentials scrubbed", got)
+	}
+	if config["url.https://Git.Example.com/.insteadof"] != "git@git.example.com:" {
+		t.Errorf("Config = %v, want the insteadOf setting matched with its subsection's case", config)
+	}
+	if snap.Repo.Redactions["http.extraheader"] == "" || snap.Repo.Redactions["remote.origin.url"] != secrets.URLCredentialsRuleID {
+		t.Errorf("Redactions = %v", snap.Repo.Redactions)
+	}
+}
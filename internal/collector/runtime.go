@@ -1,10 +1,14 @@
 package collector
 
 import (
+	"context"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/GBerghoff/envdiff/internal/snapshot"
 )
@@ -15,6 +19,7 @@ type RuntimeDefinition struct {
 	Command   string
 	Args      []string
 	VersionRE *regexp.Regexp
+	Timeout   time.Duration // overrides the collector timeout when set
 }
 
 // Registry stores all registered runtime definitions
@@ -166,10 +171,14 @@ func init() {
 // RuntimeCollector detects installed runtimes and CLI tools
 type RuntimeCollector struct {
 	Definitions []RuntimeDefinition
+	Timeout     time.Duration
 }
 
+// Name identifies the collector in collection errors
+func (c *RuntimeCollector) Name() string { return "runtime" }
+
 // Collect gathers runtime information using parallel detection
-func (c *RuntimeCollector) Collect(snap *snapshot.Snapshot) error {
+func (c *RuntimeCollector) Collect(ctx context.Context, snap *snapshot.Snapshot) error {
 	var waitGroup sync.WaitGroup
 	var mutex sync.Mutex
 
@@ -185,10 +194,17 @@ func (c *RuntimeCollector) Collect(snap *snapshot.Snapshot) error {
 		waitGroup.Add(1)
 		go func(runtime RuntimeDefinition) {
 			defer waitGroup.Done()
-			if info := c.detectRuntime(runtime); info != nil {
-				mutex.Lock()
+			info, cerr := c.detectRuntime(ctx, runtime)
+			if info != nil {
+				info.Installations = c.findInstallations(ctx, runtime, info)
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			if info != nil {
 				snap.Runtime[runtime.Name] = info
-				mutex.Unlock()
+			}
+			if cerr != nil {
+				snap.AddError(*cerr)
 			}
 		}(runtime)
 	}
@@ -196,32 +212,106 @@ func (c *RuntimeCollector) Collect(snap *snapshot.Snapshot) error {
 	return nil
 }
 
-func (c *RuntimeCollector) detectRuntime(runtime RuntimeDefinition) *snapshot.RuntimeInfo {
+// detectRuntime probes a single runtime. A nil info means the command is not
+// on PATH; a non-nil error means it is installed but the version probe failed.
+func (c *RuntimeCollector) detectRuntime(ctx context.Context, runtime RuntimeDefinition) (*snapshot.RuntimeInfo, *snapshot.CollectionError) {
 	path, err := exec.LookPath(runtime.Command)
 	if err != nil {
-		return nil // Not installed
+		return nil, nil // Not installed
 	}
 
-	cmd := exec.Command(runtime.Command, runtime.Args...)
+	info := &snapshot.RuntimeInfo{
+		Version: "unknown",
+		Path:    path,
+	}
+
+	timeout := c.Timeout
+	if runtime.Timeout > 0 {
+		timeout = runtime.Timeout
+	}
+	ctx, cancel := probeContext(ctx, timeout)
+	defer cancel()
+
+	cmd := probeCommand(ctx, runtime.Command, runtime.Args...)
 	// Capture both stdout and stderr (java outputs to stderr)
 	out, err := cmd.CombinedOutput()
 	if err != nil {
-		// Command exists but failed to get version
-		return &snapshot.RuntimeInfo{
-			Version: "unknown",
-			Path:    path,
+		// Command exists but failed (or timed out) getting its version
+		return info, commandError(ctx, c.Name(), runtime.Name, cmd, out, err)
+	}
+
+	if version := c.extractVersion(string(out), runtime.VersionRE); version != "" {
+		info.Version = version
+		return info, nil
+	}
+
+	return info, &snapshot.CollectionError{
+		Collector: c.Name(),
+		Probe:     runtime.Name,
+		Command:   strings.Join(cmd.Args, " "),
+		Stderr:    truncate(strings.TrimSpace(string(out)), maxStderrLen),
+		Message:   "version not found in output",
+	}
+}
+
+// findInstallations lists every binary for runtime on PATH, so that an
+// install shadowed by an earlier PATH entry shows up in the snapshot.
+// The winning binary reuses the version already probed for info.
+func (c *RuntimeCollector) findInstallations(ctx context.Context, runtime RuntimeDefinition, info *snapshot.RuntimeInfo) []snapshot.Installation {
+	var installations []snapshot.Installation
+	seen := make(map[string]bool)
+	for i, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+		path, err := exec.LookPath(filepath.Join(dir, runtime.Command))
+		if err != nil {
+			continue
+		}
+		// The same file reached through another PATH entry or a symlinked
+		// directory (/bin -> /usr/bin) is not a separate installation
+		real, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			real = path
+		}
+		if seen[real] {
+			continue
+		}
+		seen[real] = true
+
+		installation := snapshot.Installation{Path: path, PathIndex: i}
+		if real != path {
+			installation.Target = real
+		}
+		if len(installations) == 0 && path == info.Path {
+			installation.Version = info.Version
+		} else {
+			installation.Version = c.probeVersion(ctx, runtime, path)
 		}
+		installations = append(installations, installation)
 	}
+	return installations
+}
 
-	version := c.extractVersion(string(out), runtime.VersionRE)
-	if version == "" {
-		version = "unknown"
+// probeVersion runs a specific binary of a runtime and returns its version,
+// or "unknown". Shadowed installs are informational, so failures are not
+// recorded as collection errors.
+func (c *RuntimeCollector) probeVersion(ctx context.Context, runtime RuntimeDefinition, path string) string {
+	timeout := c.Timeout
+	if runtime.Timeout > 0 {
+		timeout = runtime.Timeout
 	}
+	ctx, cancel := probeContext(ctx, timeout)
+	defer cancel()
 
-	return &snapshot.RuntimeInfo{
-		Version: version,
-		Path:    path,
+	out, err := probeCommand(ctx, path, runtime.Args...).CombinedOutput()
+	if err != nil {
+		return "unknown"
+	}
+	if version := c.extractVersion(string(out), runtime.VersionRE); version != "" {
+		return version
 	}
+	return "unknown"
 }
 
 func (c *RuntimeCollector) extractVersion(output string, pattern *regexp.Regexp) string {
@@ -1,8 +1,13 @@
 package collector
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/GBerghoff/envdiff/internal/snapshot"
 )
@@ -26,7 +31,7 @@ func TestRegisterRuntime(t *testing.T) {
 		Definitions: []RuntimeDefinition{def},
 	}
 
-	err := collector.Collect(snap)
+	err := collector.Collect(context.Background(), snap)
 	if err != nil {
 		t.Fatalf("Collect() error = %v", err)
 	}
@@ -40,3 +45,129 @@ func TestRegisterRuntime(t *testing.T) {
 		t.Errorf("expected version 1.2.3, got %s", info.Version)
 	}
 }
+
+func TestRuntimeCollector_RecordsProbeFailures(t *testing.T) {
+	snap := snapshot.New()
+	collector := &RuntimeCollector{
+		Definitions: []RuntimeDefinition{
+			{
+				Name:      "failing-runtime",
+				Command:   "sh",
+				Args:      []string{"-c", "echo boom >&2; exit 3"},
+				VersionRE: regexp.MustCompile(`(\d+\.\d+\.\d+)`),
+			},
+			{
+				Name:      "unparseable-runtime",
+				Command:   "echo",
+				Args:      []string{"no version here"},
+				VersionRE: regexp.MustCompile(`v(\d+\.\d+\.\d+)`),
+			},
+			{
+				Name:      "absent-runtime",
+				Command:   "envdiff-definitely-not-installed",
+				VersionRE: regexp.MustCompile(`(\d+)`),
+			},
+		},
+	}
+
+	if err := collector.Collect(context.Background(), snap); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	failing := snap.ProbeError("runtime", "failing-runtime")
+	if failing == nil {
+		t.Fatal("failing-runtime should have a collection error")
+	}
+	if failing.ExitCode != 3 {
+		t.Errorf("ExitCode = %d, want 3", failing.ExitCode)
+	}
+	if failing.Stderr != "boom" {
+		t.Errorf("Stderr = %q, want %q", failing.Stderr, "boom")
+	}
+	if snap.Runtime["failing-runtime"] == nil || snap.Runtime["failing-runtime"].Version != "unknown" {
+		t.Error("failing-runtime should still be recorded as installed with an unknown version")
+	}
+
+	if snap.ProbeError("runtime", "unparseable-runtime") == nil {
+		t.Error("unparseable-runtime should have a collection error")
+	}
+
+	if snap.ProbeError("runtime", "absent-runtime") != nil {
+		t.Error("a runtime that is not installed is not a collection error")
+	}
+	if _, ok := snap.Runtime["absent-runtime"]; ok {
+		t.Error("absent-runtime should not be in the snapshot")
+	}
+}
+
+func TestRuntimeCollector_Timeout(t *testing.T) {
+	snap := snapshot.New()
+	collector := &RuntimeCollector{
+		Definitions: []RuntimeDefinition{
+			{
+				Name:      "hung-runtime",
+				Command:   "sleep",
+				Args:      []string{"10"},
+				VersionRE: regexp.MustCompile(`(\d+)`),
+				Timeout:   50 * time.Millisecond,
+			},
+		},
+	}
+
+	start := time.Now()
+	if err := collector.Collect(context.Background(), snap); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Collect() took %v, should have been cut short by the timeout", elapsed)
+	}
+
+	perr := snap.ProbeError("runtime", "hung-runtime")
+	if perr == nil || !perr.TimedOut {
+		t.Fatalf("hung-runtime should be recorded as timed out, got %+v", perr)
+	}
+	if snap.Runtime["hung-runtime"] == nil {
+		t.Error("a timed-out runtime should not be reported as not installed")
+	}
+}
+
+func TestRuntimeCollector_FindsShadowedInstallations(t *testing.T) {
+	// Two directories on PATH each provide the same command
+	first, second := t.TempDir(), t.TempDir()
+	for dir, version := range map[string]string{first: "2.0.0", second: "1.0.0"} {
+		script := "#!/bin/sh\necho tool " + version + "\n"
+		if err := os.WriteFile(filepath.Join(dir, "envdiff-tool"), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The first directory again through a symlink is the same install
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(first, link); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", strings.Join([]string{first, link, second, os.Getenv("PATH")}, string(os.PathListSeparator)))
+
+	snap := snapshot.New()
+	collector := &RuntimeCollector{
+		Definitions: []RuntimeDefinition{{
+			Name:      "tool",
+			Command:   "envdiff-tool",
+			VersionRE: regexp.MustCompile(`(\d+\.\d+\.\d+)`),
+		}},
+	}
+	if err := collector.Collect(context.Background(), snap); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	info := snap.Runtime["tool"]
+	if info == nil || info.Version != "2.0.0" {
+		t.Fatalf("expected the first install to win, got %+v", info)
+	}
+	if len(info.Installations) != 2 {
+		t.Fatalf("expected 2 installations, got %+v", info.Installations)
+	}
+	shadowed := info.Installations[1]
+	if shadowed.Version != "1.0.0" || shadowed.PathIndex != 2 || filepath.Dir(shadowed.Path) != second {
+		t.Errorf("unexpected shadowed install %+v", shadowed)
+	}
+}
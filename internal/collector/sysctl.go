@@ -0,0 +1,91 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+// SysctlCollector reads kernel parameters from /proc/sys: a default set that
+// commonly differs between CI, development machines and production, plus
+// Keys. Parameters this kernel does not have are left out, and platforms
+// without /proc/sys record none.
+type SysctlCollector struct {
+	Keys []string
+}
+
+// Name identifies the collector in collection errors
+func (c *SysctlCollector) Name() string { return "sysctl" }
+
+// procSysDir holds one file per kernel parameter
+var procSysDir = "/proc/sys"
+
+// defaultSysctlKeys are recorded on every snapshot: connection backlog and
+// port range, memory overcommit and mappings (Elasticsearch), inotify
+// watches (webpack and other file watchers) and process and file limits
+var defaultSysctlKeys = []string{
+	"net.core.somaxconn",
+	"net.ipv4.ip_local_port_range",
+	"net.ipv4.tcp_keepalive_time",
+	"net.ipv4.tcp_fin_timeout",
+	"net.ipv4.tcp_tw_reuse",
+	"net.ipv4.ip_forward",
+	"net.ipv6.conf.all.disable_ipv6",
+	"vm.max_map_count",
+	"vm.overcommit_memory",
+	"vm.swappiness",
+	"fs.inotify.max_user_watches",
+	"fs.inotify.max_user_instances",
+	"fs.file-max",
+	"fs.nr_open",
+	"kernel.pid_max",
+	"kernel.threads-max",
+	"user.max_user_namespaces",
+}
+
+// Collect fills snap.Sysctl
+func (c *SysctlCollector) Collect(_ context.Context, snap *snapshot.Snapshot) error {
+	if _, err := os.Stat(procSysDir); err != nil {
+		return nil
+	}
+
+	values := make(map[string]string)
+	for _, list := range [][]string{defaultSysctlKeys, c.Keys} {
+		for _, key := range list {
+			if _, done := values[key]; done {
+				continue
+			}
+			value, err := readSysctl(key)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				snap.AddError(snapshot.CollectionError{Collector: c.Name(), Probe: key, Message: err.Error()})
+				continue
+			}
+			values[key] = value
+		}
+	}
+	if len(values) > 0 {
+		snap.Sysctl = values
+	}
+	return nil
+}
+
+// readSysctl reads a parameter such as vm.max_map_count from
+// /proc/sys/vm/max_map_count. Values with several fields, such as
+// net.ipv4.ip_local_port_range, are joined by single spaces as sysctl
+// prints them.
+func readSysctl(key string) (string, error) {
+	path := filepath.Join(procSysDir, strings.ReplaceAll(key, ".", "/"))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(strings.Fields(string(data)), " "), nil
+}
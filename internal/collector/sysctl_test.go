@@ -0,0 +1,54 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+func TestSysctlCollector_Collect(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"vm/max_map_count":                "65530\n",
+		"net/ipv4/ip_local_port_range":    "32768\t60999\n",
+		"net/ipv4/tcp_congestion_control": "bbr\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := procSysDir
+	procSysDir = dir
+	t.Cleanup(func() { procSysDir = old })
+
+	snap := snapshot.New()
+	collector := &SysctlCollector{Keys: []string{"net.ipv4.tcp_congestion_control", "vm.max_map_count", "no.such.key"}}
+	if err := collector.Collect(context.Background(), snap); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"vm.max_map_count":                "65530",
+		"net.ipv4.ip_local_port_range":    "32768 60999",
+		"net.ipv4.tcp_congestion_control": "bbr",
+	}
+	if len(snap.Sysctl) != len(want) {
+		t.Fatalf("got %v", snap.Sysctl)
+	}
+	for key, value := range want {
+		if snap.Sysctl[key] != value {
+			t.Errorf("%s = %q, want %q", key, snap.Sysctl[key], value)
+		}
+	}
+	if len(snap.CollectionErrors) != 0 {
+		t.Errorf("missing parameters should not be errors: %+v", snap.CollectionErrors)
+	}
+}
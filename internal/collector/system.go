@@ -2,20 +2,26 @@ package collector
 
 import (
 	"bufio"
+	"context"
 	"os"
-	"os/exec"
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/GBerghoff/envdiff/internal/snapshot"
 )
 
 // SystemCollector gathers OS and hardware information
-type SystemCollector struct{}
+type SystemCollector struct {
+	Timeout time.Duration
+}
+
+// Name identifies the collector in collection errors
+func (c *SystemCollector) Name() string { return "system" }
 
 // Collect gathers system information
-func (c *SystemCollector) Collect(snap *snapshot.Snapshot) error {
+func (c *SystemCollector) Collect(ctx context.Context, snap *snapshot.Snapshot) error {
 	hostname, err := os.Hostname()
 	if err != nil {
 		hostname = "unknown"
@@ -26,28 +32,37 @@ func (c *SystemCollector) Collect(snap *snapshot.Snapshot) error {
 	snap.System.Arch = runtime.GOARCH
 	snap.System.CPUCores = runtime.NumCPU()
 
+	var cerr *snapshot.CollectionError
+
 	// Get OS version
-	snap.System.OSVersion = c.getOSVersion()
+	if snap.System.OSVersion, cerr = c.getOSVersion(ctx); cerr != nil {
+		snap.AddError(*cerr)
+	}
 
 	// Get kernel version
-	snap.System.Kernel = c.getKernel()
+	if snap.System.Kernel, cerr = c.getKernel(ctx); cerr != nil {
+		snap.AddError(*cerr)
+	}
 
 	// Get memory
-	snap.System.MemoryGB = c.getMemoryGB()
+	if snap.System.MemoryBytes, cerr = c.getMemory(ctx); cerr != nil {
+		snap.AddError(*cerr)
+	}
+	snap.System.MemoryGB = int(snap.System.MemoryBytes >> 30)
 
 	return nil
 }
 
-func (c *SystemCollector) getOSVersion() string {
+func (c *SystemCollector) getOSVersion(ctx context.Context) (string, *snapshot.CollectionError) {
 	switch runtime.GOOS {
 	case "linux":
-		return c.getLinuxOSVersion()
+		return c.getLinuxOSVersion(), nil
 	case "darwin":
-		return c.getMacOSVersion()
+		return c.getMacOSVersion(ctx)
 	case "windows":
-		return c.getWindowsVersion()
+		return c.getWindowsVersion(ctx)
 	default:
-		return runtime.GOOS
+		return runtime.GOOS, nil
 	}
 }
 
@@ -74,45 +89,65 @@ func (c *SystemCollector) getLinuxOSVersion() string {
 	return "Linux"
 }
 
-func (c *SystemCollector) getMacOSVersion() string {
-	out, err := exec.Command("sw_vers", "-productVersion").Output()
+func (c *SystemCollector) getMacOSVersion(ctx context.Context) (string, *snapshot.CollectionError) {
+	ctx, cancel := probeContext(ctx, c.Timeout)
+	defer cancel()
+
+	cmd := probeCommand(ctx, "sw_vers", "-productVersion")
+	out, err := cmd.Output()
 	if err != nil {
-		return "macOS"
+		return "macOS", commandError(ctx, c.Name(), "os_version", cmd, out, err)
 	}
-	return "macOS " + strings.TrimSpace(string(out))
+	return "macOS " + strings.TrimSpace(string(out)), nil
 }
 
-func (c *SystemCollector) getWindowsVersion() string {
-	out, err := exec.Command("cmd", "/c", "ver").Output()
+func (c *SystemCollector) getWindowsVersion(ctx context.Context) (string, *snapshot.CollectionError) {
+	ctx, cancel := probeContext(ctx, c.Timeout)
+	defer cancel()
+
+	cmd := probeCommand(ctx, "cmd", "/c", "ver")
+	out, err := cmd.Output()
 	if err != nil {
-		return "Windows"
+		return "Windows", commandError(ctx, c.Name(), "os_version", cmd, out, err)
 	}
-	return strings.TrimSpace(string(out))
+	return strings.TrimSpace(string(out)), nil
 }
 
-func (c *SystemCollector) getKernel() string {
-	out, err := exec.Command("uname", "-r").Output()
+func (c *SystemCollector) getKernel(ctx context.Context) (string, *snapshot.CollectionError) {
+	if runtime.GOOS == "windows" {
+		return "", nil // No uname; the version string already covers the kernel
+	}
+	ctx, cancel := probeContext(ctx, c.Timeout)
+	defer cancel()
+
+	cmd := probeCommand(ctx, "uname", "-r")
+	out, err := cmd.Output()
 	if err != nil {
-		return ""
+		return "", commandError(ctx, c.Name(), "kernel", cmd, out, err)
 	}
-	return strings.TrimSpace(string(out))
+	return strings.TrimSpace(string(out)), nil
 }
 
-func (c *SystemCollector) getMemoryGB() int {
+// getMemory returns the host's memory in bytes
+func (c *SystemCollector) getMemory(ctx context.Context) (int64, *snapshot.CollectionError) {
 	switch runtime.GOOS {
 	case "linux":
 		return c.getLinuxMemory()
 	case "darwin":
-		return c.getMacMemory()
+		return c.getMacMemory(ctx)
 	default:
-		return 0
+		return 0, nil
 	}
 }
 
-func (c *SystemCollector) getLinuxMemory() int {
+func (c *SystemCollector) getLinuxMemory() (int64, *snapshot.CollectionError) {
 	file, err := os.Open("/proc/meminfo")
 	if err != nil {
-		return 0
+		return 0, &snapshot.CollectionError{
+			Collector: c.Name(),
+			Probe:     "memory",
+			Message:   err.Error(),
+		}
 	}
 	defer func() { _ = file.Close() }()
 
@@ -125,22 +160,35 @@ func (c *SystemCollector) getLinuxMemory() int {
 			if len(fields) >= 2 {
 				kb, err := strconv.ParseInt(fields[1], 10, 64)
 				if err == nil {
-					return int(kb / 1024 / 1024) // Convert KB to GB
+					return kb << 10, nil
 				}
 			}
 		}
 	}
-	return 0
+	return 0, &snapshot.CollectionError{
+		Collector: c.Name(),
+		Probe:     "memory",
+		Message:   "MemTotal not found in /proc/meminfo",
+	}
 }
 
-func (c *SystemCollector) getMacMemory() int {
-	out, err := exec.Command("sysctl", "-n", "hw.memsize").Output()
+func (c *SystemCollector) getMacMemory(ctx context.Context) (int64, *snapshot.CollectionError) {
+	ctx, cancel := probeContext(ctx, c.Timeout)
+	defer cancel()
+
+	cmd := probeCommand(ctx, "sysctl", "-n", "hw.memsize")
+	out, err := cmd.Output()
 	if err != nil {
-		return 0
+		return 0, commandError(ctx, c.Name(), "memory", cmd, out, err)
 	}
 	bytes, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
 	if err != nil {
-		return 0
+		return 0, &snapshot.CollectionError{
+			Collector: c.Name(),
+			Probe:     "memory",
+			Command:   strings.Join(cmd.Args, " "),
+			Message:   err.Error(),
+		}
 	}
-	return int(bytes / 1024 / 1024 / 1024) // Convert bytes to GB
+	return bytes, nil
 }
@@ -0,0 +1,254 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GBerghoff/envdiff/internal/secrets"
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+// ToolchainCollector records toolchain settings that live outside the
+// environment: go env, npm config, pip config and git config. Two machines
+// with the same go or node version can still build differently because of
+// them. Tools that are not installed are skipped.
+type ToolchainCollector struct {
+	Redact bool
+	// Redactor overrides the default placeholder redaction
+	Redactor *secrets.Redactor
+	Timeout  time.Duration
+}
+
+// Name identifies the collector in collection errors
+func (c *ToolchainCollector) Name() string { return "toolchain" }
+
+// toolchainProbe runs one tool's config listing and parses it
+type toolchainProbe struct {
+	Command string
+	Args    []string
+	Parse   func(out []byte) (*snapshot.ToolConfig, error)
+}
+
+var toolchainProbes = map[string]toolchainProbe{
+	snapshot.ToolchainGo:  {"go", []string{"env", "-json"}, parseGoEnv},
+	snapshot.ToolchainNpm: {"npm", []string{"config", "ls", "-l", "--json"}, parseNpmConfig},
+	snapshot.ToolchainPip: {"python3", []string{"-m", "pip", "config", "list", "--disable-pip-version-check"}, parsePipConfig},
+	snapshot.ToolchainGit: {"git", []string{"config", "--list", "--show-origin", "-z"}, parseGitConfig},
+}
+
+// Collect runs every tool's probe in parallel
+func (c *ToolchainCollector) Collect(ctx context.Context, snap *snapshot.Snapshot) error {
+	var waitGroup sync.WaitGroup
+	var mutex sync.Mutex
+
+	for tool, probe := range toolchainProbes {
+		waitGroup.Add(1)
+		go func(tool string, probe toolchainProbe) {
+			defer waitGroup.Done()
+			config, cerr := c.probe(ctx, tool, probe)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if config != nil {
+				if snap.Toolchain == nil {
+					snap.Toolchain = make(map[string]*snapshot.ToolConfig)
+				}
+				snap.Toolchain[tool] = config
+			}
+			if cerr != nil {
+				snap.AddError(*cerr)
+			}
+		}(tool, probe)
+	}
+	waitGroup.Wait()
+	return nil
+}
+
+func (c *ToolchainCollector) probe(ctx context.Context, tool string, probe toolchainProbe) (*snapshot.ToolConfig, *snapshot.CollectionError) {
+	if _, err := exec.LookPath(probe.Command); err != nil {
+		return nil, nil
+	}
+	ctx, cancel := probeContext(ctx, c.Timeout)
+	defer cancel()
+
+	cmd := probeCommand(ctx, probe.Command, probe.Args...)
+	out, err := cmd.Output()
+	if err != nil {
+		if tool == snapshot.ToolchainPip && ctx.Err() == nil && exitStderrContains(err, "No module named pip") {
+			return nil, nil // python3 without pip
+		}
+		// Without stderr, commandError would store stdout, the full
+		// unredacted config listing
+		return nil, commandError(ctx, c.Name(), tool, cmd, nil, err)
+	}
+	config, perr := probe.Parse(out)
+	if perr != nil {
+		return nil, parseError(c.Name(), tool, cmd, perr)
+	}
+
+	if c.Redact {
+		c.redact(config)
+	}
+	return config, nil
+}
+
+// redact applies the secret rules to a tool's settings
+func (c *ToolchainCollector) redact(config *snapshot.ToolConfig) {
+	if rules := redactSettings(c.Redactor, config.Settings, config.Origins); len(rules) > 0 {
+		config.Redactions = rules
+	}
+}
+
+// redactSettings applies the secret rules to settings as if they were
+// environment variables, returning the rule that fired for each changed
+// setting. Unset values hide nothing and are skipped. Keys can hold URLs
+// too, so their credentials are scrubbed first, moving the setting and its
+// entry in origins, which may be nil. A nil redactor uses the placeholder.
+func redactSettings(redactor *secrets.Redactor, settings, origins map[string]string) map[string]string {
+	if redactor == nil {
+		redactor = &secrets.Redactor{}
+	}
+	rules := make(map[string]string)
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		scrubbed, ok := redactor.ScrubURLs(key, key)
+		if !ok {
+			continue
+		}
+		settings[scrubbed] = settings[key]
+		delete(settings, key)
+		if origin, ok := origins[key]; ok {
+			origins[scrubbed] = origin
+			delete(origins, key)
+		}
+		rules[scrubbed] = secrets.URLCredentialsRuleID
+	}
+
+	set := make(map[string]string)
+	for key, value := range settings {
+		if value != "" {
+			set[key] = value
+		}
+	}
+	redacted, valueRules := redactor.RedactEnv(set)
+	for key, value := range redacted {
+		settings[key] = value
+	}
+	for key, rule := range valueRules {
+		rules[key] = rule
+	}
+	return rules
+}
+
+// goEnvVolatile lists go env keys that change between runs on one machine.
+// GOGCCFLAGS embeds a fresh temporary directory each time.
+var goEnvVolatile = []string{"GOGCCFLAGS"}
+
+// parseGoEnv parses `go env -json`
+func parseGoEnv(out []byte) (*snapshot.ToolConfig, error) {
+	settings := make(map[string]string)
+	if err := json.Unmarshal(out, &settings); err != nil {
+		return nil, err
+	}
+	for _, key := range goEnvVolatile {
+		delete(settings, key)
+	}
+	return &snapshot.ToolConfig{Settings: settings}, nil
+}
+
+// npmInvocationKeys are set by the listing command itself
+var npmInvocationKeys = []string{"long", "json"}
+
+// parseNpmConfig parses `npm config ls -l --json`. Values other than
+// strings are stored in their JSON form, and unset ones as "".
+func parseNpmConfig(out []byte) (*snapshot.ToolConfig, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(out, &raw); err != nil {
+		return nil, err
+	}
+	for _, key := range npmInvocationKeys {
+		delete(raw, key)
+	}
+
+	settings := make(map[string]string, len(raw))
+	for key, value := range raw {
+		var s string
+		switch {
+		case json.Unmarshal(value, &s) == nil:
+			settings[key] = s
+		case string(value) == "null":
+			settings[key] = ""
+		default:
+			settings[key] = string(value)
+		}
+	}
+	return &snapshot.ToolConfig{Settings: settings}, nil
+}
+
+// parsePipConfig parses `pip config list` lines such as
+// global.index-url='https://pypi.org/simple'
+func parsePipConfig(out []byte) (*snapshot.ToolConfig, error) {
+	settings := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %q is not key=value", line)
+		}
+		settings[key] = strings.Trim(value, `'"`)
+	}
+	return &snapshot.ToolConfig{Settings: settings}, nil
+}
+
+// parseGitConfig parses `git config --list --show-origin -z`, a sequence of
+// NUL-terminated origin and "key\nvalue" records. A later value overrides an
+// earlier one, as it does for git. A key without a value is a true boolean.
+func parseGitConfig(out []byte) (*snapshot.ToolConfig, error) {
+	config := &snapshot.ToolConfig{
+		Settings: make(map[string]string),
+		Origins:  make(map[string]string),
+	}
+	fields := bytes.Split(bytes.TrimSuffix(out, []byte{0}), []byte{0})
+	if len(fields) == 1 && len(fields[0]) == 0 {
+		return config, nil
+	}
+	if len(fields)%2 != 0 {
+		return nil, fmt.Errorf("expected origin and setting pairs, got %d fields", len(fields))
+	}
+	for i := 0; i < len(fields); i += 2 {
+		origin := string(fields[i])
+		key, value, ok := strings.Cut(string(fields[i+1]), "\n")
+		if !ok {
+			value = "true"
+		}
+		key = scrubGitURLKey(key)
+		config.Settings[key] = value
+		config.Origins[key] = strings.TrimPrefix(origin, "file:")
+	}
+	return config, nil
+}
+
+// scrubGitURLKey removes credentials from the base URL of a url.<base>.*
+// key. CI jobs inject tokens this way, as in
+// url.https://x-access-token:<token>@github.com/.insteadof, and the key
+// would otherwise carry the token into snapshots and diff field names.
+func scrubGitURLKey(key string) string {
+	rest, ok := strings.CutPrefix(key, "url.")
+	dot := strings.LastIndex(rest, ".")
+	if !ok || dot < 0 {
+		return key
+	}
+	base, _ := secrets.ScrubURLs(rest[:dot])
+	return "url." + base + rest[dot:]
+}
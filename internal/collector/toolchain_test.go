@@ -0,0 +1,174 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GBerghoff/envdiff/internal/secrets"
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+func TestParseGoEnv(t *testing.T) {
+	out := []byte(`{"GOFLAGS": "-mod=mod", "CGO_ENABLED": "0", "GOGCCFLAGS": "-fdebug-prefix-map=/tmp/go-build123=/tmp/go-build"}`)
+	cfg, err := parseGoEnv(out)
+	if err != nil {
+		t.Fatalf("parseGoEnv() error = %v", err)
+	}
+	if cfg.Settings["GOFLAGS"] != "-mod=mod" || cfg.Settings["CGO_ENABLED"] != "0" {
+		t.Errorf("unexpected settings %v", cfg.Settings)
+	}
+	if _, ok := cfg.Settings["GOGCCFLAGS"]; ok {
+		t.Error("GOGCCFLAGS changes on every run and should be dropped")
+	}
+}
+
+func TestParseNpmConfig(t *testing.T) {
+	out := []byte(`{"long": true, "json": true, "registry": "https://registry.npmjs.org/", "audit": true, "cache-min": 0, "proxy": null, "omit": ["dev"]}`)
+	cfg, err := parseNpmConfig(out)
+	if err != nil {
+		t.Fatalf("parseNpmConfig() error = %v", err)
+	}
+	want := map[string]string{
+		"registry":  "https://registry.npmjs.org/",
+		"audit":     "true",
+		"cache-min": "0",
+		"proxy":     "",
+		"omit":      `["dev"]`,
+	}
+	if len(cfg.Settings) != len(want) {
+		t.Fatalf("got %v, want %v", cfg.Settings, want)
+	}
+	for key, value := range want {
+		if cfg.Settings[key] != value {
+			t.Errorf("%s = %q, want %q", key, cfg.Settings[key], value)
+		}
+	}
+}
+
+func TestParsePipConfig(t *testing.T) {
+	out := []byte("global.index-url='https://pypi.example.com/simple'\n:env:.timeout='60'\n")
+	cfg, err := parsePipConfig(out)
+	if err != nil {
+		t.Fatalf("parsePipConfig() error = %v", err)
+	}
+	if cfg.Settings["global.index-url"] != "https://pypi.example.com/simple" || cfg.Settings[":env:.timeout"] != "60" {
+		t.Errorf("unexpected settings %v", cfg.Settings)
+	}
+
+	if _, err := parsePipConfig([]byte("not a setting\n")); err == nil {
+		t.Error("expected an error for a line without =")
+	}
+}
+
+func TestParseGitConfig(t *testing.T) {
+	records := []string{
+		"file:/etc/gitconfig", "core.autocrlf\ninput",
+		"file:/home/me/.gitconfig", "core.autocrlf\nfalse",
+		"file:.git/config", "core.bare",
+		"command line:", "alias.lg\nlog --graph\n--oneline",
+	}
+	cfg, err := parseGitConfig([]byte(strings.Join(records, "\x00") + "\x00"))
+	if err != nil {
+		t.Fatalf("parseGitConfig() error = %v", err)
+	}
+	if cfg.Settings["core.autocrlf"] != "false" || cfg.Origins["core.autocrlf"] != "/home/me/.gitconfig" {
+		t.Errorf("the later value should win, got %q from %q", cfg.Settings["core.autocrlf"], cfg.Origins["core.autocrlf"])
+	}
+	if cfg.Settings["core.bare"] != "true" {
+		t.Errorf("a key without a value is true, got %q", cfg.Settings["core.bare"])
+	}
+	if cfg.Settings["alias.lg"] != "log --graph\n--oneline" {
+		t.Errorf("values may contain newlines, got %q", cfg.Settings["alias.lg"])
+	}
+
+	empty, err := parseGitConfig(nil)
+	if err != nil || len(empty.Settings) != 0 {
+		t.Errorf("empty output should give no settings, got %v, %v", empty, err)
+	}
+}
+
+func TestParseGitConfig_ScrubsInsteadOfCredentials(t *testing.T) {
+	// Assembled at run time so the source holds no credential-shaped URL
+	token := "ghs_" + strings.Repeat("x9Y8", 9)
+	key := "url.https://x-access-token:" + token + "@github.com/.insteadof"
+	cfg, err := parseGitConfig([]byte("file:/home/runner/.gitconfig\x00" + key + "\nhttps://github.com/\x00"))
+	if err != nil {
+		t.Fatalf("parseGitConfig() error = %v", err)
+	}
+
+	want := "url.https://" + secrets.RedactedValue + "@github.com/.insteadof"
+	if cfg.Settings[want] != "https://github.com/" || cfg.Origins[want] != "/home/runner/.gitconfig" {
+		t.Errorf("settings = %v, origins = %v; want the key stored as %q", cfg.Settings, cfg.Origins, want)
+	}
+	for key := range cfg.Settings {
+		if strings.Contains(key, token) {
+			t.Errorf("key %q still holds the token", key)
+		}
+	}
+}
+
+func TestToolchainCollector_Redacts(t *testing.T) {
+	// npm stores registry tokens under keys like //host/:_authToken
+	token := "npm_" + strings.Repeat("a1B2", 9)
+	cfg := &snapshot.ToolConfig{Settings: map[string]string{
+		"//registry.npmjs.org/:_authToken": token,
+		"registry":                         "https://registry.npmjs.org/",
+		"init-author-name":                 "",
+	}}
+
+	c := &ToolchainCollector{Redact: true}
+	c.redact(cfg)
+
+	if cfg.Settings["//registry.npmjs.org/:_authToken"] != secrets.RedactedValue {
+		t.Errorf("the token should be redacted, got %q", cfg.Settings["//registry.npmjs.org/:_authToken"])
+	}
+	if cfg.Settings["registry"] != "https://registry.npmjs.org/" {
+		t.Errorf("registry should be kept, got %q", cfg.Settings["registry"])
+	}
+	if _, ok := cfg.Redactions["init-author-name"]; ok {
+		t.Error("an unset value has nothing to redact")
+	}
+}
+
+func TestToolchainCollector_FailedProbeKeepsOutputOut(t *testing.T) {
+	// A listing that fails after printing settings, with nothing on stderr
+	script := filepath.Join(t.TempDir(), "fake-npm")
+	secret := "//registry.npmjs.org/:_authToken=" + "hunter2"
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho '"+secret+"'\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &ToolchainCollector{Redact: true}
+	_, cerr := c.probe(context.Background(), snapshot.ToolchainNpm, toolchainProbe{Command: script, Parse: parseNpmConfig})
+	if cerr == nil {
+		t.Fatal("a failing probe should be recorded")
+	}
+	if strings.Contains(cerr.Stderr, "hunter2") {
+		t.Errorf("Stderr = %q, should not hold the config listing", cerr.Stderr)
+	}
+}
+
+func TestToolchainCollector_RedactsKeys(t *testing.T) {
+	key := "url.https://ci:" + "hunter2" + "@git.example.com/.insteadof"
+	cfg := &snapshot.ToolConfig{
+		Settings: map[string]string{key: "https://git.example.com/"},
+		Origins:  map[string]string{key: "/etc/gitconfig"},
+	}
+
+	c := &ToolchainCollector{Redact: true}
+	c.redact(cfg)
+
+	want := "url.https://" + secrets.RedactedValue + "@git.example.com/.insteadof"
+	if _, ok := cfg.Settings[key]; ok {
+		t.Error("the key with credentials should be replaced")
+	}
+	if cfg.Settings[want] != "https://git.example.com/" || cfg.Origins[want] != "/etc/gitconfig" {
+		t.Errorf("settings = %v, origins = %v; want the key scrubbed to %q", cfg.Settings, cfg.Origins, want)
+	}
+	if cfg.Redactions[want] != secrets.URLCredentialsRuleID {
+		t.Errorf("Redactions = %v, want the scrubbed key recorded", cfg.Redactions)
+	}
+}
@@ -0,0 +1,255 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+	"github.com/Masterminds/semver/v3"
+)
+
+// versionManager describes where a version manager keeps its installs
+type versionManager struct {
+	Name        string
+	RootEnv     string            // variable that overrides the root
+	DefaultRoot string            // root relative to the home directory
+	Installs    map[string]string // runtime -> directory of installed versions, relative to the root
+}
+
+// versionManagers lists the supported managers. asdf and mise name some
+// runtimes differently (asdf's nodejs and golang plugins).
+var versionManagers = []versionManager{
+	{
+		Name: "asdf", RootEnv: "ASDF_DATA_DIR", DefaultRoot: ".asdf",
+		Installs: map[string]string{
+			"go": "installs/golang", "node": "installs/nodejs", "python": "installs/python",
+			"ruby": "installs/ruby", "java": "installs/java", "rust": "installs/rust",
+			"terraform": "installs/terraform", "kubectl": "installs/kubectl",
+		},
+	},
+	{
+		Name: "mise", RootEnv: "MISE_DATA_DIR", DefaultRoot: ".local/share/mise",
+		Installs: map[string]string{
+			"go": "installs/go", "node": "installs/node", "python": "installs/python",
+			"ruby": "installs/ruby", "java": "installs/java", "rust": "installs/rust",
+			"terraform": "installs/terraform", "kubectl": "installs/kubectl",
+		},
+	},
+	{Name: "nvm", RootEnv: "NVM_DIR", DefaultRoot: ".nvm", Installs: map[string]string{"node": "versions/node"}},
+	{Name: "pyenv", RootEnv: "PYENV_ROOT", DefaultRoot: ".pyenv", Installs: map[string]string{"python": "versions"}},
+	{Name: "rbenv", RootEnv: "RBENV_ROOT", DefaultRoot: ".rbenv", Installs: map[string]string{"ruby": "versions"}},
+	{Name: "sdkman", RootEnv: "SDKMAN_DIR", DefaultRoot: ".sdkman", Installs: map[string]string{"java": "candidates/java"}},
+	{Name: "goenv", RootEnv: "GOENV_ROOT", DefaultRoot: ".goenv", Installs: map[string]string{"go": "versions"}},
+}
+
+// root returns the manager's data directory
+func (m versionManager) root() string {
+	if dir := os.Getenv(m.RootEnv); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, m.DefaultRoot)
+}
+
+// installed lists the versions of a runtime the manager has installed
+func (m versionManager) installed(runtime string) []string {
+	dir, ok := m.Installs[runtime]
+	if !ok {
+		return nil
+	}
+	entries, err := os.ReadDir(filepath.Join(m.root(), dir))
+	if err != nil {
+		return nil
+	}
+	var versions []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if name == "current" || strings.HasPrefix(name, ".") {
+			continue // sdkman's symlink to the default version
+		}
+		versions = append(versions, strings.TrimPrefix(name, "v"))
+	}
+	sortVersions(versions)
+	return versions
+}
+
+// sortVersions orders versions oldest first, so 3.9 sorts before 3.10.
+// Names that are not versions, such as "system" or "lts", sort last.
+func sortVersions(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		vi, erri := semver.NewVersion(versions[i])
+		vj, errj := semver.NewVersion(versions[j])
+		switch {
+		case erri == nil && errj == nil:
+			return vi.LessThan(vj)
+		case erri == nil || errj == nil:
+			return erri == nil
+		default:
+			return versions[i] < versions[j]
+		}
+	})
+}
+
+// managerFor returns the version manager whose root holds path, a shim or
+// an install directory, or nil for a system install
+func managerFor(path string) *versionManager {
+	for i, m := range versionManagers {
+		root := m.root()
+		if root == "" {
+			continue
+		}
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return &versionManagers[i]
+		}
+	}
+	return nil
+}
+
+// Pin is a runtime version requested by a file in the project
+type Pin struct {
+	Version string
+	File    string // relative to the project root, e.g. .nvmrc or ../.tool-versions
+}
+
+// pinFiles are checked in order within each directory, so a runtime's own
+// file wins over .tool-versions in the same directory
+var pinFiles = []struct {
+	Name  string
+	Parse func(content string) map[string]string
+}{
+	{".nvmrc", singlePin("node")},
+	{".python-version", singlePin("python")},
+	{".ruby-version", singlePin("ruby")},
+	{".go-version", singlePin("go")},
+	{".sdkmanrc", parseSdkmanrc},
+	{".tool-versions", parseToolVersions},
+}
+
+// FindPins returns the versions pinned for each runtime by files in root or
+// its parents. Like the version managers themselves, the nearest file wins.
+func FindPins(root string) map[string]Pin {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil
+	}
+
+	pins := make(map[string]Pin)
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		for _, pinFile := range pinFiles {
+			path := filepath.Join(dir, pinFile.Name)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			rel, err := filepath.Rel(abs, path)
+			if err != nil {
+				rel = path
+			}
+			for runtime, version := range pinFile.Parse(string(data)) {
+				if _, seen := pins[runtime]; !seen && version != "" {
+					pins[runtime] = Pin{Version: version, File: rel}
+				}
+			}
+		}
+		if filepath.Dir(dir) == dir {
+			return pins
+		}
+	}
+}
+
+// singlePin parses a file holding one version, such as .nvmrc
+func singlePin(runtime string) func(string) map[string]string {
+	return func(content string) map[string]string {
+		for _, line := range strings.Split(content, "\n") {
+			line = strings.TrimSpace(line)
+			if line != "" && !strings.HasPrefix(line, "#") {
+				return map[string]string{runtime: line}
+			}
+		}
+		return nil
+	}
+}
+
+// toolVersionsNames maps asdf plugin names to runtime names
+var toolVersionsNames = map[string]string{"nodejs": "node", "golang": "go"}
+
+// parseToolVersions parses .tool-versions lines such as "nodejs 20.11.0".
+// Only the first of several listed versions is active.
+func parseToolVersions(content string) map[string]string {
+	pins := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		runtime := fields[0]
+		if name, ok := toolVersionsNames[runtime]; ok {
+			runtime = name
+		}
+		pins[runtime] = fields[1]
+	}
+	return pins
+}
+
+// parseSdkmanrc parses .sdkmanrc lines such as "java=17.0.9-tem"
+func parseSdkmanrc(content string) map[string]string {
+	pins := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if candidate, version, ok := strings.Cut(line, "="); ok {
+			pins[strings.TrimSpace(candidate)] = strings.TrimSpace(version)
+		}
+	}
+	return pins
+}
+
+// VersionManagerCollector records which version manager provides each
+// registered runtime, what else it has installed, and the version the
+// project pins. It reads the runtime paths, so it runs after RuntimeCollector.
+type VersionManagerCollector struct {
+	Root string // project root to search for pin files; empty skips them
+}
+
+// Name identifies the collector in collection errors
+func (c *VersionManagerCollector) Name() string { return "version-manager" }
+
+// Collect fills snap.VersionManagers for runtimes that are managed or pinned
+func (c *VersionManagerCollector) Collect(_ context.Context, snap *snapshot.Snapshot) error {
+	var pins map[string]Pin
+	if c.Root != "" {
+		pins = FindPins(c.Root)
+	}
+
+	for name := range Registry {
+		info := &snapshot.VersionManagerInfo{}
+		if runtime := snap.Runtime[name]; runtime != nil {
+			if m := managerFor(runtime.Path); m != nil {
+				info.Manager = m.Name
+				info.Installed = m.installed(name)
+			}
+		}
+		if pin, ok := pins[name]; ok {
+			info.Pinned, info.PinFile = pin.Version, pin.File
+		}
+		if info.Manager == "" && info.Pinned == "" {
+			continue
+		}
+		if snap.VersionManagers == nil {
+			snap.VersionManagers = make(map[string]*snapshot.VersionManagerInfo)
+		}
+		snap.VersionManagers[name] = info
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+func TestFindPins_NearestFileWins(t *testing.T) {
+	parent := writeProject(t, map[string]string{
+		".tool-versions": "nodejs 20.11.0\ngolang 1.22.1 1.21.0 # fallback\npython 3.12.1\n",
+	})
+	child := filepath.Join(parent, "app")
+	if err := os.Mkdir(child, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		".nvmrc":          "v18\n",
+		".python-version": "3.11.7\n",
+		".sdkmanrc":       "# Enable auto-env\njava=17.0.9-tem\n",
+	} {
+		if err := os.WriteFile(filepath.Join(child, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pins := FindPins(child)
+	want := map[string]Pin{
+		"node":   {Version: "v18", File: ".nvmrc"},
+		"python": {Version: "3.11.7", File: ".python-version"},
+		"java":   {Version: "17.0.9-tem", File: ".sdkmanrc"},
+		"go":     {Version: "1.22.1", File: filepath.Join("..", ".tool-versions")},
+	}
+	for runtime, pin := range want {
+		if pins[runtime] != pin {
+			t.Errorf("%s = %+v, want %+v", runtime, pins[runtime], pin)
+		}
+	}
+}
+
+func TestVersionManagerCollector_Collect(t *testing.T) {
+	nvmDir := t.TempDir()
+	for _, version := range []string{"v18.19.0", "v20.11.0", "v9.11.2"} {
+		if err := os.MkdirAll(filepath.Join(nvmDir, "versions", "node", version, "bin"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("NVM_DIR", nvmDir)
+	root := writeProject(t, map[string]string{".nvmrc": "18\n"})
+
+	snap := snapshot.New()
+	snap.Runtime["node"] = &snapshot.RuntimeInfo{
+		Version: "20.11.0",
+		Path:    filepath.Join(nvmDir, "versions", "node", "v20.11.0", "bin", "node"),
+	}
+	snap.Runtime["git"] = &snapshot.RuntimeInfo{Version: "2.43.0", Path: "/usr/bin/git"}
+
+	if err := (&VersionManagerCollector{Root: root}).Collect(context.Background(), snap); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	node := snap.VersionManagers["node"]
+	if node == nil {
+		t.Fatal("expected node to be recorded")
+	}
+	if node.Manager != "nvm" {
+		t.Errorf("Manager = %q, want nvm", node.Manager)
+	}
+	if len(node.Installed) != 3 || node.Installed[0] != "9.11.2" || node.Installed[2] != "20.11.0" {
+		t.Errorf("Installed = %v, want versions in semver order", node.Installed)
+	}
+	if node.Pinned != "18" || node.PinFile != ".nvmrc" {
+		t.Errorf("pin = %q from %q, want 18 from .nvmrc", node.Pinned, node.PinFile)
+	}
+	if _, ok := snap.VersionManagers["git"]; ok {
+		t.Error("a system install without a pin should not be recorded")
+	}
+}
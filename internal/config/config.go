@@ -3,7 +3,12 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -13,8 +18,15 @@ type Config struct {
 	Runtime        map[string]string   `yaml:"runtime"`
 	Env            EnvConfig           `yaml:"env"`
 	Packages       []string            `yaml:"packages,omitempty"`
+	Pinned         []string            `yaml:"pinned,omitempty"` // runtimes whose active version must match their pin file; "*" for all
+	Shadowed       []string            `yaml:"shadowed,omitempty"` // runtimes to warn about when another install on PATH is shadowed; "*" for all
+	Repo           RepoConfig          `yaml:"repo,omitempty"`
+	Filesystems    FilesystemConfig    `yaml:"filesystems,omitempty"`
+	Limits         LimitsConfig        `yaml:"limits,omitempty"`
+	Sysctl         map[string]string   `yaml:"sysctl,omitempty"` // kernel parameter -> constraint such as ">= 524288"; "" only records it
 	CustomRuntimes []CustomRuntimeConfig `yaml:"custom_runtimes,omitempty"`
 	Fix            map[string]FixConfig `yaml:"fix,omitempty"`
+	Secrets        SecretsConfig        `yaml:"secrets,omitempty"`
 }
 
 // CustomRuntimeConfig defines a project-specific tool to probe
@@ -23,6 +35,19 @@ type CustomRuntimeConfig struct {
 	Command   string   `yaml:"command"`
 	Args      []string `yaml:"args,omitempty"`
 	VersionRE string   `yaml:"regex"`
+	Timeout   string   `yaml:"timeout,omitempty"` // e.g. "30s"; overrides --timeout
+}
+
+// TimeoutDuration parses the probe timeout, returning 0 if none is set
+func (c CustomRuntimeConfig) TimeoutDuration() (time.Duration, error) {
+	if c.Timeout == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(c.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q: %w", c.Timeout, err)
+	}
+	return d, nil
 }
 
 // EnvConfig holds environment variable requirements
@@ -30,6 +55,146 @@ type EnvConfig struct {
 	Required []string          `yaml:"required,omitempty"`
 	Expected map[string]string `yaml:"expected,omitempty"`
 	Ignore   []string          `yaml:"ignore,omitempty"`
+	Lists    map[string]string `yaml:"lists,omitempty"` // list-valued variables -> entry separator, added to DefaultEnvLists
+}
+
+// DefaultEnvLists are the variables compared entry by entry rather than as
+// one string, with the separator between their entries
+var DefaultEnvLists = map[string]string{
+	"PATH":            ":",
+	"LD_LIBRARY_PATH": ":",
+	"PYTHONPATH":      ":",
+	"MANPATH":         ":",
+	"GOFLAGS":         " ",
+}
+
+// ListSeparators returns DefaultEnvLists merged with the configured lists.
+// An empty separator removes a default.
+func (e EnvConfig) ListSeparators() map[string]string {
+	separators := make(map[string]string, len(DefaultEnvLists)+len(e.Lists))
+	for name, sep := range DefaultEnvLists {
+		separators[name] = sep
+	}
+	for name, sep := range e.Lists {
+		if sep == "" {
+			delete(separators, name)
+			continue
+		}
+		separators[name] = sep
+	}
+	return separators
+}
+
+// RepoConfig holds requirements on the git checkout being checked
+type RepoConfig struct {
+	Clean      bool              `yaml:"clean,omitempty"`      // no changed or untracked files
+	Branch     string            `yaml:"branch,omitempty"`     // branch that must be checked out
+	Submodules bool              `yaml:"submodules,omitempty"` // every submodule initialized
+	LFS        bool              `yaml:"lfs,omitempty"`        // LFS content fetched, not just pointers
+	Hooks      []string          `yaml:"hooks,omitempty"`      // hooks that must be installed
+	Config     map[string]string `yaml:"config,omitempty"`     // expected git settings, e.g. core.autocrlf
+}
+
+// IsSet reports whether any repo requirement is configured
+func (r RepoConfig) IsSet() bool {
+	return r.Clean || r.Branch != "" || r.Submodules || r.LFS || len(r.Hooks) > 0 || len(r.Config) > 0
+}
+
+// FilesystemConfig holds requirements on the filesystems a snapshot
+// records, keyed by role (project, tmp, home, docker) or "*" for all of them
+type FilesystemConfig struct {
+	MinFree          map[string]string   `yaml:"min_free,omitempty"`          // role -> size such as "5GB"
+	ForbiddenOptions map[string][]string `yaml:"forbidden_options,omitempty"` // role -> mount options such as noexec
+}
+
+// IsSet reports whether any filesystem requirement is configured
+func (f FilesystemConfig) IsSet() bool {
+	return len(f.MinFree) > 0 || len(f.ForbiddenOptions) > 0
+}
+
+// LimitsConfig holds constraints on the effective resource limits, such as
+// ">= 2" CPUs or ">= 4GB" of memory. Sizes take the units ParseSize accepts,
+// and "unlimited" is larger than any number.
+type LimitsConfig struct {
+	CPUs      string `yaml:"cpus,omitempty"`
+	Memory    string `yaml:"memory,omitempty"`
+	Pids      string `yaml:"pids,omitempty"`
+	OpenFiles string `yaml:"open_files,omitempty"` // ulimit -n
+	Processes string `yaml:"processes,omitempty"`  // ulimit -u
+	Stack     string `yaml:"stack,omitempty"`      // ulimit -s, as a size
+}
+
+// sizeUnits are the size suffixes ParseSize accepts. Like df -h they are
+// powers of 1024, so "1GB" and "1GiB" are the same size.
+var sizeUnits = []struct {
+	Suffixes []string
+	Bytes    uint64
+}{
+	{[]string{"TIB", "TB", "T"}, 1 << 40},
+	{[]string{"GIB", "GB", "G"}, 1 << 30},
+	{[]string{"MIB", "MB", "M"}, 1 << 20},
+	{[]string{"KIB", "KB", "K"}, 1 << 10},
+	{[]string{"B", ""}, 1},
+}
+
+// ParseSize parses a size such as "500MB", "1.5G" or "1024"
+func ParseSize(s string) (uint64, error) {
+	upper := strings.ToUpper(strings.TrimSpace(s))
+	for _, unit := range sizeUnits {
+		for _, suffix := range unit.Suffixes {
+			number, ok := strings.CutSuffix(upper, suffix)
+			if !ok {
+				continue
+			}
+			n, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid size %q", s)
+			}
+			return uint64(n * float64(unit.Bytes)), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid size %q", s)
+}
+
+// FormatSize formats a byte count in the largest unit ParseSize accepts,
+// e.g. "12.4GB"
+func FormatSize(bytes uint64) string {
+	for _, unit := range sizeUnits[:len(sizeUnits)-1] {
+		if bytes >= unit.Bytes {
+			return strconv.FormatFloat(float64(bytes)/float64(unit.Bytes), 'f', 1, 64) + unit.Suffixes[1]
+		}
+	}
+	return fmt.Sprintf("%dB", bytes)
+}
+
+// FormatLimit formats a count limit, where a negative limit is unlimited
+func FormatLimit(n int64) string {
+	if n < 0 {
+		return "unlimited"
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// FormatSizeLimit formats a size limit, where a negative limit is unlimited
+func FormatSizeLimit(bytes int64) string {
+	if bytes < 0 {
+		return "unlimited"
+	}
+	return FormatSize(uint64(bytes))
+}
+
+// SecretsConfig customizes which environment variables are redacted
+type SecretsConfig struct {
+	Patterns        []string      `yaml:"patterns,omitempty"`         // extra name regexes
+	Allow           []string      `yaml:"allow,omitempty"`            // names never redacted (glob)
+	DisableDefaults bool          `yaml:"disable_defaults,omitempty"` // drop built-in name patterns
+	Entropy         EntropyConfig `yaml:"entropy,omitempty"`
+}
+
+// EntropyConfig tunes the high-entropy value check
+type EntropyConfig struct {
+	MinLength  int     `yaml:"min_length,omitempty"` // negative disables the check
+	MinEntropy float64 `yaml:"min_entropy,omitempty"` // bits per character
 }
 
 // FixConfig holds remediation hints
@@ -92,6 +257,16 @@ func (c *Config) ToYAML() ([]byte, error) {
 	return yaml.Marshal(c)
 }
 
+// SysctlKeys returns the kernel parameters named under sysctl:, sorted
+func (c *Config) SysctlKeys() []string {
+	keys := make([]string, 0, len(c.Sysctl))
+	for key := range c.Sysctl {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // GetRequiredRuntimes returns a map of runtime names that should be probed.
 // This is used by the collector to filter what it searches for.
 func (c *Config) GetRequiredRuntimes() map[string]bool {
@@ -144,6 +319,61 @@ env:
     - "*_SESSION*"
     - "*_TOKEN*"
 
+  # Variables compared entry by entry, with their separator. PATH,
+  # LD_LIBRARY_PATH, PYTHONPATH, MANPATH (":") and GOFLAGS (" ") are
+  # built in; an empty separator turns one off.
+  # lists:
+  #   CLASSPATH: ":"
+  #   CFLAGS: " "
+
+# Runtimes whose active version must match the version pinned by
+# .tool-versions, .nvmrc, .python-version, .ruby-version, .go-version
+# or .sdkmanrc in this directory or a parent ("*" checks every pin found)
+# pinned:
+#   - node
+#   - python
+
+# Runtimes to warn about when more than one install is on PATH and the
+# later ones are shadowed by the first ("*" checks every runtime)
+# shadowed:
+#   - python
+
+# Requirements on the git checkout envdiff runs in
+# repo:
+#   clean: true          # no uncommitted or untracked files
+#   branch: main
+#   submodules: true     # every submodule initialized
+#   lfs: true            # LFS content fetched, not just pointers
+#   hooks:
+#     - pre-commit
+#   config:
+#     core.autocrlf: input
+
+# Filesystems holding the project, $TMPDIR, $HOME and the docker data
+# root, keyed by role: project, tmp, home, docker ("*" for all)
+# filesystems:
+#   min_free:
+#     tmp: 2GB
+#     docker: 20GB
+#   forbidden_options:
+#     tmp: [noexec]
+
+# Effective resource limits: cgroup CPU, memory and pids limits where
+# lower than the host's, and the ulimit -n, -u and -s soft limits
+# limits:
+#   cpus: ">= 2"
+#   memory: ">= 4GB"
+#   open_files: ">= 65536"
+#   stack: ">= 8MB"
+
+# Kernel parameters from /proc/sys to record and check. Numeric values
+# take >=, <=, >, <, = or !=; others must match exactly. An empty
+# constraint records the parameter without checking it.
+# sysctl:
+#   fs.inotify.max_user_watches: ">= 524288"
+#   vm.max_map_count: ">= 262144"
+#   net.ipv4.tcp_congestion_control: bbr
+
 # Packages to verify (opt-in, not checked by default)
 # packages:
 #   - nginx
@@ -155,6 +385,19 @@ env:
 #     command: "internal-cli"
 #     args: ["--version"]
 #     regex: "v(\\d+\\.\\d+)"
+#     timeout: "30s"  # overrides --timeout for slow tools
+
+# Secret redaction rules for 'envdiff snapshot'
+# secrets:
+#   patterns:            # extra name patterns (regular expressions)
+#     - "_PASSPHRASE_FILE$"
+#   allow:               # names never redacted (glob patterns supported)
+#     - AUTHOR_NAME
+#     - "*_CERT_DIR"
+#   disable_defaults: false  # true drops the built-in name patterns
+#   entropy:             # flag long random-looking values
+#     min_length: 32     # -1 turns the check off
+#     min_entropy: 4.5
 
 # Remediation hints (shown when check fails)
 # fix:
@@ -189,6 +189,63 @@ custom_runtimes:
 	}
 }
 
+func TestConfig_Secrets(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "envdiff.yaml")
+
+	yamlContent := `
+secrets:
+  patterns:
+    - "_PASSPHRASE_FILE$"
+  allow:
+    - AUTHOR_NAME
+    - "*_CERT_DIR"
+  disable_defaults: true
+  entropy:
+    min_length: 40
+    min_entropy: 4.8
+`
+	if err := os.WriteFile(configPath, []byte(yamlContent), 0600); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if len(cfg.Secrets.Patterns) != 1 || cfg.Secrets.Patterns[0] != "_PASSPHRASE_FILE$" {
+		t.Errorf("Secrets.Patterns = %v", cfg.Secrets.Patterns)
+	}
+	if len(cfg.Secrets.Allow) != 2 {
+		t.Errorf("Secrets.Allow = %v, want 2 entries", cfg.Secrets.Allow)
+	}
+	if !cfg.Secrets.DisableDefaults {
+		t.Error("Secrets.DisableDefaults should be true")
+	}
+	if cfg.Secrets.Entropy.MinLength != 40 || cfg.Secrets.Entropy.MinEntropy != 4.8 {
+		t.Errorf("Secrets.Entropy = %+v", cfg.Secrets.Entropy)
+	}
+}
+
+func TestEnvConfig_ListSeparators(t *testing.T) {
+	env := EnvConfig{Lists: map[string]string{"CLASSPATH": ":", "GOFLAGS": ""}}
+	separators := env.ListSeparators()
+
+	if separators["PATH"] != ":" {
+		t.Errorf("PATH separator = %q, want the default", separators["PATH"])
+	}
+	if separators["CLASSPATH"] != ":" {
+		t.Errorf("CLASSPATH separator = %q, want :", separators["CLASSPATH"])
+	}
+	if _, ok := separators["GOFLAGS"]; ok {
+		t.Error("an empty separator should remove GOFLAGS")
+	}
+	if DefaultEnvLists["GOFLAGS"] != " " {
+		t.Error("ListSeparators() must not modify DefaultEnvLists")
+	}
+}
+
 func TestTemplate(t *testing.T) {
 	template := Template()
 
@@ -203,3 +260,36 @@ func TestTemplate(t *testing.T) {
 	}
 }
 
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want uint64
+	}{
+		{"1024", 1024},
+		{"512B", 512},
+		{"4k", 4 << 10},
+		{"500MB", 500 << 20},
+		{"1.5G", 3 << 29},
+		{"2GiB", 2 << 30},
+		{" 1 TB ", 1 << 40},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "GB", "-1GB", "5 apples"} {
+		if _, err := ParseSize(bad); err == nil {
+			t.Errorf("ParseSize(%q) should fail", bad)
+		}
+	}
+
+	if got := FormatSize(3 << 29); got != "1.5GB" {
+		t.Errorf("FormatSize() = %q, want 1.5GB", got)
+	}
+	if got := FormatSize(100); got != "100B" {
+		t.Errorf("FormatSize() = %q, want 100B", got)
+	}
+}
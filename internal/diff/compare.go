@@ -3,45 +3,134 @@
 package diff
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/GBerghoff/envdiff/internal/config"
 	"github.com/GBerghoff/envdiff/internal/secrets"
 	"github.com/GBerghoff/envdiff/internal/snapshot"
 )
 
+// Options controls how snapshots are compared
+type Options struct {
+	// Ignore holds glob patterns for fields to leave out of the diff.
+	// A pattern matches either the bare field name ("PWD") or the
+	// section-qualified name ("runtime.go").
+	Ignore []string
+	// ListSeparators maps list-valued variables such as PATH to their
+	// entry separator. Nil means config.DefaultEnvLists.
+	ListSeparators map[string]string
+}
+
 // Compare compares multiple snapshots and produces a Diff
-func Compare(snapshots map[string]*snapshot.Snapshot) *Diff {
+func Compare(snapshots map[string]*snapshot.Snapshot, opts Options) *Diff {
 	result := New()
 
-	// Build node list
+	// Build node list, sorted so that baselines and output are the same on
+	// every run
 	for name := range snapshots {
 		result.Nodes = append(result.Nodes, name)
 		result.Snapshots[name] = snapshots[name]
 	}
+	sort.Strings(result.Nodes)
+
+	// Nodes whose snapshots recorded collection errors are incomplete
+	for name, snap := range snapshots {
+		if msg := collectionErrorSummary(snap); msg != "" {
+			result.Errors[name] = msg
+			result.Summary.FailedNodes++
+		}
+	}
 
 	result.Summary.TotalNodes = len(result.Nodes)
-	result.Summary.SuccessfulNodes = len(result.Nodes)
+	result.Summary.SuccessfulNodes = len(result.Nodes) - result.Summary.FailedNodes
 
 	// Initialize diff sections
 	result.Diffs["system"] = make(map[string]*FieldDiff)
 	result.Diffs["runtime"] = make(map[string]*FieldDiff)
 	result.Diffs["env"] = make(map[string]*FieldDiff)
 	result.Diffs["package"] = make(map[string]*FieldDiff)
+	result.Diffs["network"] = make(map[string]*FieldDiff)
 
 	// Compare system fields
-	compareSystemFields(result, snapshots)
+	compareSystemFields(result, snapshots, opts)
 
 	// Compare runtime versions
-	compareRuntimeFields(result, snapshots)
+	compareRuntimeFields(result, snapshots, opts)
 
 	// Compare environment variables
-	compareEnvFields(result, snapshots)
+	compareEnvFields(result, snapshots, opts)
 
 	// Compare system packages
-	comparePackageFields(result, snapshots)
+	comparePackageFields(result, snapshots, opts)
+
+	// Compare hosts entries and listening ports
+	compareNetworkFields(result, snapshots, opts)
+
+	// Compare language package inventories, one section per ecosystem
+	compareInventories(result, snapshots, opts)
+
+	// Compare toolchain configuration (go env, npm, pip, git config)
+	compareToolchainFields(result, snapshots, opts)
+
+	// Compare project lockfiles and the dependency versions they resolve
+	compareProjectFields(result, snapshots, opts)
+
+	// Compare the git checkout each snapshot was taken in
+	compareRepoFields(result, snapshots, opts)
+
+	// Compare the filesystems holding the project, tmp, home and docker
+	compareFilesystemFields(result, snapshots, opts)
+
+	// Compare the effective CPU, memory and process limits
+	compareLimitsFields(result, snapshots, opts)
+
+	// Compare kernel parameters
+	compareKernelFields(result, snapshots, opts)
+
+	// Rank differences so renderers can surface the ones that matter
+	scoreDiffs(result)
 
 	return result
 }
 
-func comparePackageFields(result *Diff, snapshots map[string]*snapshot.Snapshot) {
+// collectionErrorSummary describes the probes that failed in a snapshot,
+// or returns "" if collection was complete
+func collectionErrorSummary(snap *snapshot.Snapshot) string {
+	if len(snap.CollectionErrors) == 0 {
+		return ""
+	}
+
+	probes := make([]string, 0, len(snap.CollectionErrors))
+	for _, e := range snap.CollectionErrors {
+		probe := e.Collector
+		if e.Probe != "" {
+			probe += "/" + e.Probe
+		}
+		if e.ExitCode != 0 {
+			probe += fmt.Sprintf(" (exit %d)", e.ExitCode)
+		}
+		probes = append(probes, probe)
+	}
+
+	noun := "probes"
+	if len(probes) == 1 {
+		noun = "probe"
+	}
+	return fmt.Sprintf("incomplete snapshot: %d %s failed: %s", len(probes), noun, strings.Join(probes, ", "))
+}
+
+// ignored reports whether a field should be left out of the diff
+func (o Options) ignored(section, name string) bool {
+	if len(o.Ignore) == 0 {
+		return false
+	}
+	return matchField(section, name, o.Ignore)
+}
+
+func comparePackageFields(result *Diff, snapshots map[string]*snapshot.Snapshot, opts Options) {
 	// Gather all package keys across all snapshots
 	allPackages := make(map[string]bool)
 	for _, snap := range snapshots {
@@ -53,6 +142,10 @@ func comparePackageFields(result *Diff, snapshots map[string]*snapshot.Snapshot)
 	}
 
 	for pkg := range allPackages {
+		if opts.ignored("package", pkg) {
+			result.Summary.Ignored++
+			continue
+		}
 		values := make(map[string]any)
 		for name, snap := range snapshots {
 			if snap.Packages != nil {
@@ -65,12 +158,290 @@ func comparePackageFields(result *Diff, snapshots map[string]*snapshot.Snapshot)
 				values[name] = nil
 			}
 		}
-		result.Diffs["package"][pkg] = createFieldDiff(values, result.Nodes)
-		updateSummary(result, result.Diffs["package"][pkg])
+		fieldDiff := createFieldDiff(values, result.Nodes)
+		classifyVersions(fieldDiff)
+		result.Diffs["package"][pkg] = fieldDiff
+		updateSummary(result, fieldDiff)
+	}
+}
+
+// compareInventories adds a section for each ecosystem (pip, npm, ...) that
+// any snapshot has an inventory for. Packages are fields, versions values.
+func compareInventories(result *Diff, snapshots map[string]*snapshot.Snapshot, opts Options) {
+	for _, ecosystem := range snapshot.InventoryEcosystems {
+		allPackages := make(map[string]bool)
+		for _, snap := range snapshots {
+			for pkg := range snap.Inventories[ecosystem] {
+				allPackages[pkg] = true
+			}
+		}
+		if len(allPackages) == 0 {
+			continue
+		}
+
+		result.Diffs[ecosystem] = make(map[string]*FieldDiff)
+		for pkg := range allPackages {
+			if opts.ignored(ecosystem, pkg) {
+				result.Summary.Ignored++
+				continue
+			}
+			values := make(map[string]any)
+			for name, snap := range snapshots {
+				if version, ok := snap.Inventories[ecosystem][pkg]; ok {
+					values[name] = version
+				} else {
+					values[name] = nil
+				}
+			}
+			fieldDiff := createFieldDiff(values, result.Nodes)
+			classifyVersions(fieldDiff)
+			result.Diffs[ecosystem][pkg] = fieldDiff
+			updateSummary(result, fieldDiff)
+		}
 	}
 }
 
-func compareSystemFields(result *Diff, snapshots map[string]*snapshot.Snapshot) {
+// compareProjectFields produces one field per lockfile holding a short
+// content hash, and one per direct dependency ("<lockfile>:<name>") holding
+// the resolved version, so a diff names the dependency that moved
+func compareProjectFields(result *Diff, snapshots map[string]*snapshot.Snapshot, opts Options) {
+	diffs := compareMapFields(result, snapshots, opts, "project", func(snap *snapshot.Snapshot) map[string]any {
+		if snap.Project == nil {
+			return nil
+		}
+		fields := make(map[string]any)
+		for file, lockfile := range snap.Project.Lockfiles {
+			fields[file] = "sha256:" + truncateHash(lockfile.SHA256)
+			for dep, version := range lockfile.Dependencies {
+				fields[file+":"+dep] = version
+			}
+		}
+		return fields
+	})
+	for field, fieldDiff := range diffs {
+		if fieldDiff.Status == StatusDifferent && strings.Contains(field, ":") {
+			// compareMapFields counted the field as different already
+			if classifyVersions(fieldDiff); fieldDiff.Status == StatusEqual {
+				result.Summary.Different--
+				result.Summary.Equal++
+			}
+		}
+	}
+}
+
+// compareMapFields adds a section holding the union of the fields get
+// returns for each node. A node without a field, or for which get returns
+// nil, compares as nil. The section is left out when no node has fields.
+func compareMapFields(result *Diff, snapshots map[string]*snapshot.Snapshot, opts Options, section string, get func(*snapshot.Snapshot) map[string]any) map[string]*FieldDiff {
+	fields := make(map[string]map[string]any) // field -> node -> value
+	for name, snap := range snapshots {
+		for field, value := range get(snap) {
+			if fields[field] == nil {
+				fields[field] = make(map[string]any)
+			}
+			fields[field][name] = value
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	result.Diffs[section] = make(map[string]*FieldDiff)
+	for field, nodeValues := range fields {
+		if opts.ignored(section, field) {
+			result.Summary.Ignored++
+			continue
+		}
+		values := make(map[string]any)
+		for name := range snapshots {
+			values[name] = nodeValues[name]
+		}
+		fieldDiff := createFieldDiff(values, result.Nodes)
+		result.Diffs[section][field] = fieldDiff
+		updateSummary(result, fieldDiff)
+	}
+	return result.Diffs[section]
+}
+
+// truncateHash shortens a content hash for display; 12 hex digits are
+// plenty to tell lockfiles apart
+func truncateHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+// compareRepoFields compares the git checkouts. Submodules, hooks and git
+// settings get one field each ("submodule/<path>", "hook/<name>",
+// "config/<key>"), so a single stale submodule or extra hook stands out.
+func compareRepoFields(result *Diff, snapshots map[string]*snapshot.Snapshot, opts Options) {
+	compareMapFields(result, snapshots, opts, "repo", func(snap *snapshot.Snapshot) map[string]any {
+		repo := snap.Repo
+		if repo == nil {
+			return nil
+		}
+		dirtyHash := "clean"
+		if repo.DirtyHash != "" {
+			dirtyHash = "sha256:" + truncateHash(repo.DirtyHash)
+		}
+		fields := map[string]any{
+			"head":       truncateHash(repo.Head),
+			"branch":     repo.Branch,
+			"dirty":      repo.Dirty,
+			"untracked":  repo.Untracked,
+			"dirty_hash": dirtyHash,
+		}
+		for path, commit := range repo.Submodules {
+			commit = truncateHash(commit)
+			if commit == "" {
+				commit = submoduleNotInitialized
+			}
+			fields["submodule/"+path] = commit
+		}
+		for _, hook := range repo.Hooks {
+			fields["hook/"+hook] = "installed"
+		}
+		if repo.LFS != nil {
+			fields["lfs/installed"] = repo.LFS.Installed
+			fields["lfs/files"] = repo.LFS.Files
+			fields["lfs/pointers"] = repo.LFS.Pointers
+		}
+		for key, value := range repo.Config {
+			fields["config/"+key] = value
+		}
+		return fields
+	})
+}
+
+// submoduleNotInitialized is the repo diff value of a submodule that has
+// nothing checked out
+const submoduleNotInitialized = "not initialized"
+
+// compareFilesystemFields compares the filesystem behind each role
+// ("tmp/fs_type", "tmp/options", ...). Free space and inodes differ between
+// any two machines, so they are left to check rules rather than compared.
+func compareFilesystemFields(result *Diff, snapshots map[string]*snapshot.Snapshot, opts Options) {
+	compareMapFields(result, snapshots, opts, "filesystem", func(snap *snapshot.Snapshot) map[string]any {
+		fields := make(map[string]any)
+		for role, mount := range snap.Filesystems {
+			fields[role+"/mount_point"] = mount.MountPoint
+			fields[role+"/fs_type"] = mount.FSType
+			fields[role+"/options"] = strings.Join(mount.Options, ",")
+			if mount.CaseSensitive != nil {
+				fields[role+"/case_sensitive"] = *mount.CaseSensitive
+			}
+		}
+		return fields
+	})
+}
+
+// compareLimitsFields compares the effective resource limits: the lower of
+// the cgroup limit and the host's resources for cpus and memory, the cgroup
+// pids limit and the soft rlimits. Limits a snapshot did not record are left
+// out rather than compared as zero.
+func compareLimitsFields(result *Diff, snapshots map[string]*snapshot.Snapshot, opts Options) {
+	compareMapFields(result, snapshots, opts, "limits", func(snap *snapshot.Snapshot) map[string]any {
+		limits := snap.Limits
+		if limits == nil {
+			return nil
+		}
+		fields := make(map[string]any)
+		if limits.CPUs != 0 {
+			fields["cpus"] = strconv.FormatFloat(limits.CPUs, 'f', -1, 64)
+		}
+		for field, bytes := range map[string]int64{"memory": limits.MemoryBytes, "stack": limits.StackBytes} {
+			if bytes != 0 {
+				fields[field] = config.FormatSizeLimit(bytes)
+			}
+		}
+		for field, n := range map[string]int64{"pids": limits.Pids, "open_files": limits.OpenFiles, "processes": limits.Processes} {
+			if n != 0 {
+				fields[field] = config.FormatLimit(n)
+			}
+		}
+		return fields
+	})
+}
+
+// compareKernelFields compares the kernel parameters recorded from
+// /proc/sys, one field per parameter ("vm.max_map_count")
+func compareKernelFields(result *Diff, snapshots map[string]*snapshot.Snapshot, opts Options) {
+	compareMapFields(result, snapshots, opts, "kernel", func(snap *snapshot.Snapshot) map[string]any {
+		fields := make(map[string]any, len(snap.Sysctl))
+		for key, value := range snap.Sysctl {
+			fields[key] = value
+		}
+		return fields
+	})
+}
+
+// compareNetworkFields produces one field per host name ("host/<name>") and
+// one per port ("port/<n>"). Ports are compared as a set: each port is either
+// listening on a node or missing, regardless of the order it was collected in.
+func compareNetworkFields(result *Diff, snapshots map[string]*snapshot.Snapshot, opts Options) {
+	allHosts := make(map[string]bool)
+	allPorts := make(map[int]bool)
+	listening := make(map[string]map[int]bool)
+	for name, snap := range snapshots {
+		listening[name] = make(map[int]bool)
+		if snap.Network == nil {
+			continue
+		}
+		for host := range snap.Network.Hosts {
+			allHosts[host] = true
+		}
+		for _, port := range snap.Network.ListeningPorts {
+			allPorts[port] = true
+			listening[name][port] = true
+		}
+	}
+
+	for host := range allHosts {
+		field := "host/" + host
+		if opts.ignored("network", field) {
+			result.Summary.Ignored++
+			continue
+		}
+		values := make(map[string]any)
+		for name, snap := range snapshots {
+			if ip, ok := networkHosts(snap)[host]; ok {
+				values[name] = ip
+			} else {
+				values[name] = nil
+			}
+		}
+		result.Diffs["network"][field] = createFieldDiff(values, result.Nodes)
+		updateSummary(result, result.Diffs["network"][field])
+	}
+
+	for port := range allPorts {
+		field := "port/" + strconv.Itoa(port)
+		if opts.ignored("network", field) {
+			result.Summary.Ignored++
+			continue
+		}
+		values := make(map[string]any)
+		for name := range snapshots {
+			if listening[name][port] {
+				values[name] = "listening"
+			} else {
+				values[name] = nil
+			}
+		}
+		result.Diffs["network"][field] = createFieldDiff(values, result.Nodes)
+		updateSummary(result, result.Diffs["network"][field])
+	}
+}
+
+func networkHosts(snap *snapshot.Snapshot) map[string]string {
+	if snap.Network == nil {
+		return nil
+	}
+	return snap.Network.Hosts
+}
+
+func compareSystemFields(result *Diff, snapshots map[string]*snapshot.Snapshot, opts Options) {
 	fields := []struct {
 		name   string
 		getter func(*snapshot.Snapshot) any
@@ -83,7 +454,22 @@ func compareSystemFields(result *Diff, snapshots map[string]*snapshot.Snapshot)
 		{"memory_gb", func(snap *snapshot.Snapshot) any { return snap.System.MemoryGB }},
 	}
 
+	// With limits recorded everywhere, the limits section compares the
+	// effective CPUs and memory, and a 16-core host running a 2-CPU
+	// container is not a host difference
+	effective := true
+	for _, snap := range snapshots {
+		effective = effective && snap.Limits != nil
+	}
+
 	for _, f := range fields {
+		if effective && (f.name == "cpu_cores" || f.name == "memory_gb") {
+			continue
+		}
+		if opts.ignored("system", f.name) {
+			result.Summary.Ignored++
+			continue
+		}
 		values := make(map[string]any)
 		for name, snap := range snapshots {
 			values[name] = f.getter(snap)
@@ -93,7 +479,7 @@ func compareSystemFields(result *Diff, snapshots map[string]*snapshot.Snapshot)
 	}
 }
 
-func compareRuntimeFields(result *Diff, snapshots map[string]*snapshot.Snapshot) {
+func compareRuntimeFields(result *Diff, snapshots map[string]*snapshot.Snapshot, opts Options) {
 	// Gather all runtime keys across all snapshots
 	allRuntimes := make(map[string]bool)
 	for _, snap := range snapshots {
@@ -103,6 +489,10 @@ func compareRuntimeFields(result *Diff, snapshots map[string]*snapshot.Snapshot)
 	}
 
 	for rt := range allRuntimes {
+		if opts.ignored("runtime", rt) {
+			result.Summary.Ignored++
+			continue
+		}
 		values := make(map[string]any)
 		for name, snap := range snapshots {
 			if info, ok := snap.Runtime[rt]; ok && info != nil {
@@ -111,12 +501,55 @@ func compareRuntimeFields(result *Diff, snapshots map[string]*snapshot.Snapshot)
 				values[name] = nil
 			}
 		}
-		result.Diffs["runtime"][rt] = createFieldDiff(values, result.Nodes)
-		updateSummary(result, result.Diffs["runtime"][rt])
+		fieldDiff := createFieldDiff(values, result.Nodes)
+		classifyVersions(fieldDiff)
+		result.Diffs["runtime"][rt] = fieldDiff
+		updateSummary(result, fieldDiff)
+
+		if fieldDiff.Status == StatusEqual {
+			compareWinningInstall(result, snapshots, rt)
+		}
+	}
+}
+
+// compareWinningInstall adds "<runtime>/install" when every node runs the
+// same version from a different binary, e.g. a pyenv shim on one machine
+// and /usr/bin/python3 on another. It is only reported when it differs.
+func compareWinningInstall(result *Diff, snapshots map[string]*snapshot.Snapshot, rt string) {
+	values := make(map[string]any)
+	for name, snap := range snapshots {
+		values[name] = winningInstall(snap, snap.Runtime[rt])
+	}
+	fieldDiff := createFieldDiff(values, result.Nodes)
+	if fieldDiff.Status != StatusDifferent {
+		return
+	}
+	result.Diffs["runtime"][rt+"/install"] = fieldDiff
+	updateSummary(result, fieldDiff)
+}
+
+// winningInstall returns the resolved binary a runtime runs from, with the
+// home directory shortened to ~ so per-user paths compare equal across hosts
+func winningInstall(snap *snapshot.Snapshot, info *snapshot.RuntimeInfo) string {
+	path := info.Path
+	if len(info.Installations) > 0 {
+		path = info.Installations[0].Path
+		if target := info.Installations[0].Target; target != "" {
+			path = target
+		}
 	}
+	if home := snap.Env["HOME"]; home != "" && home != "/" && !secrets.IsRedacted(home) {
+		if path == home {
+			return "~"
+		}
+		if rest, ok := strings.CutPrefix(path, home+"/"); ok {
+			return "~/" + rest
+		}
+	}
+	return path
 }
 
-func compareEnvFields(result *Diff, snapshots map[string]*snapshot.Snapshot) {
+func compareEnvFields(result *Diff, snapshots map[string]*snapshot.Snapshot, opts Options) {
 	// Gather all env keys across all snapshots
 	allEnvs := make(map[string]bool)
 	for _, snap := range snapshots {
@@ -125,28 +558,30 @@ func compareEnvFields(result *Diff, snapshots map[string]*snapshot.Snapshot) {
 		}
 	}
 
+	separators := opts.ListSeparators
+	if separators == nil {
+		separators = config.DefaultEnvLists
+	}
+
 	for envKey := range allEnvs {
+		if opts.ignored("env", envKey) {
+			result.Summary.Ignored++
+			continue
+		}
 		values := make(map[string]any)
-		anyRedacted := false
-
 		for name, snap := range snapshots {
 			if val, ok := snap.Env[envKey]; ok {
 				values[name] = val
-				if secrets.IsRedacted(val) {
-					anyRedacted = true
-				}
 			} else {
 				values[name] = nil
 			}
 		}
 
 		fieldDiff := createFieldDiff(values, result.Nodes)
+		markRedacted(fieldDiff)
 
-		// If any value is redacted, mark the whole field as redacted
-		if anyRedacted {
-			fieldDiff.Status = StatusRedacted
-			fieldDiff.Majority = nil
-			fieldDiff.Outliers = nil
+		if sep, ok := separators[envKey]; ok && fieldDiff.Status == StatusDifferent {
+			fieldDiff.List = compareList(envKey, sep, fieldDiff, snapshots, result.Nodes)
 		}
 
 		result.Diffs["env"][envKey] = fieldDiff
@@ -154,6 +589,89 @@ func compareEnvFields(result *Diff, snapshots map[string]*snapshot.Snapshot) {
 	}
 }
 
+// markRedacted updates the status of a field that holds redacted values
+func markRedacted(fieldDiff *FieldDiff) {
+	anyRedacted := false
+	keyIDs := make(map[string]bool)
+	allFingerprints := true
+	for _, value := range fieldDiff.NodeValues {
+		val, ok := value.(string)
+		if !ok {
+			continue
+		}
+		if secrets.IsRedacted(val) {
+			anyRedacted = true
+		}
+		if keyID, _, ok := secrets.ParseFingerprint(val); ok {
+			keyIDs[keyID] = true
+		} else {
+			allFingerprints = false
+		}
+	}
+
+	switch {
+	case anyRedacted && allFingerprints && len(keyIDs) == 1:
+		// Fingerprints from one salt compare like the values they hide
+		if fieldDiff.Status == StatusEqual {
+			fieldDiff.Status = StatusRedactedEqual
+		} else {
+			fieldDiff.Status = StatusRedactedDifferent
+		}
+	case anyRedacted:
+		// Otherwise mark the whole field as redacted
+		fieldDiff.Status = StatusRedacted
+		fieldDiff.Majority = nil
+		fieldDiff.Outliers = nil
+	}
+}
+
+// compareToolchainFields compares go env, npm, pip and git settings in one
+// toolchain section, with fields named "<tool>/<setting>" such as
+// "go/GOFLAGS". A tool missing from a snapshot is left out for that node
+// rather than reported as every setting unset.
+func compareToolchainFields(result *Diff, snapshots map[string]*snapshot.Snapshot, opts Options) {
+	fields := make(map[string]bool)
+	for _, snap := range snapshots {
+		for tool, cfg := range snap.Toolchain {
+			for key := range cfg.Settings {
+				fields[tool+"/"+key] = true
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+	result.Diffs["toolchain"] = make(map[string]*FieldDiff)
+
+	for field := range fields {
+		if opts.ignored("toolchain", field) {
+			result.Summary.Ignored++
+			continue
+		}
+		tool, key, _ := strings.Cut(field, "/")
+		values := make(map[string]any)
+		for name, snap := range snapshots {
+			cfg := snap.Toolchain[tool]
+			if cfg == nil {
+				continue
+			}
+			if val, ok := cfg.Settings[key]; ok {
+				values[name] = val
+			} else {
+				values[name] = nil
+			}
+		}
+		if len(values) < 2 {
+			continue // the tool is only installed on one node
+		}
+
+		fieldDiff := createFieldDiff(values, result.Nodes)
+		markRedacted(fieldDiff)
+		result.Diffs["toolchain"][field] = fieldDiff
+		updateSummary(result, fieldDiff)
+	}
+}
+
 func createFieldDiff(values map[string]any, nodes []string) *FieldDiff {
 	fieldDiff := &FieldDiff{
 		NodeValues: values,
@@ -229,9 +747,9 @@ func calculateMajority(values map[string]any, nodes []string) (any, []string) {
 func updateSummary(result *Diff, fieldDiff *FieldDiff) {
 	result.Summary.TotalFields++
 	switch fieldDiff.Status {
-	case StatusEqual:
+	case StatusEqual, StatusRedactedEqual:
 		result.Summary.Equal++
-	case StatusDifferent:
+	case StatusDifferent, StatusRedactedDifferent:
 		result.Summary.Different++
 	case StatusRedacted:
 		result.Summary.Redacted++
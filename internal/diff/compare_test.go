@@ -1,8 +1,10 @@
 package diff
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/GBerghoff/envdiff/internal/secrets"
 	"github.com/GBerghoff/envdiff/internal/snapshot"
 )
 
@@ -38,7 +40,7 @@ func TestCompare_TwoIdenticalSnapshots(t *testing.T) {
 		"ci":    snap2,
 	}
 
-	result := Compare(snapshots)
+	result := Compare(snapshots, Options{})
 
 	if result.Summary.Different != 0 {
 		t.Errorf("Different = %d, want 0 for identical snapshots", result.Summary.Different)
@@ -80,7 +82,7 @@ func TestCompare_TwoDifferentSnapshots(t *testing.T) {
 		"ci":    snap2,
 	}
 
-	result := Compare(snapshots)
+	result := Compare(snapshots, Options{})
 
 	if result.Summary.Different == 0 {
 		t.Error("Different should be > 0 for different snapshots")
@@ -116,7 +118,7 @@ func TestCompare_MissingRuntime(t *testing.T) {
 		"ci":    snap2,
 	}
 
-	result := Compare(snapshots)
+	result := Compare(snapshots, Options{})
 
 	goDiff := result.Diffs["runtime"]["go"]
 	if goDiff.Status != StatusDifferent {
@@ -149,7 +151,7 @@ func TestCompare_RedactedEnvVars(t *testing.T) {
 		"ci":    snap2,
 	}
 
-	result := Compare(snapshots)
+	result := Compare(snapshots, Options{})
 
 	apiKeyDiff := result.Diffs["env"]["API_KEY"]
 	if apiKeyDiff.Status != StatusRedacted {
@@ -160,6 +162,43 @@ func TestCompare_RedactedEnvVars(t *testing.T) {
 	}
 }
 
+func TestCompare_FingerprintedEnvVars(t *testing.T) {
+	team, _ := secrets.NewFingerprinter([]byte("team-shared-salt-0123"))
+	other, _ := secrets.NewFingerprinter([]byte("another-teams-salt-456"))
+
+	snapshots := map[string]*snapshot.Snapshot{
+		"local": {Env: map[string]string{
+			"DATABASE_URL": team.Fingerprint("DATABASE_URL", "postgres://localhost/app"),
+			"API_KEY":      team.Fingerprint("API_KEY", "k1"),
+			"JWT_SECRET":   team.Fingerprint("JWT_SECRET", "s1"),
+		}},
+		"ci": {Env: map[string]string{
+			"DATABASE_URL": team.Fingerprint("DATABASE_URL", "postgres://db/app"),
+			"API_KEY":      team.Fingerprint("API_KEY", "k1"),
+			"JWT_SECRET":   other.Fingerprint("JWT_SECRET", "s1"),
+		}},
+	}
+
+	result := Compare(snapshots, Options{})
+
+	tests := map[string]FieldStatus{
+		"DATABASE_URL": StatusRedactedDifferent,
+		"API_KEY":      StatusRedactedEqual,
+		"JWT_SECRET":   StatusRedacted, // different salts cannot be compared
+	}
+	for name, want := range tests {
+		if got := result.Diffs["env"][name].Status; got != want {
+			t.Errorf("%s Status = %q, want %q", name, got, want)
+		}
+	}
+	if result.Summary.Different != 1 || result.Summary.Redacted != 1 {
+		t.Errorf("Summary = %+v, want 1 different and 1 redacted", result.Summary)
+	}
+	if sev := result.Diffs["env"]["DATABASE_URL"].Severity; sev != SeverityMedium {
+		t.Errorf("DATABASE_URL Severity = %q, want %q", sev, SeverityMedium)
+	}
+}
+
 func TestCalculateMajority_ClearMajority(t *testing.T) {
 	values := map[string]any{
 		"node1": "1.22.0",
@@ -211,3 +250,539 @@ func TestCalculateMajority_TiedValues(t *testing.T) {
 		t.Errorf("majority = %v, want nil for tied values", majority)
 	}
 }
+
+func TestCompare_IgnorePatterns(t *testing.T) {
+	snap1 := &snapshot.Snapshot{
+		System: snapshot.SystemInfo{OS: "linux", Kernel: "6.1.0"},
+		Runtime: map[string]*snapshot.RuntimeInfo{
+			"go": {Version: "1.22.0", Path: "/usr/bin/go"},
+		},
+		Env: map[string]string{
+			"PWD":             "/home/dev/app",
+			"SHLVL":           "1",
+			"TERM_SESSION_ID": "abc",
+			"NODE_ENV":        "development",
+		},
+	}
+
+	snap2 := &snapshot.Snapshot{
+		System: snapshot.SystemInfo{OS: "linux", Kernel: "5.15.0"},
+		Runtime: map[string]*snapshot.RuntimeInfo{
+			"go": {Version: "1.21.0", Path: "/usr/bin/go"},
+		},
+		Env: map[string]string{
+			"PWD":      "/builds/app",
+			"SHLVL":    "2",
+			"NODE_ENV": "production",
+		},
+	}
+
+	snapshots := map[string]*snapshot.Snapshot{
+		"local": snap1,
+		"ci":    snap2,
+	}
+
+	result := Compare(snapshots, Options{
+		Ignore: []string{"PWD", "SHLVL", "*_SESSION*", "system.kernel"},
+	})
+
+	for _, name := range []string{"PWD", "SHLVL", "TERM_SESSION_ID"} {
+		if _, ok := result.Diffs["env"][name]; ok {
+			t.Errorf("env %s should be ignored", name)
+		}
+	}
+	if _, ok := result.Diffs["system"]["kernel"]; ok {
+		t.Error("system.kernel should be ignored")
+	}
+	if _, ok := result.Diffs["env"]["NODE_ENV"]; !ok {
+		t.Error("NODE_ENV should not be ignored")
+	}
+	if result.Summary.Ignored != 4 {
+		t.Errorf("Summary.Ignored = %d, want 4", result.Summary.Ignored)
+	}
+}
+
+func TestCompare_IgnoreSectionWildcard(t *testing.T) {
+	snap1 := &snapshot.Snapshot{
+		Runtime: map[string]*snapshot.RuntimeInfo{},
+		Env:     map[string]string{"NODE_ENV": "development"},
+		Network: &snapshot.NetworkInfo{
+			Hosts:          map[string]string{"db.local": "127.0.0.1"},
+			ListeningPorts: []int{5432},
+		},
+	}
+	snap2 := &snapshot.Snapshot{
+		Runtime: map[string]*snapshot.RuntimeInfo{},
+		Env:     map[string]string{"NODE_ENV": "production"},
+		Network: &snapshot.NetworkInfo{},
+	}
+
+	result := Compare(map[string]*snapshot.Snapshot{"local": snap1, "ci": snap2}, Options{
+		Ignore: []string{"network.*"},
+	})
+
+	if len(result.Diffs["network"]) != 0 {
+		t.Errorf("network fields should be ignored, got %v", result.Diffs["network"])
+	}
+	if result.Summary.Ignored != 2 {
+		t.Errorf("Summary.Ignored = %d, want 2", result.Summary.Ignored)
+	}
+	if _, ok := result.Diffs["env"]["NODE_ENV"]; !ok {
+		t.Error("NODE_ENV should not be ignored")
+	}
+}
+
+func TestCompare_NetworkFields(t *testing.T) {
+	snap1 := &snapshot.Snapshot{
+		Runtime: map[string]*snapshot.RuntimeInfo{},
+		Env:     map[string]string{},
+		Network: &snapshot.NetworkInfo{
+			Hosts:          map[string]string{"localhost": "127.0.0.1", "db.local": "127.0.0.1"},
+			ListeningPorts: []int{22, 5432, 8080},
+		},
+	}
+
+	snap2 := &snapshot.Snapshot{
+		Runtime: map[string]*snapshot.RuntimeInfo{},
+		Env:     map[string]string{},
+		Network: &snapshot.NetworkInfo{
+			Hosts:          map[string]string{"localhost": "127.0.0.1"},
+			ListeningPorts: []int{8080, 22},
+		},
+	}
+
+	snapshots := map[string]*snapshot.Snapshot{
+		"local": snap1,
+		"ci":    snap2,
+	}
+
+	result := Compare(snapshots, Options{})
+	network := result.Diffs["network"]
+
+	if network["host/localhost"].Status != StatusEqual {
+		t.Error("host/localhost should be equal")
+	}
+	if network["host/db.local"].Status != StatusDifferent {
+		t.Error("host/db.local should be different")
+	}
+	if network["host/db.local"].NodeValues["ci"] != nil {
+		t.Error("ci should have nil value for missing hosts entry")
+	}
+	if network["port/8080"].Status != StatusEqual || network["port/22"].Status != StatusEqual {
+		t.Error("ports listening on both nodes should be equal regardless of order")
+	}
+	if network["port/5432"].Status != StatusDifferent {
+		t.Error("port/5432 should be different")
+	}
+}
+
+func TestCompare_CollectionErrors(t *testing.T) {
+	snap1 := &snapshot.Snapshot{
+		Runtime: map[string]*snapshot.RuntimeInfo{},
+		Env:     map[string]string{},
+	}
+
+	snap2 := &snapshot.Snapshot{
+		Runtime: map[string]*snapshot.RuntimeInfo{},
+		Env:     map[string]string{},
+		CollectionErrors: []snapshot.CollectionError{
+			{Collector: "runtime", Probe: "gcloud", ExitCode: 1, Message: "exit status 1"},
+		},
+	}
+
+	snapshots := map[string]*snapshot.Snapshot{
+		"local": snap1,
+		"ci":    snap2,
+	}
+
+	result := Compare(snapshots, Options{})
+
+	if result.Summary.FailedNodes != 1 {
+		t.Errorf("Summary.FailedNodes = %d, want 1", result.Summary.FailedNodes)
+	}
+	if result.Summary.SuccessfulNodes != 1 {
+		t.Errorf("Summary.SuccessfulNodes = %d, want 1", result.Summary.SuccessfulNodes)
+	}
+	if _, ok := result.Errors["local"]; ok {
+		t.Error("local should have no errors")
+	}
+	if !strings.Contains(result.Errors["ci"], "runtime/gcloud") {
+		t.Errorf("Errors[ci] = %q, should name the failed probe", result.Errors["ci"])
+	}
+}
+
+func TestCompare_Inventories(t *testing.T) {
+	snap1 := &snapshot.Snapshot{
+		Runtime: map[string]*snapshot.RuntimeInfo{},
+		Env:     map[string]string{},
+		Inventories: map[string]snapshot.Inventory{
+			snapshot.InventoryPip: {"requests": "2.31.0", "numpy": "1.26.4"},
+			snapshot.InventoryNpm: {"typescript": "5.3.3"},
+		},
+	}
+
+	snap2 := &snapshot.Snapshot{
+		Runtime: map[string]*snapshot.RuntimeInfo{},
+		Env:     map[string]string{},
+		Inventories: map[string]snapshot.Inventory{
+			snapshot.InventoryPip: {"requests": "2.31.0", "numpy": "2.0.0"},
+		},
+	}
+
+	snapshots := map[string]*snapshot.Snapshot{
+		"local": snap1,
+		"ci":    snap2,
+	}
+
+	result := Compare(snapshots, Options{Ignore: []string{"npm.typescript"}})
+	pip := result.Diffs[snapshot.InventoryPip]
+
+	if pip["requests"].Status != StatusEqual {
+		t.Error("pip.requests should be equal")
+	}
+	if pip["numpy"].Status != StatusDifferent {
+		t.Fatal("pip.numpy should be different")
+	}
+	if pip["numpy"].Delta == nil || pip["numpy"].Delta.Kind != DeltaMajor {
+		t.Errorf("pip.numpy delta = %+v, want major", pip["numpy"].Delta)
+	}
+	if len(result.Diffs[snapshot.InventoryNpm]) != 0 {
+		t.Error("ignored npm packages should be left out")
+	}
+	if _, ok := result.Diffs[snapshot.InventoryGem]; ok {
+		t.Error("no section expected for an ecosystem neither snapshot has")
+	}
+}
+
+func TestCompare_ProjectLockfiles(t *testing.T) {
+	snap1 := &snapshot.Snapshot{
+		Runtime: map[string]*snapshot.RuntimeInfo{},
+		Env:     map[string]string{},
+		Project: &snapshot.ProjectInfo{Lockfiles: map[string]*snapshot.Lockfile{
+			"package-lock.json": {SHA256: "aaaaaaaaaaaaaaaa", Dependencies: map[string]string{"lodash": "4.17.20", "react": "18.2.0"}},
+		}},
+	}
+
+	snap2 := &snapshot.Snapshot{
+		Runtime: map[string]*snapshot.RuntimeInfo{},
+		Env:     map[string]string{},
+		Project: &snapshot.ProjectInfo{Lockfiles: map[string]*snapshot.Lockfile{
+			"package-lock.json": {SHA256: "bbbbbbbbbbbbbbbb", Dependencies: map[string]string{"lodash": "4.17.21", "react": "18.2.0"}},
+		}},
+	}
+
+	snapshots := map[string]*snapshot.Snapshot{
+		"local": snap1,
+		"ci":    snap2,
+	}
+
+	result := Compare(snapshots, Options{})
+	project := result.Diffs["project"]
+
+	if project["package-lock.json"].Status != StatusDifferent {
+		t.Error("lockfile hashes should differ")
+	}
+	lodash := project["package-lock.json:lodash"]
+	if lodash == nil || lodash.Status != StatusDifferent {
+		t.Fatal("package-lock.json:lodash should be different")
+	}
+	if lodash.NodeValues["local"] != "4.17.20" || lodash.NodeValues["ci"] != "4.17.21" {
+		t.Errorf("unexpected values %v", lodash.NodeValues)
+	}
+	if lodash.Delta == nil || lodash.Delta.Kind != DeltaPatch {
+		t.Errorf("lodash delta = %+v, want patch", lodash.Delta)
+	}
+	if !strings.Contains(lodash.Reason, "package-lock.json") {
+		t.Errorf("reason %q should name the lockfile", lodash.Reason)
+	}
+	if project["package-lock.json:react"].Status != StatusEqual {
+		t.Error("react should be equal")
+	}
+}
+
+func TestCompare_WinningInstall(t *testing.T) {
+	pyenv := &snapshot.Snapshot{
+		Runtime: map[string]*snapshot.RuntimeInfo{
+			"python": {Version: "3.11.7", Path: "/home/me/.pyenv/shims/python3", Installations: []snapshot.Installation{
+				{Path: "/home/me/.pyenv/shims/python3", Version: "3.11.7", PathIndex: 0},
+				{Path: "/usr/bin/python3", Target: "/usr/bin/python3.11", Version: "3.11.2", PathIndex: 3},
+			}},
+			"node": {Version: "20.11.0", Path: "/home/me/.nvm/versions/node/v20.11.0/bin/node"},
+		},
+		Env: map[string]string{"HOME": "/home/me"},
+	}
+	system := &snapshot.Snapshot{
+		Runtime: map[string]*snapshot.RuntimeInfo{
+			"python": {Version: "3.11.7", Path: "/usr/local/bin/python3", Installations: []snapshot.Installation{
+				{Path: "/usr/local/bin/python3", Target: "/usr/local/bin/python3.11", Version: "3.11.7", PathIndex: 1},
+			}},
+			// Same install under a different home directory
+			"node": {Version: "20.11.0", Path: "/Users/ci/.nvm/versions/node/v20.11.0/bin/node"},
+		},
+		Env: map[string]string{"HOME": "/Users/ci"},
+	}
+
+	result := Compare(map[string]*snapshot.Snapshot{"dev": pyenv, "ci": system}, Options{})
+	runtime := result.Diffs["runtime"]
+
+	if runtime["python"].Status != StatusEqual {
+		t.Error("python versions should be equal")
+	}
+	install := runtime["python/install"]
+	if install == nil || install.Status != StatusDifferent {
+		t.Fatalf("python/install should be different, got %+v", install)
+	}
+	if install.NodeValues["dev"] != "~/.pyenv/shims/python3" || install.NodeValues["ci"] != "/usr/local/bin/python3.11" {
+		t.Errorf("unexpected install values %v", install.NodeValues)
+	}
+	if _, ok := runtime["node/install"]; ok {
+		t.Error("node installs only differ by home directory and should not be reported")
+	}
+	if severity, reason := scoreField("runtime", "python/install", install, result.Nodes); severity != SeverityMedium || reason != "same version, different winning install" {
+		t.Errorf("scoreField() = %v, %q", severity, reason)
+	}
+}
+
+func TestCompare_Toolchain(t *testing.T) {
+	dev := &snapshot.Snapshot{Toolchain: map[string]*snapshot.ToolConfig{
+		snapshot.ToolchainGo:  {Settings: map[string]string{"GOFLAGS": "-mod=mod", "GOPATH": "/home/me/go", "GOOS": "linux"}},
+		snapshot.ToolchainNpm: {Settings: map[string]string{"registry": "https://registry.npmjs.org/"}},
+	}}
+	ci := &snapshot.Snapshot{Toolchain: map[string]*snapshot.ToolConfig{
+		snapshot.ToolchainGo: {Settings: map[string]string{"GOFLAGS": "", "GOPATH": "/root/go", "GOOS": "linux"}},
+	}}
+
+	result := Compare(map[string]*snapshot.Snapshot{"dev": dev, "ci": ci}, Options{})
+	toolchain := result.Diffs["toolchain"]
+	if toolchain == nil {
+		t.Fatal("expected a toolchain section")
+	}
+	if _, ok := result.Diffs["env"]["go/GOFLAGS"]; ok {
+		t.Error("toolchain settings should not appear in the env section")
+	}
+
+	flags := toolchain["go/GOFLAGS"]
+	if flags == nil || flags.Status != StatusDifferent || flags.Severity != SeverityHigh {
+		t.Fatalf("go/GOFLAGS should be a high severity difference, got %+v", flags)
+	}
+	if gopath := toolchain["go/GOPATH"]; gopath == nil || gopath.Severity != SeverityLow {
+		t.Errorf("go/GOPATH should be low severity noise, got %+v", gopath)
+	}
+	if !toolchain["go/GOOS"].Status.IsEqual() {
+		t.Error("go/GOOS should be equal")
+	}
+	if _, ok := toolchain["npm/registry"]; ok {
+		t.Error("npm is only installed on dev and should not be compared")
+	}
+}
+
+func TestCompare_Repo(t *testing.T) {
+	local := &snapshot.Snapshot{Repo: &snapshot.RepoInfo{
+		Head: "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b", Branch: "main", Dirty: 2,
+		DirtyHash:  "9f8e7d6c5b4a39281706f5e4d3c2b1a0987654329f8e7d6c5b4a39281706f5e4",
+		Submodules: map[string]string{"vendor/lib": "0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c"},
+		Hooks:      []string{"pre-commit"},
+		Config:     map[string]string{"core.autocrlf": "true"},
+	}}
+	ci := &snapshot.Snapshot{Repo: &snapshot.RepoInfo{
+		Head:       "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b",
+		Submodules: map[string]string{"vendor/lib": ""},
+		Config:     map[string]string{"core.autocrlf": "input"},
+	}}
+
+	result := Compare(map[string]*snapshot.Snapshot{"local": local, "ci": ci}, Options{})
+	repo := result.Diffs["repo"]
+	if repo == nil {
+		t.Fatal("expected a repo section")
+	}
+	if !repo["head"].Status.IsEqual() {
+		t.Error("head should be equal")
+	}
+
+	tests := []struct {
+		field    string
+		severity Severity
+		ciValue  any
+	}{
+		{"dirty_hash", SeverityHigh, "clean"},
+		{"submodule/vendor/lib", SeverityHigh, "not initialized"},
+		{"config/core.autocrlf", SeverityHigh, "input"},
+		{"hook/pre-commit", SeverityMedium, nil},
+		{"branch", SeverityLow, ""},
+	}
+	for _, tt := range tests {
+		field := repo[tt.field]
+		if field == nil || field.Status != StatusDifferent {
+			t.Errorf("%s should be different, got %+v", tt.field, field)
+			continue
+		}
+		if field.Severity != tt.severity {
+			t.Errorf("%s Severity = %q (%s), want %q", tt.field, field.Severity, field.Reason, tt.severity)
+		}
+		if field.NodeValues["ci"] != tt.ciValue {
+			t.Errorf("%s ci value = %v, want %v", tt.field, field.NodeValues["ci"], tt.ciValue)
+		}
+	}
+	if reason := repo["submodule/vendor/lib"].Reason; reason != "submodule not initialized on ci" {
+		t.Errorf("submodule Reason = %q", reason)
+	}
+}
+
+func TestCompare_Filesystems(t *testing.T) {
+	sensitive, insensitive := true, false
+	laptop := &snapshot.Snapshot{Filesystems: map[string]*snapshot.Mount{
+		snapshot.FilesystemProject: {MountPoint: "/System/Volumes/Data", FSType: "apfs", Options: []string{"journaled", "local", "rw"}, FreeBytes: 100 << 30, CaseSensitive: &insensitive},
+		snapshot.FilesystemTmp:     {MountPoint: "/System/Volumes/Data", FSType: "apfs", Options: []string{"rw"}, FreeBytes: 100 << 30},
+	}}
+	ci := &snapshot.Snapshot{Filesystems: map[string]*snapshot.Mount{
+		snapshot.FilesystemProject: {MountPoint: "/", FSType: "ext4", Options: []string{"journaled", "local", "rw"}, FreeBytes: 10 << 30, CaseSensitive: &sensitive},
+		snapshot.FilesystemTmp:     {MountPoint: "/System/Volumes/Data", FSType: "apfs", Options: []string{"noexec", "rw"}, FreeBytes: 100 << 30},
+	}}
+
+	result := Compare(map[string]*snapshot.Snapshot{"laptop": laptop, "ci": ci}, Options{})
+	filesystem := result.Diffs["filesystem"]
+
+	tests := []struct {
+		field    string
+		severity Severity
+		reason   string
+	}{
+		{"project/case_sensitive", SeverityHigh, "case sensitivity differs"},
+		{"project/fs_type", SeverityMedium, "filesystem type differs"},
+		{"tmp/options", SeverityHigh, "noexec differs"},
+	}
+	for _, tt := range tests {
+		field := filesystem[tt.field]
+		if field == nil || field.Status != StatusDifferent {
+			t.Errorf("%s should be different, got %+v", tt.field, field)
+			continue
+		}
+		if field.Severity != tt.severity || field.Reason != tt.reason {
+			t.Errorf("%s = %s %q, want %s %q", tt.field, field.Severity, field.Reason, tt.severity, tt.reason)
+		}
+	}
+	if !filesystem["project/options"].Status.IsEqual() {
+		t.Error("project/options should be equal")
+	}
+	if _, ok := filesystem["project/free"]; ok {
+		t.Error("free space should not be compared")
+	}
+}
+
+func TestCompare_Limits(t *testing.T) {
+	laptop := &snapshot.Snapshot{Limits: &snapshot.LimitsInfo{
+		CPUs: 16, CPUSource: snapshot.LimitHost, MemoryBytes: 64 << 30, MemorySource: snapshot.LimitHost,
+		Pids: snapshot.Unlimited, OpenFiles: 65536, Processes: 4096, StackBytes: 8 << 20,
+	}}
+	ci := &snapshot.Snapshot{Limits: &snapshot.LimitsInfo{
+		CPUs: 2, CPUSource: snapshot.LimitCgroup, MemoryBytes: 4 << 30, MemorySource: snapshot.LimitCgroup,
+		Pids: snapshot.Unlimited, OpenFiles: 1024, StackBytes: 8 << 20,
+	}}
+
+	result := Compare(map[string]*snapshot.Snapshot{"laptop": laptop, "ci": ci}, Options{})
+	limits := result.Diffs["limits"]
+
+	tests := []struct {
+		field    string
+		severity Severity
+		reason   string
+	}{
+		{"memory", SeverityHigh, "effective memory limit differs"},
+		{"cpus", SeverityMedium, "effective CPU limit differs"},
+		{"open_files", SeverityMedium, "open file limit differs"},
+		{"processes", SeverityLow, "not recorded on ci"},
+	}
+	for _, tt := range tests {
+		field := limits[tt.field]
+		if field == nil || field.Status.IsEqual() {
+			t.Errorf("%s should differ, got %+v", tt.field, field)
+			continue
+		}
+		if field.Severity != tt.severity || field.Reason != tt.reason {
+			t.Errorf("%s = %s %q, want %s %q", tt.field, field.Severity, field.Reason, tt.severity, tt.reason)
+		}
+	}
+	if got := limits["memory"].NodeValues["ci"]; got != "4.0GB" {
+		t.Errorf("ci memory = %v, want 4.0GB", got)
+	}
+	for _, field := range []string{"pids", "stack"} {
+		if !limits[field].Status.IsEqual() {
+			t.Errorf("%s should be equal", field)
+		}
+	}
+	for _, field := range []string{"cpu_cores", "memory_gb"} {
+		if _, ok := result.Diffs["system"][field]; ok {
+			t.Errorf("system.%s should be left to the limits section", field)
+		}
+	}
+
+	// Older snapshots without limits still compare the host's resources
+	laptop.Limits = nil
+	result = Compare(map[string]*snapshot.Snapshot{"laptop": laptop, "ci": ci}, Options{})
+	if _, ok := result.Diffs["system"]["cpu_cores"]; !ok {
+		t.Error("system.cpu_cores should be compared when a snapshot has no limits")
+	}
+}
+
+func TestCompare_Kernel(t *testing.T) {
+	laptop := &snapshot.Snapshot{Sysctl: map[string]string{
+		"fs.inotify.max_user_watches": "524288",
+		"vm.swappiness":               "60",
+		"net.core.somaxconn":          "4096",
+		"kernel.pid_max":              "4194304",
+	}}
+	ci := &snapshot.Snapshot{Sysctl: map[string]string{
+		"fs.inotify.max_user_watches": "8192",
+		"vm.swappiness":               "10",
+		"net.core.somaxconn":          "4096",
+	}}
+
+	result := Compare(map[string]*snapshot.Snapshot{"laptop": laptop, "ci": ci}, Options{})
+	kernel := result.Diffs["kernel"]
+
+	tests := []struct {
+		field    string
+		severity Severity
+	}{
+		{"fs.inotify.max_user_watches", SeverityHigh},
+		{"vm.swappiness", SeverityMedium},
+		{"kernel.pid_max", SeverityLow},
+	}
+	for _, tt := range tests {
+		field := kernel[tt.field]
+		if field == nil || field.Status.IsEqual() {
+			t.Errorf("%s should differ, got %+v", tt.field, field)
+			continue
+		}
+		if field.Severity != tt.severity {
+			t.Errorf("%s severity = %s, want %s", tt.field, field.Severity, tt.severity)
+		}
+	}
+	if !kernel["net.core.somaxconn"].Status.IsEqual() {
+		t.Error("net.core.somaxconn should be equal")
+	}
+}
+
+func TestCompare_EquivalentVersions(t *testing.T) {
+	snapshots := map[string]*snapshot.Snapshot{
+		"local": {Runtime: map[string]*snapshot.RuntimeInfo{
+			"go": {Version: "1.22", Path: "/usr/bin/go"},
+		}},
+		"ci": {Runtime: map[string]*snapshot.RuntimeInfo{
+			"go": {Version: "1.22.0", Path: "/usr/bin/go"},
+		}},
+	}
+
+	result := Compare(snapshots, Options{})
+
+	goDiff := result.Diffs["runtime"]["go"]
+	if goDiff.Status != StatusEqual {
+		t.Errorf("1.22 and 1.22.0 status = %q, want equal", goDiff.Status)
+	}
+	if goDiff.Delta != nil {
+		t.Errorf("equal versions should have no delta, got %+v", goDiff.Delta)
+	}
+	if result.Summary.Different != 0 {
+		t.Errorf("Summary.Different = %d, want 0", result.Summary.Different)
+	}
+}
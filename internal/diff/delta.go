@@ -0,0 +1,168 @@
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// DeltaKind classifies how far apart the versions of a field are
+type DeltaKind string
+
+// Delta kinds, from smallest to largest change. Unparseable versions
+// cannot be ranked and sort below every other kind.
+const (
+	DeltaUnparseable DeltaKind = "unparseable"
+	DeltaPrerelease  DeltaKind = "prerelease"
+	DeltaPatch       DeltaKind = "patch"
+	DeltaMinor       DeltaKind = "minor"
+	DeltaMajor       DeltaKind = "major"
+)
+
+var deltaRanks = map[DeltaKind]int{
+	DeltaUnparseable: 0,
+	DeltaPrerelease:  1,
+	DeltaPatch:       2,
+	DeltaMinor:       3,
+	DeltaMajor:       4,
+}
+
+// VersionDelta describes a version difference in a runtime or package field
+type VersionDelta struct {
+	Kind  DeltaKind `json:"kind"`
+	Newer string    `json:"newer,omitempty"` // node with the highest version
+}
+
+// AtLeast reports whether k is as large a change as threshold.
+// Unparseable deltas never satisfy a threshold other than themselves.
+func (k DeltaKind) AtLeast(threshold DeltaKind) bool {
+	if k == DeltaUnparseable || threshold == DeltaUnparseable {
+		return k == threshold
+	}
+	return deltaRanks[k] >= deltaRanks[threshold]
+}
+
+// ParseDeltaKind parses a delta kind name such as "major"
+func ParseDeltaKind(name string) (DeltaKind, error) {
+	k := DeltaKind(strings.ToLower(strings.TrimSpace(name)))
+	if _, ok := deltaRanks[k]; !ok {
+		return "", fmt.Errorf("unknown version delta %q (use major, minor, patch, prerelease, or unparseable)", name)
+	}
+	return k, nil
+}
+
+// debianEpoch matches the "1:" epoch prefix of Debian package versions
+var debianEpoch = regexp.MustCompile(`^\d+:`)
+
+// parseVersion parses runtime and package versions leniently: "1.22",
+// "v20.1.0" and Debian-style "1:2.39.5-0+deb12u2" are all accepted
+func parseVersion(s string) (*semver.Version, error) {
+	return semver.NewVersion(debianEpoch.ReplaceAllString(strings.TrimSpace(s), ""))
+}
+
+// classifyVersions sets the delta of a different version field. Spellings
+// of one version, such as "1.22" from go itself and "1.22.0" from a
+// .go-version pin, make the field equal instead.
+func classifyVersions(fieldDiff *FieldDiff) {
+	if fieldDiff.Status != StatusDifferent {
+		return
+	}
+	fieldDiff.Delta = versionDelta(fieldDiff.NodeValues)
+	if fieldDiff.Delta == nil && sameVersion(fieldDiff.NodeValues) {
+		fieldDiff.Status = StatusEqual
+		fieldDiff.Majority, fieldDiff.Outliers = nil, nil
+	}
+}
+
+// sameVersion reports whether every value parses to the same version,
+// build metadata included
+func sameVersion(values map[string]any) bool {
+	var first *semver.Version
+	for _, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			return false
+		}
+		v, err := parseVersion(s)
+		if err != nil {
+			return false
+		}
+		if first == nil {
+			first = v
+		} else if !v.Equal(first) || v.Metadata() != first.Metadata() {
+			return false
+		}
+	}
+	return first != nil
+}
+
+// versionDelta classifies the difference between the lowest and highest
+// version of a field. It returns nil when a node has no value (a missing
+// runtime is not a version change) or when the versions are equivalent.
+func versionDelta(values map[string]any) *VersionDelta {
+	var newest, oldest *semver.Version
+	var newestNode string
+
+	nodes := make([]string, 0, len(values))
+	for node := range values {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	for _, node := range nodes {
+		if _, ok := values[node].(string); !ok {
+			return nil
+		}
+	}
+
+	versions := make([]*semver.Version, 0, len(nodes))
+	for _, node := range nodes {
+		v, err := parseVersion(values[node].(string))
+		if err != nil {
+			return &VersionDelta{Kind: DeltaUnparseable}
+		}
+		if newest == nil || v.GreaterThan(newest) {
+			newest, newestNode = v, node
+		}
+		if oldest == nil || v.LessThan(oldest) {
+			oldest = v
+		}
+		versions = append(versions, v)
+	}
+
+	if newest == nil {
+		return nil
+	}
+
+	delta := &VersionDelta{Newer: newestNode}
+	switch {
+	case newest.Major() != oldest.Major():
+		delta.Kind = DeltaMajor
+	case newest.Minor() != oldest.Minor():
+		delta.Kind = DeltaMinor
+	case newest.Patch() != oldest.Patch():
+		delta.Kind = DeltaPatch
+	case buildsDiffer(versions):
+		delta.Kind = DeltaPrerelease
+		if !newest.GreaterThan(oldest) {
+			delta.Newer = "" // build metadata has no precedence
+		}
+	default:
+		return nil
+	}
+	return delta
+}
+
+// buildsDiffer reports whether versions with the same major.minor.patch
+// differ in their prerelease or build metadata
+func buildsDiffer(versions []*semver.Version) bool {
+	for _, v := range versions[1:] {
+		if v.Prerelease() != versions[0].Prerelease() || v.Metadata() != versions[0].Metadata() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package diff
+
+import "testing"
+
+func TestVersionDelta(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    map[string]any
+		wantKind  DeltaKind
+		wantNewer string
+	}{
+		{"patch", map[string]any{"local": "1.22.0", "ci": "1.22.1"}, DeltaPatch, "ci"},
+		{"major downgrade", map[string]any{"local": "22.1.0", "ci": "18.19.0"}, DeltaMajor, "local"},
+		{"minor short form", map[string]any{"local": "3.11", "ci": "3.12.1"}, DeltaMinor, "ci"},
+		{"prerelease", map[string]any{"local": "1.0.0-rc.1", "ci": "1.0.0"}, DeltaPrerelease, "ci"},
+		{"debian revision", map[string]any{"local": "1:2.39.5-0+deb12u1", "ci": "1:2.39.5-0+deb12u2"}, DeltaPrerelease, ""},
+		{"unparseable", map[string]any{"local": "unknown", "ci": "1.2.3"}, DeltaUnparseable, ""},
+		{"largest of many", map[string]any{"a": "1.2.0", "b": "1.3.0", "c": "2.0.0"}, DeltaMajor, "c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delta := versionDelta(tt.values)
+			if delta == nil {
+				t.Fatal("versionDelta() = nil")
+			}
+			if delta.Kind != tt.wantKind {
+				t.Errorf("Kind = %q, want %q", delta.Kind, tt.wantKind)
+			}
+			if tt.wantNewer != "" && delta.Newer != tt.wantNewer {
+				t.Errorf("Newer = %q, want %q", delta.Newer, tt.wantNewer)
+			}
+		})
+	}
+}
+
+func TestVersionDelta_NoDelta(t *testing.T) {
+	if delta := versionDelta(map[string]any{"local": "1.22.0", "ci": nil}); delta != nil {
+		t.Errorf("a missing value should have no delta, got %+v", delta)
+	}
+	if delta := versionDelta(map[string]any{"local": "1.22", "ci": "1.22.0"}); delta != nil {
+		t.Errorf("equivalent versions should have no delta, got %+v", delta)
+	}
+}
+
+func TestDeltaKind_AtLeast(t *testing.T) {
+	if !DeltaMajor.AtLeast(DeltaMinor) {
+		t.Error("major should be at least minor")
+	}
+	if DeltaPatch.AtLeast(DeltaMajor) {
+		t.Error("patch should not be at least major")
+	}
+	if DeltaUnparseable.AtLeast(DeltaPrerelease) {
+		t.Error("unparseable should not rank against parseable deltas")
+	}
+}
+
+func TestClassifyVersions(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]any
+		want   FieldStatus
+	}{
+		{"short form", map[string]any{"local": "1.22", "ci": "1.22.0"}, StatusEqual},
+		{"v prefix", map[string]any{"local": "v20.1.0", "ci": "20.1.0"}, StatusEqual},
+		{"patch", map[string]any{"local": "1.22", "ci": "1.22.1"}, StatusDifferent},
+		{"missing", map[string]any{"local": "1.22.0", "ci": nil}, StatusDifferent},
+		{"unparseable", map[string]any{"local": "devel", "ci": "tip"}, StatusDifferent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fieldDiff := createFieldDiff(tt.values, []string{"ci", "local"})
+			classifyVersions(fieldDiff)
+			if fieldDiff.Status != tt.want {
+				t.Errorf("Status = %q, want %q", fieldDiff.Status, tt.want)
+			}
+		})
+	}
+}
@@ -13,6 +13,10 @@ type FieldDiff struct {
 	NodeValues map[string]any `json:"values"` // values keyed by node name
 	Majority   any            `json:"majority,omitempty"`
 	Outliers   []string       `json:"outliers,omitempty"`
+	Delta      *VersionDelta  `json:"delta,omitempty"` // runtime and package fields only
+	List       *ListDiff      `json:"list,omitempty"`  // list-valued env fields such as PATH only
+	Severity   Severity       `json:"severity,omitempty"`
+	Reason     string         `json:"reason,omitempty"`
 }
 
 // Summary contains diff statistics
@@ -24,6 +28,7 @@ type Summary struct {
 	Equal           int `json:"equal"`
 	Different       int `json:"different"`
 	Redacted        int `json:"redacted"`
+	Ignored         int `json:"ignored"`
 }
 
 // Diff represents a comparison between multiple snapshots
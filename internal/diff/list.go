@@ -0,0 +1,213 @@
+package diff
+
+import (
+	"strings"
+
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+// ListChangeKind says how an entry of a list variable changed
+type ListChangeKind string
+
+// List change kinds
+const (
+	ListAdded   ListChangeKind = "added"
+	ListRemoved ListChangeKind = "removed"
+	ListMoved   ListChangeKind = "moved"
+)
+
+// ListChange is one entry that differs from the baseline. From is its
+// index in the baseline list and To its index in the node's list, or -1
+// where the entry is absent.
+type ListChange struct {
+	Kind  ListChangeKind `json:"kind"`
+	Entry string         `json:"entry"`
+	From  int            `json:"from"`
+	To    int            `json:"to"`
+}
+
+// ListDiff breaks a list-valued variable such as PATH into the entries each
+// node added, removed or reordered relative to a baseline node
+type ListDiff struct {
+	Separator string                  `json:"separator"`
+	Baseline  string                  `json:"baseline"`
+	Changes   map[string][]ListChange `json:"changes,omitempty"` // keyed by node
+	Missing   map[string][]string     `json:"missing,omitempty"` // entries not on disk, keyed by node
+}
+
+// compareList builds the list diff for a variable that differs between
+// nodes. The baseline is the first node by name holding the majority
+// value, or the first that has the variable at all. Nodes without it are
+// left out.
+func compareList(name, sep string, fieldDiff *FieldDiff, snapshots map[string]*snapshot.Snapshot, nodes []string) *ListDiff {
+	baseline := ""
+	for _, node := range nodes {
+		value, ok := fieldDiff.NodeValues[node].(string)
+		if !ok {
+			continue
+		}
+		if fieldDiff.Majority != nil && value == fieldDiff.Majority {
+			baseline = node
+			break
+		}
+		if baseline == "" {
+			baseline = node
+		}
+	}
+	if baseline == "" {
+		return nil
+	}
+
+	list := &ListDiff{
+		Separator: sep,
+		Baseline:  baseline,
+		Changes:   make(map[string][]ListChange),
+	}
+	base := splitList(fieldDiff.NodeValues[baseline].(string), listSeparator(snapshots[baseline], sep))
+	for _, node := range nodes {
+		value, ok := fieldDiff.NodeValues[node].(string)
+		if !ok {
+			continue
+		}
+		if node != baseline {
+			if changes := listChanges(base, splitList(value, listSeparator(snapshots[node], sep))); len(changes) > 0 {
+				list.Changes[node] = changes
+			}
+		}
+		if missing := snapshots[node].MissingPaths[name]; len(missing) > 0 {
+			if list.Missing == nil {
+				list.Missing = make(map[string][]string)
+			}
+			list.Missing[node] = missing
+		}
+	}
+	return list
+}
+
+// onlyMoved reports whether every node holds the baseline's entries,
+// reordered. Lookup order still matters for PATH, so this is a difference.
+func (l *ListDiff) onlyMoved() bool {
+	moved := false
+	for _, changes := range l.Changes {
+		for _, change := range changes {
+			if change.Kind != ListMoved {
+				return false
+			}
+			moved = true
+		}
+	}
+	return moved
+}
+
+// listSeparator uses ";" for path lists from Windows hosts
+func listSeparator(snap *snapshot.Snapshot, sep string) string {
+	if sep == ":" && snap != nil && snap.System.OS == "windows" {
+		return ";"
+	}
+	return sep
+}
+
+// splitList splits a list variable into entries. A space separator splits
+// on any run of whitespace, as for flag lists like GOFLAGS.
+func splitList(value, sep string) []string {
+	if sep == " " {
+		return strings.Fields(value)
+	}
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, sep)
+}
+
+// listChanges compares a node's entries with the baseline's. Only the first
+// occurrence of a repeated entry counts, since that is the one a lookup finds.
+// Entries in both lists that fall outside their longest common ordering are
+// reported as moved.
+func listChanges(base, other []string) []ListChange {
+	baseIndex := firstIndexes(base)
+	otherIndex := firstIndexes(other)
+
+	var baseCommon, otherCommon []string
+	for i, entry := range base {
+		if baseIndex[entry] == i {
+			if _, ok := otherIndex[entry]; ok {
+				baseCommon = append(baseCommon, entry)
+			}
+		}
+	}
+	for i, entry := range other {
+		if otherIndex[entry] == i {
+			if _, ok := baseIndex[entry]; ok {
+				otherCommon = append(otherCommon, entry)
+			}
+		}
+	}
+	inOrder := longestCommonOrder(baseCommon, otherCommon)
+
+	var changes []ListChange
+	for i, entry := range other {
+		if otherIndex[entry] != i {
+			continue
+		}
+		from, inBase := baseIndex[entry]
+		switch {
+		case !inBase:
+			changes = append(changes, ListChange{Kind: ListAdded, Entry: entry, From: -1, To: i})
+		case !inOrder[entry]:
+			changes = append(changes, ListChange{Kind: ListMoved, Entry: entry, From: from, To: i})
+		}
+	}
+	for i, entry := range base {
+		if baseIndex[entry] != i {
+			continue
+		}
+		if _, ok := otherIndex[entry]; !ok {
+			changes = append(changes, ListChange{Kind: ListRemoved, Entry: entry, From: i, To: -1})
+		}
+	}
+	return changes
+}
+
+// firstIndexes maps each entry to the index of its first occurrence
+func firstIndexes(entries []string) map[string]int {
+	index := make(map[string]int, len(entries))
+	for i, entry := range entries {
+		if _, seen := index[entry]; !seen {
+			index[entry] = i
+		}
+	}
+	return index
+}
+
+// longestCommonOrder returns the entries of the longest common subsequence
+// of a and b, which hold the same distinct entries in different orders
+func longestCommonOrder(a, b []string) map[string]bool {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	common := make(map[string]bool)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			common[a[i]] = true
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return common
+}
@@ -0,0 +1,106 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+func TestListChanges(t *testing.T) {
+	base := []string{"/usr/local/bin", "/usr/bin", "/bin", "/usr/local/go/bin"}
+	other := []string{"/opt/tool/bin", "/bin", "/usr/local/bin", "/usr/bin", "/bin"}
+
+	changes := listChanges(base, other)
+	want := []ListChange{
+		{Kind: ListAdded, Entry: "/opt/tool/bin", From: -1, To: 0},
+		{Kind: ListMoved, Entry: "/bin", From: 2, To: 1},
+		{Kind: ListRemoved, Entry: "/usr/local/go/bin", From: 3, To: -1},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("got %+v, want %+v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("change %d = %+v, want %+v", i, changes[i], want[i])
+		}
+	}
+
+	if changes := listChanges(base, base); len(changes) != 0 {
+		t.Errorf("identical lists should have no changes, got %+v", changes)
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	if got := splitList("-mod=mod   -trimpath", " "); len(got) != 2 {
+		t.Errorf("space-separated lists split on runs of whitespace, got %q", got)
+	}
+	if got := splitList("", ":"); got != nil {
+		t.Errorf("an empty value has no entries, got %q", got)
+	}
+	if got := splitList("/a::/b", ":"); len(got) != 3 || got[1] != "" {
+		t.Errorf("empty entries are kept, got %q", got)
+	}
+}
+
+func TestCompare_ListVariables(t *testing.T) {
+	local := &snapshot.Snapshot{
+		Runtime: map[string]*snapshot.RuntimeInfo{},
+		Env:     map[string]string{"PATH": "/usr/local/bin:/usr/bin:/bin", "CLASSPATH": "a.jar:b.jar"},
+	}
+	ci := &snapshot.Snapshot{
+		Runtime:      map[string]*snapshot.RuntimeInfo{},
+		Env:          map[string]string{"PATH": "/usr/bin:/usr/local/bin:/bin:/opt/ci/bin", "CLASSPATH": "b.jar:a.jar"},
+		MissingPaths: map[string][]string{"PATH": {"/opt/ci/bin"}},
+	}
+	snapshots := map[string]*snapshot.Snapshot{"local": local, "ci": ci}
+
+	result := Compare(snapshots, Options{})
+	path := result.Diffs["env"]["PATH"].List
+	if path == nil {
+		t.Fatal("PATH should have a list diff")
+	}
+	if path.Baseline != "ci" {
+		t.Errorf("Baseline = %q, want ci, the first node by name", path.Baseline)
+	}
+	kinds := make(map[ListChangeKind]int)
+	for _, change := range path.Changes["local"] {
+		kinds[change.Kind]++
+	}
+	if kinds[ListAdded]+kinds[ListRemoved] != 1 || kinds[ListMoved] != 1 {
+		t.Errorf("expected one added or removed and one moved entry, got %+v", path.Changes)
+	}
+	if len(path.Missing["ci"]) != 1 {
+		t.Errorf("Missing = %v, want /opt/ci/bin on ci", path.Missing)
+	}
+
+	// CLASSPATH is only a list when configured
+	if result.Diffs["env"]["CLASSPATH"].List != nil {
+		t.Error("CLASSPATH is not a default list variable")
+	}
+	result = Compare(snapshots, Options{ListSeparators: map[string]string{"CLASSPATH": ":"}})
+	classpath := result.Diffs["env"]["CLASSPATH"]
+	if classpath.List == nil || !classpath.List.onlyMoved() {
+		t.Fatalf("CLASSPATH should only have moved entries, got %+v", classpath.List)
+	}
+	if classpath.Reason != "same entries in a different order" {
+		t.Errorf("Reason = %q", classpath.Reason)
+	}
+	if result.Diffs["env"]["PATH"].List != nil {
+		t.Error("configured separators replace the defaults")
+	}
+}
+
+func TestCompare_ListBaselineIsStable(t *testing.T) {
+	snapshots := map[string]*snapshot.Snapshot{
+		"local": {Runtime: map[string]*snapshot.RuntimeInfo{}, Env: map[string]string{"PATH": "/usr/local/bin:/usr/bin"}},
+		"ci":    {Runtime: map[string]*snapshot.RuntimeInfo{}, Env: map[string]string{"PATH": "/usr/bin:/opt/ci/bin"}},
+	}
+
+	want := Compare(snapshots, Options{}).Diffs["env"]["PATH"].List
+	for i := 0; i < 50; i++ {
+		got := Compare(snapshots, Options{}).Diffs["env"]["PATH"].List
+		if got.Baseline != want.Baseline || len(got.Changes[want.Baseline]) != 0 {
+			t.Fatalf("run %d: Baseline = %q, want %q on every run", i, got.Baseline, want.Baseline)
+		}
+	}
+}
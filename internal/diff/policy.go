@@ -0,0 +1,210 @@
+package diff
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/GBerghoff/envdiff/internal/check"
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+// Policy decides which differences should fail a CI job.
+//
+// Each --fail-on expression is one of:
+//
+//	any                any differing field
+//	runtime            any difference in a section (same as section:runtime)
+//	section:env        any difference in a section
+//	field:runtime.go   a field, as section.name (glob patterns allowed;
+//	                   runtime.* covers every field in the section)
+//	severity>=high     differences at or above a severity (also >, =)
+//	major              a version delta at least this large (also minor,
+//	                   patch, prerelease; same as delta>=major)
+//
+// A difference fails the policy if it matches any expression and no --allow
+// pattern. Allow patterns match section.name or the bare name, like ignore
+// patterns, and may carry the field: prefix.
+type Policy struct {
+	rules []policyRule
+	allow []string
+}
+
+type policyRule struct {
+	expr  string
+	match func(Issue) bool
+}
+
+// Violation is a difference that fails a policy, with the expression it matched
+type Violation struct {
+	Issue
+	Rule string
+}
+
+// ParsePolicy builds a policy from --fail-on expressions and --allow patterns
+func ParsePolicy(failOn, allow []string) (*Policy, error) {
+	p := &Policy{}
+	for _, expr := range failOn {
+		expr = strings.TrimSpace(expr)
+		if expr == "" {
+			continue
+		}
+		match, err := parseRule(expr)
+		if err != nil {
+			return nil, err
+		}
+		p.rules = append(p.rules, policyRule{expr: expr, match: match})
+	}
+	for _, pattern := range allow {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			p.allow = append(p.allow, strings.TrimPrefix(pattern, "field:"))
+		}
+	}
+	return p, nil
+}
+
+func parseRule(expr string) (func(Issue) bool, error) {
+	if expr == "any" {
+		return func(Issue) bool { return true }, nil
+	}
+
+	if rest, ok := strings.CutPrefix(expr, "severity"); ok {
+		return parseSeverityRule(expr, rest)
+	}
+	if rest, ok := strings.CutPrefix(expr, "delta>="); ok {
+		kind, err := ParseDeltaKind(rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --fail-on %q: %w", expr, err)
+		}
+		return deltaRule(kind), nil
+	}
+	if section, ok := strings.CutPrefix(expr, "section:"); ok {
+		return parseSectionRule(expr, section)
+	}
+	if pattern, ok := strings.CutPrefix(expr, "field:"); ok {
+		return func(i Issue) bool { return fieldPatternMatches(i.Section, i.Field, pattern) }, nil
+	}
+	if kind, err := ParseDeltaKind(expr); err == nil {
+		return deltaRule(kind), nil
+	}
+	if !strings.ContainsAny(expr, ":<>=.*") {
+		return parseSectionRule(expr, expr)
+	}
+
+	return nil, fmt.Errorf("invalid --fail-on %q (use any, <section>, section:<name>, field:<section.name>, severity>=<level>, or major/minor/patch)", expr)
+}
+
+func parseSeverityRule(expr, rest string) (func(Issue) bool, error) {
+	var op string
+	for _, candidate := range []string{">=", ">", "="} {
+		if strings.HasPrefix(rest, candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		return nil, fmt.Errorf("invalid --fail-on %q: expected severity>=, severity>, or severity=", expr)
+	}
+
+	level, err := ParseSeverity(strings.TrimPrefix(rest, op))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --fail-on %q: %w", expr, err)
+	}
+
+	return func(i Issue) bool {
+		switch op {
+		case ">=":
+			return i.Severity.Rank() >= level.Rank()
+		case ">":
+			return i.Severity.Rank() > level.Rank()
+		default:
+			return i.Severity == level
+		}
+	}, nil
+}
+
+// diffSections are the sections Compare produces besides one per package
+// ecosystem
+var diffSections = []string{
+	"system", "runtime", "env", "package", "network", "project", "repo",
+	"filesystem", "limits", "kernel", "toolchain",
+}
+
+// parseSectionRule rejects section names Compare never produces, so that a
+// typo such as "runtimes" fails instead of silently matching nothing
+func parseSectionRule(expr, section string) (func(Issue) bool, error) {
+	if !slices.Contains(diffSections, section) && !slices.Contains(snapshot.InventoryEcosystems, section) {
+		sections := append(slices.Clone(diffSections), snapshot.InventoryEcosystems...)
+		return nil, fmt.Errorf("invalid --fail-on %q: unknown section %q (use one of %s)", expr, section, strings.Join(sections, ", "))
+	}
+	return sectionRule(section), nil
+}
+
+func sectionRule(section string) func(Issue) bool {
+	return func(i Issue) bool { return i.Section == section }
+}
+
+func deltaRule(kind DeltaKind) func(Issue) bool {
+	return func(i Issue) bool { return i.Delta != nil && i.Delta.Kind.AtLeast(kind) }
+}
+
+// Empty reports whether the policy has no --fail-on expressions
+func (p *Policy) Empty() bool {
+	return len(p.rules) == 0
+}
+
+// Violations returns the differences that fail the policy, sorted by field
+func (p *Policy) Violations(d *Diff) []Violation {
+	var violations []Violation
+	for section, fields := range d.Diffs {
+		for name, fieldDiff := range fields {
+			if !fieldDiff.Status.IsDifferent() {
+				continue
+			}
+			issue := Issue{Section: section, Field: name, FieldDiff: fieldDiff}
+			if p.allowed(issue) {
+				continue
+			}
+			for _, rule := range p.rules {
+				if rule.match(issue) {
+					violations = append(violations, Violation{Issue: issue, Rule: rule.expr})
+					break
+				}
+			}
+		}
+	}
+
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].Section != violations[j].Section {
+			return violations[i].Section < violations[j].Section
+		}
+		return violations[i].Field < violations[j].Field
+	})
+	return violations
+}
+
+func (p *Policy) allowed(i Issue) bool {
+	return matchField(i.Section, i.Field, p.allow)
+}
+
+// matchField reports whether any pattern matches a field as section.name or
+// as the bare name
+func matchField(section, field string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if check.ShouldIgnore(field, []string{pattern}) || fieldPatternMatches(section, field, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldPatternMatches matches a glob against section.name. A trailing ".*"
+// covers the rest of the section: glob stars stop at "/", which would miss
+// names such as runtime.python3/install or toolchain.npm/registry.
+func fieldPatternMatches(section, field, pattern string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, ".*"); ok && check.ShouldIgnore(section, []string{prefix}) {
+		return true
+	}
+	return check.ShouldIgnore(section+"."+field, []string{pattern})
+}
@@ -0,0 +1,146 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func policyDiff() *Diff {
+	d := New()
+	d.Diffs["runtime"] = map[string]*FieldDiff{
+		"go":   {Status: StatusDifferent, Severity: SeverityMedium, Delta: &VersionDelta{Kind: DeltaMinor}},
+		"node": {Status: StatusDifferent, Severity: SeverityHigh, Delta: &VersionDelta{Kind: DeltaMajor}},
+		"git":  {Status: StatusEqual},
+	}
+	d.Diffs["env"] = map[string]*FieldDiff{
+		"TZ":       {Status: StatusDifferent, Severity: SeverityHigh},
+		"HOSTNAME": {Status: StatusDifferent, Severity: SeverityLow},
+		"API_KEY":  {Status: StatusRedacted, Severity: SeverityLow},
+	}
+	return d
+}
+
+func violationFields(violations []Violation) []string {
+	fields := make([]string, len(violations))
+	for i, v := range violations {
+		fields[i] = v.Section + "." + v.Field
+	}
+	return fields
+}
+
+func TestPolicy_Violations(t *testing.T) {
+	tests := []struct {
+		name   string
+		failOn []string
+		allow  []string
+		want   []string
+	}{
+		{"any", []string{"any"}, nil, []string{"env.HOSTNAME", "env.TZ", "runtime.go", "runtime.node"}},
+		{"bare section", []string{"runtime"}, nil, []string{"runtime.go", "runtime.node"}},
+		{"section prefix", []string{"section:env"}, nil, []string{"env.HOSTNAME", "env.TZ"}},
+		{"field", []string{"field:runtime.go"}, nil, []string{"runtime.go"}},
+		{"field glob", []string{"field:runtime.*"}, nil, []string{"runtime.go", "runtime.node"}},
+		{"severity", []string{"severity>=high"}, nil, []string{"env.TZ", "runtime.node"}},
+		{"severity strict", []string{"severity>medium"}, nil, []string{"env.TZ", "runtime.node"}},
+		{"severity exact", []string{"severity=low"}, nil, []string{"env.HOSTNAME"}},
+		{"delta shorthand", []string{"major"}, nil, []string{"runtime.node"}},
+		{"delta", []string{"delta>=minor"}, nil, []string{"runtime.go", "runtime.node"}},
+		{"or", []string{"major", "field:env.TZ"}, nil, []string{"env.TZ", "runtime.node"}},
+		{"allow", []string{"any"}, []string{"env.HOSTNAME", "field:runtime.go"}, []string{"env.TZ", "runtime.node"}},
+		{"allow bare name", []string{"env"}, []string{"TZ"}, []string{"env.HOSTNAME"}},
+		{"no match", []string{"section:network"}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParsePolicy(tt.failOn, tt.allow)
+			if err != nil {
+				t.Fatalf("ParsePolicy() error = %v", err)
+			}
+			got := violationFields(policy.Violations(policyDiff()))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Violations() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Violations()[%d] = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPolicy_SectionWildcardCoversSlashes(t *testing.T) {
+	d := New()
+	d.Diffs["runtime"] = map[string]*FieldDiff{
+		"python3/install": {Status: StatusDifferent, Severity: SeverityMedium},
+	}
+	d.Diffs["toolchain"] = map[string]*FieldDiff{
+		"npm/registry": {Status: StatusDifferent, Severity: SeverityHigh},
+	}
+
+	for _, tt := range []struct {
+		name   string
+		failOn []string
+		allow  []string
+		want   []string
+	}{
+		{"field section", []string{"field:toolchain.*"}, nil, []string{"toolchain.npm/registry"}},
+		{"field any section", []string{"field:*.*"}, nil, []string{"runtime.python3/install", "toolchain.npm/registry"}},
+		{"field glob stops at slash", []string{"field:toolchain.n*"}, nil, nil},
+		{"allow section", []string{"any"}, []string{"runtime.*"}, []string{"toolchain.npm/registry"}},
+		{"allow with prefix", []string{"any"}, []string{"field:toolchain.*"}, []string{"runtime.python3/install"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParsePolicy(tt.failOn, tt.allow)
+			if err != nil {
+				t.Fatalf("ParsePolicy() error = %v", err)
+			}
+			got := violationFields(policy.Violations(d))
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Violations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_RecordsRule(t *testing.T) {
+	policy, err := ParsePolicy([]string{"field:env.*", "severity>=high"}, nil)
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	for _, v := range policy.Violations(policyDiff()) {
+		if v.Section == "runtime" && v.Rule != "severity>=high" {
+			t.Errorf("%s.%s matched %q, want severity>=high", v.Section, v.Field, v.Rule)
+		}
+		if v.Section == "env" && v.Rule != "field:env.*" {
+			t.Errorf("%s.%s matched %q, want the first matching rule", v.Section, v.Field, v.Rule)
+		}
+	}
+}
+
+func TestParsePolicy_Invalid(t *testing.T) {
+	for _, expr := range []string{"severity>=urgent", "severity~high", "delta>=huge", "runtime.go", "bogus:x", "runtimes", "enviroment", "section:envs"} {
+		if _, err := ParsePolicy([]string{expr}, nil); err == nil {
+			t.Errorf("ParsePolicy(%q) should fail", expr)
+		}
+	}
+}
+
+func TestParsePolicy_Sections(t *testing.T) {
+	for _, expr := range []string{"runtime", "section:kernel", "pip", "section:gobin"} {
+		if _, err := ParsePolicy([]string{expr}, nil); err != nil {
+			t.Errorf("ParsePolicy(%q) error = %v", expr, err)
+		}
+	}
+}
+
+func TestParsePolicy_Empty(t *testing.T) {
+	policy, err := ParsePolicy(nil, []string{"env.TZ"})
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+	if !policy.Empty() {
+		t.Error("a policy without --fail-on should be empty")
+	}
+}
@@ -0,0 +1,379 @@
+package diff
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/GBerghoff/envdiff/internal/check"
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+// behaviorEnv lists variables that change how programs build or run.
+// A difference here is a likely cause, not a symptom.
+var behaviorEnv = map[string]bool{
+	"NODE_ENV":               true,
+	"RAILS_ENV":              true,
+	"RACK_ENV":               true,
+	"APP_ENV":                true,
+	"FLASK_ENV":              true,
+	"DJANGO_SETTINGS_MODULE": true,
+	"NODE_OPTIONS":           true,
+	"GO111MODULE":            true,
+	"GOFLAGS":                true,
+	"CGO_ENABLED":            true,
+	"GOOS":                   true,
+	"GOARCH":                 true,
+	"JAVA_HOME":              true,
+	"JAVA_TOOL_OPTIONS":      true,
+	"PYTHONPATH":             true,
+	"VIRTUAL_ENV":            true,
+	"LD_LIBRARY_PATH":        true,
+	"LD_PRELOAD":             true,
+	"DYLD_LIBRARY_PATH":      true,
+	"TZ":                     true,
+	"LC_ALL":                 true,
+	"SSL_CERT_FILE":          true,
+	"REQUESTS_CA_BUNDLE":     true,
+	"NODE_EXTRA_CA_CERTS":    true,
+	"HTTP_PROXY":             true,
+	"HTTPS_PROXY":            true,
+	"NO_PROXY":               true,
+}
+
+// noiseEnv lists glob patterns for variables that differ between any two
+// machines or shells and almost never explain a failure.
+var noiseEnv = []string{
+	"HOSTNAME", "HOST", "USER", "LOGNAME", "HOME", "PWD", "OLDPWD", "SHLVL", "_",
+	"TERM", "TERM_*", "COLORTERM", "DISPLAY", "TMPDIR", "XDG_*", "SSH_*",
+	"*_SESSION*", "WINDOWID", "ITERM_*", "VSCODE_*",
+}
+
+// toolchainBehavior lists toolchain settings that change what gets built
+// or which dependencies are resolved, as "<tool>/<setting>". A "*" matches
+// any run of characters, including the slashes in git URL subsections.
+var toolchainBehavior = []string{
+	"go/GOFLAGS", "go/GOPRIVATE", "go/GOPROXY", "go/GONOPROXY", "go/GONOSUMDB", "go/GOSUMDB",
+	"go/GOINSECURE", "go/CGO_ENABLED", "go/GOAMD64", "go/GOARM", "go/GOARM64", "go/GOOS",
+	"go/GOARCH", "go/GOEXPERIMENT", "go/GOTOOLCHAIN", "go/GO111MODULE", "go/CC", "go/CXX",
+	"go/CGO_CFLAGS", "go/CGO_LDFLAGS",
+	"npm/registry", "npm/@*:registry", "npm/ignore-scripts", "npm/legacy-peer-deps",
+	"npm/engine-strict", "npm/omit", "npm/strict-ssl", "npm/node-options",
+	"pip/*.index-url", "pip/*.extra-index-url", "pip/*.trusted-host", "pip/*.no-binary",
+	"pip/*.only-binary", "pip/*.constraint",
+	"git/core.autocrlf", "git/core.eol", "git/core.symlinks", "git/core.ignorecase",
+	"git/core.filemode", "git/url.*.insteadof", "git/http.sslverify", "git/submodule.recurse",
+}
+
+// toolchainNoise lists settings that hold per-user paths or preferences
+var toolchainNoise = []string{
+	"go/GOPATH", "go/GOCACHE", "go/GOMODCACHE", "go/GOENV", "go/GOROOT", "go/GOTOOLDIR",
+	"go/GOMOD", "go/GOWORK", "go/GOTMPDIR", "go/GOTELEMETRYDIR", "go/GOTELEMETRY",
+	"npm/cache", "npm/prefix", "npm/globalconfig", "npm/userconfig", "npm/user-agent",
+	"npm/init-*", "npm/init.*", "npm/editor", "npm/shell", "npm/viewer", "npm/tmp",
+	"git/user.*", "git/core.editor", "git/core.pager", "git/color.*", "git/alias.*",
+	"git/credential.*", "git/gpg.*", "git/commit.*", "git/init.*", "git/safe.*",
+}
+
+// matchesSetting matches a toolchain field against a pattern with at most
+// one "*"
+func matchesSetting(field, pattern string) bool {
+	prefix, suffix, wildcard := strings.Cut(pattern, "*")
+	if !wildcard {
+		return field == pattern
+	}
+	return len(field) >= len(prefix)+len(suffix) &&
+		strings.HasPrefix(field, prefix) && strings.HasSuffix(field, suffix)
+}
+
+func matchesAnySetting(field string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matchesSetting(field, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// scoreDiffs assigns a severity and reason to every non-equal field
+func scoreDiffs(result *Diff) {
+	for section, fields := range result.Diffs {
+		for name, fieldDiff := range fields {
+			if fieldDiff.Status.IsEqual() {
+				continue
+			}
+			fieldDiff.Severity, fieldDiff.Reason = scoreField(section, name, fieldDiff, result.Nodes)
+		}
+	}
+}
+
+func scoreField(section, name string, fieldDiff *FieldDiff, nodes []string) (Severity, string) {
+	if fieldDiff.Status == StatusRedacted {
+		return SeverityLow, "secret value, not compared"
+	}
+
+	missing := missingNodes(fieldDiff, nodes)
+
+	switch section {
+	case "system":
+		switch name {
+		case "arch":
+			return SeverityCritical, "architecture mismatch"
+		case "os":
+			return SeverityCritical, "different operating systems"
+		case "os_version":
+			return SeverityMedium, "OS version differs"
+		case "kernel":
+			return SeverityLow, "kernel version differs"
+		case "cpu_cores", "memory_gb":
+			return SeverityLow, "host hardware differs"
+		default:
+			return SeverityLow, "system detail differs"
+		}
+
+	case "runtime":
+		if strings.HasSuffix(name, "/install") {
+			return SeverityMedium, "same version, different winning install"
+		}
+		if len(missing) > 0 {
+			return SeverityHigh, fmt.Sprintf("%s missing on %s", name, strings.Join(missing, ", "))
+		}
+		switch deltaKind(fieldDiff) {
+		case DeltaMajor:
+			return SeverityHigh, "major version difference"
+		case DeltaMinor:
+			return SeverityMedium, "minor version difference"
+		case DeltaPatch:
+			return SeverityLow, "patch version difference"
+		case DeltaPrerelease:
+			return SeverityLow, "prerelease or build differs"
+		default:
+			return SeverityMedium, "versions differ and could not be compared"
+		}
+
+	case "package", snapshot.InventoryPip, snapshot.InventoryNpm, snapshot.InventoryGem,
+		snapshot.InventoryCargo, snapshot.InventoryGoBin:
+		if len(missing) > 0 {
+			return SeverityMedium, fmt.Sprintf("not installed on %s", strings.Join(missing, ", "))
+		}
+		if deltaKind(fieldDiff) == DeltaMajor {
+			return SeverityMedium, "major version difference"
+		}
+		return SeverityLow, "package version differs"
+
+	case "env":
+		if check.ShouldIgnore(name, noiseEnv) {
+			return SeverityLow, "host-specific, likely noise"
+		}
+		if behaviorEnv[name] {
+			return SeverityHigh, "changes build or runtime behavior"
+		}
+		if len(missing) > 0 {
+			return SeverityMedium, fmt.Sprintf("not set on %s", strings.Join(missing, ", "))
+		}
+		if fieldDiff.Status == StatusRedactedDifferent {
+			return SeverityMedium, "secret value differs (fingerprints do not match)"
+		}
+		if fieldDiff.List != nil && fieldDiff.List.onlyMoved() {
+			return SeverityMedium, "same entries in a different order"
+		}
+		return SeverityMedium, "value differs"
+
+	case "project":
+		file, dep, isDep := strings.Cut(name, ":")
+		if !isDep {
+			if len(missing) > 0 {
+				return SeverityMedium, fmt.Sprintf("no %s on %s", file, strings.Join(missing, ", "))
+			}
+			return SeverityLow, "lockfile contents differ"
+		}
+		if len(missing) > 0 {
+			return SeverityMedium, fmt.Sprintf("%s not locked in %s on %s", dep, file, strings.Join(missing, ", "))
+		}
+		if deltaKind(fieldDiff) == DeltaMajor {
+			return SeverityHigh, fmt.Sprintf("major version difference in %s", file)
+		}
+		return SeverityMedium, fmt.Sprintf("resolved version differs in %s", file)
+
+	case "toolchain":
+		tool, _, _ := strings.Cut(name, "/")
+		missing = unsetNodes(fieldDiff, nodes)
+		if matchesAnySetting(name, toolchainNoise) {
+			return SeverityLow, "per-user path or preference, likely noise"
+		}
+		if matchesAnySetting(name, toolchainBehavior) {
+			return SeverityHigh, fmt.Sprintf("changes how %s builds or resolves dependencies", tool)
+		}
+		if fieldDiff.Status == StatusRedactedDifferent {
+			return SeverityMedium, "secret setting differs (fingerprints do not match)"
+		}
+		if len(missing) > 0 {
+			return SeverityLow, fmt.Sprintf("not set on %s", strings.Join(missing, ", "))
+		}
+		return SeverityLow, fmt.Sprintf("%s setting differs", tool)
+
+	case "repo":
+		if len(missing) > 0 && (name == "head" || name == "branch") {
+			return SeverityMedium, fmt.Sprintf("not a git checkout on %s", strings.Join(missing, ", "))
+		}
+		kind, _, _ := strings.Cut(name, "/")
+		switch kind {
+		case "head":
+			return SeverityMedium, "different commit checked out"
+		case "branch":
+			return SeverityLow, "different branch"
+		case "dirty", "dirty_hash":
+			return SeverityHigh, "uncommitted changes differ"
+		case "untracked":
+			return SeverityMedium, "untracked files differ"
+		case "submodule":
+			if notInitialized := nodesWithValue(fieldDiff, nodes, submoduleNotInitialized); len(notInitialized) > 0 {
+				return SeverityHigh, fmt.Sprintf("submodule not initialized on %s", strings.Join(notInitialized, ", "))
+			}
+			if len(missing) > 0 {
+				return SeverityMedium, fmt.Sprintf("no such submodule on %s", strings.Join(missing, ", "))
+			}
+			return SeverityHigh, "different submodule commit checked out"
+		case "hook":
+			return SeverityMedium, fmt.Sprintf("hook not installed on %s", strings.Join(missing, ", "))
+		case "lfs":
+			if name == "lfs/files" {
+				return SeverityMedium, "different number of LFS files"
+			}
+			return SeverityHigh, "LFS content not fetched everywhere"
+		case "config":
+			return SeverityHigh, "changes how files are checked out"
+		}
+
+	case "filesystem":
+		if len(missing) > 0 {
+			return SeverityLow, fmt.Sprintf("not recorded on %s", strings.Join(missing, ", "))
+		}
+		_, attr, _ := strings.Cut(name, "/")
+		switch attr {
+		case "case_sensitive":
+			return SeverityHigh, "case sensitivity differs"
+		case "options":
+			if changed := changedMountOptions(fieldDiff); len(changed) > 0 {
+				return SeverityHigh, fmt.Sprintf("%s differs", strings.Join(changed, ", "))
+			}
+			return SeverityLow, "mount options differ"
+		case "fs_type":
+			return SeverityMedium, "filesystem type differs"
+		default:
+			return SeverityLow, "mounted elsewhere"
+		}
+
+	case "limits":
+		if len(missing) > 0 {
+			return SeverityLow, fmt.Sprintf("not recorded on %s", strings.Join(missing, ", "))
+		}
+		switch name {
+		case "memory":
+			return SeverityHigh, "effective memory limit differs"
+		case "cpus":
+			return SeverityMedium, "effective CPU limit differs"
+		case "open_files":
+			return SeverityMedium, "open file limit differs"
+		default:
+			return SeverityLow, "limit differs"
+		}
+
+	case "kernel":
+		if len(missing) > 0 {
+			return SeverityLow, fmt.Sprintf("not recorded on %s", strings.Join(missing, ", "))
+		}
+		if reason, ok := kernelParameterReasons[name]; ok {
+			return SeverityHigh, reason
+		}
+		return SeverityMedium, "kernel parameter differs"
+
+	case "network":
+		if strings.HasPrefix(name, "port/") && len(missing) > 0 {
+			return SeverityMedium, fmt.Sprintf("nothing listening on %s", strings.Join(missing, ", "))
+		}
+		return SeverityMedium, "hosts entry differs"
+	}
+
+	return SeverityLow, "value differs"
+}
+
+// kernelParameterReasons explains the kernel parameters whose differences
+// most often break builds, tests or services
+var kernelParameterReasons = map[string]string{
+	"fs.inotify.max_user_watches":  "limits how many files watchers such as webpack can follow",
+	"vm.max_map_count":             "Elasticsearch and other mmap-heavy services need it raised",
+	"vm.overcommit_memory":         "changes when allocations fail",
+	"net.core.somaxconn":           "caps the connection backlog of listening sockets",
+	"net.ipv4.ip_local_port_range": "changes how many outgoing connections can be open",
+}
+
+// missingNodes returns the nodes with no value for a field, sorted
+func missingNodes(fieldDiff *FieldDiff, nodes []string) []string {
+	var missing []string
+	for _, node := range nodes {
+		if fieldDiff.NodeValues[node] == nil {
+			missing = append(missing, node)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// unsetNodes returns the nodes that recorded a field as nil, sorted. Unlike
+// missingNodes it skips nodes left out of the field's values, such as nodes
+// without the tool a toolchain setting belongs to.
+func unsetNodes(fieldDiff *FieldDiff, nodes []string) []string {
+	var unset []string
+	for _, node := range nodes {
+		if value, ok := fieldDiff.NodeValues[node]; ok && value == nil {
+			unset = append(unset, node)
+		}
+	}
+	sort.Strings(unset)
+	return unset
+}
+
+// riskyMountOptions are the mount options that make builds or tests fail
+var riskyMountOptions = []string{"ro", "noexec", "nosuid", "nodev"}
+
+// changedMountOptions returns the risky options set on some nodes but not
+// others, from comma-separated option values
+func changedMountOptions(fieldDiff *FieldDiff) []string {
+	var changed []string
+	for _, option := range riskyMountOptions {
+		set, unset := false, false
+		for _, value := range fieldDiff.NodeValues {
+			options, _ := value.(string)
+			if slices.Contains(strings.Split(options, ","), option) {
+				set = true
+			} else {
+				unset = true
+			}
+		}
+		if set && unset {
+			changed = append(changed, option)
+		}
+	}
+	return changed
+}
+
+// nodesWithValue returns the nodes whose value for a field is value, sorted
+func nodesWithValue(fieldDiff *FieldDiff, nodes []string, value any) []string {
+	var matching []string
+	for _, node := range nodes {
+		if fieldDiff.NodeValues[node] == value {
+			matching = append(matching, node)
+		}
+	}
+	sort.Strings(matching)
+	return matching
+}
+
+func deltaKind(fieldDiff *FieldDiff) DeltaKind {
+	if fieldDiff.Delta == nil {
+		return ""
+	}
+	return fieldDiff.Delta.Kind
+}
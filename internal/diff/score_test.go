@@ -0,0 +1,142 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/GBerghoff/envdiff/internal/snapshot"
+)
+
+func TestScoreField(t *testing.T) {
+	nodes := []string{"local", "ci"}
+	tests := []struct {
+		name     string
+		section  string
+		field    string
+		values   map[string]any
+		expected Severity
+	}{
+		{"arch mismatch", "system", "arch", map[string]any{"local": "arm64", "ci": "amd64"}, SeverityCritical},
+		{"kernel differs", "system", "kernel", map[string]any{"local": "6.1.0", "ci": "5.15.0"}, SeverityLow},
+		{"major runtime bump", "runtime", "node", map[string]any{"local": "18.0.0", "ci": "22.1.0"}, SeverityHigh},
+		{"minor runtime bump", "runtime", "go", map[string]any{"local": "1.21.0", "ci": "1.22.0"}, SeverityMedium},
+		{"patch runtime bump", "runtime", "go", map[string]any{"local": "1.22.0", "ci": "1.22.1"}, SeverityLow},
+		{"missing runtime", "runtime", "docker", map[string]any{"local": "24.0.7", "ci": nil}, SeverityHigh},
+		{"behavior env changed", "env", "NODE_ENV", map[string]any{"local": "development", "ci": "production"}, SeverityHigh},
+		{"hostname noise", "env", "HOSTNAME", map[string]any{"local": "laptop", "ci": "runner-42"}, SeverityLow},
+		{"other env changed", "env", "LOG_LEVEL", map[string]any{"local": "debug", "ci": "info"}, SeverityMedium},
+		{"port not listening", "network", "port/5432", map[string]any{"local": "listening", "ci": nil}, SeverityMedium},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fieldDiff := createFieldDiff(tt.values, nodes)
+			if tt.section == "runtime" {
+				fieldDiff.Delta = versionDelta(tt.values)
+			}
+			got, reason := scoreField(tt.section, tt.field, fieldDiff, nodes)
+			if got != tt.expected {
+				t.Errorf("scoreField(%s.%s) = %q (%s), want %q", tt.section, tt.field, got, reason, tt.expected)
+			}
+			if reason == "" {
+				t.Error("reason should be set")
+			}
+		})
+	}
+}
+
+func TestScoreField_SystemReasons(t *testing.T) {
+	nodes := []string{"local", "ci"}
+	for field, want := range map[string]string{
+		"kernel":    "kernel version differs",
+		"cpu_cores": "host hardware differs",
+		"memory_gb": "host hardware differs",
+		"hostname":  "system detail differs",
+	} {
+		fieldDiff := createFieldDiff(map[string]any{"local": "a", "ci": "b"}, nodes)
+		if _, reason := scoreField("system", field, fieldDiff, nodes); reason != want {
+			t.Errorf("scoreField(system.%s) reason = %q, want %q", field, reason, want)
+		}
+	}
+}
+
+func TestScoreField_ToolchainSkipsNodesWithoutTool(t *testing.T) {
+	with := &snapshot.ToolConfig{Settings: map[string]string{"fund": "false"}}
+	without := &snapshot.ToolConfig{Settings: map[string]string{}}
+	result := Compare(map[string]*snapshot.Snapshot{
+		"local":   {Toolchain: map[string]*snapshot.ToolConfig{snapshot.ToolchainNpm: with}},
+		"ci":      {Toolchain: map[string]*snapshot.ToolConfig{snapshot.ToolchainNpm: without}},
+		"staging": {},
+	}, Options{})
+
+	fieldDiff := result.Diffs["toolchain"]["npm/fund"]
+	if fieldDiff == nil {
+		t.Fatal("npm/fund should be compared between the nodes that have npm")
+	}
+	if fieldDiff.Severity != SeverityLow || fieldDiff.Reason != "not set on ci" {
+		t.Errorf("npm/fund = %q, %q; want low, not set on ci", fieldDiff.Severity, fieldDiff.Reason)
+	}
+}
+
+func TestCompare_SetsSeverity(t *testing.T) {
+	snapshots := map[string]*snapshot.Snapshot{
+		"local": {
+			System:  snapshot.SystemInfo{Arch: "arm64"},
+			Runtime: map[string]*snapshot.RuntimeInfo{},
+			Env:     map[string]string{"NODE_ENV": "development"},
+		},
+		"ci": {
+			System:  snapshot.SystemInfo{Arch: "amd64"},
+			Runtime: map[string]*snapshot.RuntimeInfo{},
+			Env:     map[string]string{"NODE_ENV": "development"},
+		},
+	}
+
+	result := Compare(snapshots, Options{})
+
+	if result.Diffs["system"]["arch"].Severity != SeverityCritical {
+		t.Errorf("arch Severity = %q, want %q", result.Diffs["system"]["arch"].Severity, SeverityCritical)
+	}
+	if result.Diffs["env"]["NODE_ENV"].Severity != SeverityNone {
+		t.Error("equal fields should have no severity")
+	}
+}
+
+func TestTopIssues(t *testing.T) {
+	d := New()
+	d.Diffs["env"] = map[string]*FieldDiff{
+		"HOSTNAME": {Status: StatusDifferent, Severity: SeverityLow},
+		"NODE_ENV": {Status: StatusDifferent, Severity: SeverityHigh},
+		"LOG":      {Status: StatusDifferent, Severity: SeverityMedium},
+	}
+	d.Diffs["system"] = map[string]*FieldDiff{
+		"arch": {Status: StatusDifferent, Severity: SeverityCritical},
+		"os":   {Status: StatusEqual},
+	}
+
+	issues := d.TopIssues(2)
+
+	if len(issues) != 2 {
+		t.Fatalf("len(TopIssues) = %d, want 2", len(issues))
+	}
+	if issues[0].Field != "arch" || issues[1].Field != "NODE_ENV" {
+		t.Errorf("TopIssues = [%s, %s], want [arch, NODE_ENV]", issues[0].Field, issues[1].Field)
+	}
+
+	for _, issue := range d.TopIssues(0) {
+		if issue.Severity == SeverityLow {
+			t.Error("TopIssues should leave out low-severity differences")
+		}
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	if s, err := ParseSeverity("HIGH"); err != nil || s != SeverityHigh {
+		t.Errorf("ParseSeverity(HIGH) = %q, %v", s, err)
+	}
+	if _, err := ParseSeverity("urgent"); err == nil {
+		t.Error("ParseSeverity should reject unknown names")
+	}
+	if _, err := ParseSeverity(""); err == nil {
+		t.Error("ParseSeverity should reject an empty name")
+	}
+}
@@ -0,0 +1,83 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severity ranks how likely a difference is to matter.
+// Equal fields have no severity.
+type Severity string
+
+// Severity levels, from least to most important.
+const (
+	SeverityNone     Severity = ""
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+var severityRanks = map[Severity]int{
+	SeverityNone:     0,
+	SeverityLow:      1,
+	SeverityMedium:   2,
+	SeverityHigh:     3,
+	SeverityCritical: 4,
+}
+
+// Rank orders severities; higher is more important
+func (s Severity) Rank() int {
+	return severityRanks[s]
+}
+
+// AtLeast reports whether s is at least as important as threshold
+func (s Severity) AtLeast(threshold Severity) bool {
+	return s.Rank() >= threshold.Rank()
+}
+
+// ParseSeverity parses a severity name such as "high"
+func ParseSeverity(name string) (Severity, error) {
+	s := Severity(strings.ToLower(strings.TrimSpace(name)))
+	if _, ok := severityRanks[s]; !ok || s == SeverityNone {
+		return SeverityNone, fmt.Errorf("unknown severity %q (use low, medium, high, or critical)", name)
+	}
+	return s, nil
+}
+
+// Issue is a single differing field, located by section and name
+type Issue struct {
+	Section string
+	Field   string
+	*FieldDiff
+}
+
+// TopIssues returns up to limit differing fields of at least medium severity,
+// most important first. These are the differences worth reading first.
+func (d *Diff) TopIssues(limit int) []Issue {
+	var issues []Issue
+	for section, fields := range d.Diffs {
+		for name, fieldDiff := range fields {
+			if fieldDiff.Status.IsEqual() || !fieldDiff.Severity.AtLeast(SeverityMedium) {
+				continue
+			}
+			issues = append(issues, Issue{Section: section, Field: name, FieldDiff: fieldDiff})
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if ri, rj := issues[i].Severity.Rank(), issues[j].Severity.Rank(); ri != rj {
+			return ri > rj
+		}
+		if issues[i].Section != issues[j].Section {
+			return issues[i].Section < issues[j].Section
+		}
+		return issues[i].Field < issues[j].Field
+	})
+
+	if limit > 0 && len(issues) > limit {
+		issues = issues[:limit]
+	}
+	return issues
+}
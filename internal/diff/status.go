@@ -8,4 +8,19 @@ const (
 	StatusEqual     FieldStatus = "equal"
 	StatusDifferent FieldStatus = "different"
 	StatusRedacted  FieldStatus = "redacted"
+
+	// Secret fields redacted with fingerprints from the same salt are
+	// compared by fingerprint without revealing the values
+	StatusRedactedEqual     FieldStatus = "redacted-equal"
+	StatusRedactedDifferent FieldStatus = "redacted-different"
 )
+
+// IsEqual reports whether all nodes had the same value
+func (s FieldStatus) IsEqual() bool {
+	return s == StatusEqual || s == StatusRedactedEqual
+}
+
+// IsDifferent reports whether the nodes' values were compared and differ
+func (s FieldStatus) IsDifferent() bool {
+	return s == StatusDifferent || s == StatusRedactedDifferent
+}
@@ -14,7 +14,10 @@ import (
 )
 
 // CLIRenderer renders output for the terminal
-type CLIRenderer struct{}
+type CLIRenderer struct {
+	// MinSeverity hides differences ranked below it. Equal fields are unaffected.
+	MinSeverity diff.Severity
+}
 
 var (
 	titleStyle = lipgloss.NewStyle().
@@ -32,6 +35,9 @@ var (
 	crossStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color(ui.ColorFail))
 
+	warnStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color(ui.ColorWarn))
+
 	redactedStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color(ui.ColorSecondary))
 
@@ -66,6 +72,7 @@ func (r *CLIRenderer) RenderSnapshot(s *snapshot.Snapshot) string {
 	fmt.Fprintf(&b, "  %s %s\n", keyStyle.Render("kernel"), valueStyle.Render(s.System.Kernel))
 	fmt.Fprintf(&b, "  %s %d cores\n", keyStyle.Render("cpu"), s.System.CPUCores)
 	fmt.Fprintf(&b, "  %s %dGB\n", keyStyle.Render("memory"), s.System.MemoryGB)
+	fmt.Fprintf(&b, "  %s %s\n", keyStyle.Render("collected via"), valueStyle.Render(executionSummary(s)))
 
 	// Runtime info
 	b.WriteString(headerStyle.Render("RUNTIME") + "\n")
@@ -73,10 +80,14 @@ func (r *CLIRenderer) RenderSnapshot(s *snapshot.Snapshot) string {
 	for _, name := range runtimes {
 		info := s.Runtime[name]
 		if info != nil {
-			fmt.Fprintf(&b, "  %s %s %s\n",
+			fmt.Fprintf(&b, "  %s %s %s%s\n",
 				checkStyle.Render("✓"),
 				keyStyle.Render(name),
-				valueStyle.Render(info.Version))
+				valueStyle.Render(info.Version),
+				dimStyle.Render(versionManagerNote(s.VersionManagers[name])))
+			for _, install := range shadowedInstalls(info) {
+				fmt.Fprintf(&b, "      %s\n", dimStyle.Render("shadows "+install))
+			}
 		}
 	}
 
@@ -84,7 +95,7 @@ func (r *CLIRenderer) RenderSnapshot(s *snapshot.Snapshot) string {
 	b.WriteString(headerStyle.Render("ENVIRONMENT") + "\n")
 	redactedCount := 0
 	for _, v := range s.Env {
-		if v == secrets.RedactedValue {
+		if secrets.IsRedacted(v) {
 			redactedCount++
 		}
 	}
@@ -108,9 +119,120 @@ func (r *CLIRenderer) RenderSnapshot(s *snapshot.Snapshot) string {
 		}
 	}
 
+	if s.Project != nil {
+		b.WriteString(headerStyle.Render("PROJECT") + " " + dimStyle.Render(s.Project.Root) + "\n")
+		for _, file := range sortedKeys(s.Project.Lockfiles) {
+			lockfile := s.Project.Lockfiles[file]
+			fmt.Fprintf(&b, "  %s %s %s\n",
+				checkStyle.Render("✓"),
+				keyStyle.Render(file),
+				valueStyle.Render(fmt.Sprintf("%d direct dependencies", len(lockfile.Dependencies))))
+		}
+	}
+
+	if s.Repo != nil {
+		b.WriteString(headerStyle.Render("REPOSITORY") + " " + dimStyle.Render(s.Repo.Root) + "\n")
+		for _, row := range repoRows(s.Repo) {
+			fmt.Fprintf(&b, "  %s %s\n", keyStyle.Render(row[0]), valueStyle.Render(row[1]))
+		}
+	}
+
+	if len(s.Filesystems) > 0 {
+		b.WriteString(headerStyle.Render("FILESYSTEMS") + "\n")
+		for _, role := range snapshot.FilesystemRoles {
+			if mount, ok := s.Filesystems[role]; ok {
+				fmt.Fprintf(&b, "  %s %s\n", keyStyle.Render(role), valueStyle.Render(mountSummary(mount)))
+			}
+		}
+	}
+
+	if s.Limits != nil {
+		b.WriteString(headerStyle.Render("RESOURCE LIMITS") + "\n")
+		for _, row := range limitRows(s.Limits) {
+			fmt.Fprintf(&b, "  %s %s\n", keyStyle.Render(row[0]), valueStyle.Render(row[1]))
+		}
+	}
+
+	if len(s.Sysctl) > 0 {
+		b.WriteString(headerStyle.Render("KERNEL PARAMETERS") + "\n")
+		wideKeyStyle := keyStyle.Width(32) // parameter names outgrow the usual column
+		for _, key := range sortedKeys(s.Sysctl) {
+			fmt.Fprintf(&b, "  %s %s\n", wideKeyStyle.Render(key), valueStyle.Render(s.Sysctl[key]))
+		}
+	}
+
+	// Language package inventories (counts only, they can be long)
+	for _, ecosystem := range snapshot.InventoryEcosystems {
+		if inventory, ok := s.Inventories[ecosystem]; ok {
+			fmt.Fprintf(&b, "%s %s\n",
+				headerStyle.Render(strings.ToUpper(inventoryTitles[ecosystem])),
+				dimStyle.Render(fmt.Sprintf("%d installed", len(inventory))))
+		}
+	}
+
+	// Toolchain configuration (counts only)
+	if len(s.Toolchain) > 0 {
+		b.WriteString(headerStyle.Render("TOOLCHAIN CONFIG") + "\n")
+		for _, tool := range snapshot.ToolchainTools {
+			if cfg, ok := s.Toolchain[tool]; ok {
+				fmt.Fprintf(&b, "  %s %s\n",
+					keyStyle.Render(tool),
+					dimStyle.Render(fmt.Sprintf("%d settings", len(cfg.Settings))))
+			}
+		}
+	}
+
+	// Collection errors
+	if len(s.CollectionErrors) > 0 {
+		b.WriteString(headerStyle.Render("WARNINGS") + "\n")
+		for _, e := range s.CollectionErrors {
+			fmt.Fprintf(&b, "  %s %s %s\n",
+				crossStyle.Render("⚠"),
+				keyStyle.Render(collectionErrorName(e)),
+				dimStyle.Render(e.Message))
+		}
+	}
+
 	return b.String()
 }
 
+// versionManagerNote describes a runtime's version manager and pin,
+// e.g. " (nvm, .nvmrc pins 18)"
+func versionManagerNote(info *snapshot.VersionManagerInfo) string {
+	if info == nil {
+		return ""
+	}
+	var parts []string
+	if info.Manager != "" {
+		parts = append(parts, info.Manager)
+	}
+	if info.Pinned != "" {
+		parts = append(parts, info.PinFile+" pins "+info.Pinned)
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// shadowedInstalls describes the installs hidden behind the winning one,
+// e.g. "/usr/bin/python3 (3.11.2)"
+func shadowedInstalls(info *snapshot.RuntimeInfo) []string {
+	if len(info.Installations) < 2 {
+		return nil
+	}
+	installs := make([]string, 0, len(info.Installations)-1)
+	for _, install := range info.Installations[1:] {
+		installs = append(installs, fmt.Sprintf("%s (%s)", install.Path, install.Version))
+	}
+	return installs
+}
+
+// collectionErrorName formats a collection error as "collector/probe"
+func collectionErrorName(e snapshot.CollectionError) string {
+	if e.Probe == "" {
+		return e.Collector
+	}
+	return e.Collector + "/" + e.Probe
+}
+
 // RenderDiff renders a diff for terminal display
 func (r *CLIRenderer) RenderDiff(d *diff.Diff) string {
 	var b strings.Builder
@@ -119,89 +241,141 @@ func (r *CLIRenderer) RenderDiff(d *diff.Diff) string {
 	nodeList := strings.Join(d.Nodes, " ↔ ")
 	b.WriteString(titleStyle.Render("envdiff") + " — comparing " + nodeList + "\n")
 
+	// Where each snapshot was taken, when any came from CI, a container or a VM
+	if hasExecutionContext(d) {
+		for _, node := range d.Nodes {
+			s := d.Snapshots[node]
+			if s == nil {
+				continue
+			}
+			fmt.Fprintf(&b, "  %s %s\n", keyStyle.Render(node), dimStyle.Render(executionSummary(s)))
+			if s.ExecutionContext != nil && s.ExecutionContext.JobURL != "" {
+				fmt.Fprintf(&b, "  %s %s\n", keyStyle.Render(""), dimStyle.Render(s.ExecutionContext.JobURL))
+			}
+		}
+	}
+
 	// Errors
 	if len(d.Errors) > 0 {
-		for node, err := range d.Errors {
-			fmt.Fprintf(&b, "  %s %s: %s\n", crossStyle.Render("⚠"), node, err)
+		nodes := make([]string, 0, len(d.Errors))
+		for node := range d.Errors {
+			nodes = append(nodes, node)
+		}
+		sort.Strings(nodes)
+		for _, node := range nodes {
+			fmt.Fprintf(&b, "  %s %s: %s\n", crossStyle.Render("⚠"), node, d.Errors[node])
 		}
 		b.WriteString("\n")
 	}
 
+	// Top issues
+	if issues := topIssues(d, r.hidden); len(issues) > 0 {
+		b.WriteString(headerStyle.Render("TOP ISSUES") + "\n")
+		for _, issue := range issues {
+			fmt.Fprintf(&b, "  %s %s %s\n",
+				severityStyle(issue.Severity).Render(severityLabel(issue.Severity)),
+				keyStyle.Render(issue.Section+"."+issue.Field),
+				dimStyle.Render(issue.Reason))
+		}
+	}
+
 	// Runtime diffs
 	if len(d.Diffs["runtime"]) > 0 {
 		b.WriteString(headerStyle.Render("RUNTIME") + "\n")
 		runtimes := sortedMapKeys(d.Diffs["runtime"])
 		for _, name := range runtimes {
 			fieldDiff := d.Diffs["runtime"][name]
-			b.WriteString(r.renderFieldDiff(name, fieldDiff, d.Nodes))
-		}
-	}
-
-	// Package diffs
-	if len(d.Diffs["package"]) > 0 {
-		b.WriteString(headerStyle.Render("PACKAGES") + "\n")
-		pkgs := sortedMapKeys(d.Diffs["package"])
-		for _, name := range pkgs {
-			fieldDiff := d.Diffs["package"][name]
-			b.WriteString(r.renderFieldDiff(name, fieldDiff, d.Nodes))
-		}
-	}
-
-	// Environment diffs
-	if len(d.Diffs["env"]) > 0 {
-		b.WriteString(headerStyle.Render("ENVIRONMENT") + "\n")
-		// Only show different or redacted fields
-		envKeys := sortedMapKeys(d.Diffs["env"])
-		shownCount := 0
-		equalCount := 0
-		for _, name := range envKeys {
-			fieldDiff := d.Diffs["env"][name]
-			if fieldDiff.Status != diff.StatusEqual {
-				b.WriteString(r.renderFieldDiff(name, fieldDiff, d.Nodes))
-				shownCount++
-			} else {
-				equalCount++
+			if r.hidden(fieldDiff) {
+				continue
 			}
-		}
-		if equalCount > 0 {
-			fmt.Fprintf(&b, "  %s %d variables match\n", checkStyle.Render("✓"), equalCount)
+			b.WriteString(r.renderFieldDiff(name, fieldDiff, d.Nodes))
 		}
 	}
 
-	// System diffs
-	if len(d.Diffs["system"]) > 0 {
-		b.WriteString(headerStyle.Render("SYSTEM") + "\n")
-		systemKeys := sortedMapKeys(d.Diffs["system"])
-		equalCount := 0
-		for _, name := range systemKeys {
-			fieldDiff := d.Diffs["system"][name]
-			if fieldDiff.Status != diff.StatusEqual {
-				b.WriteString(r.renderFieldDiff(name, fieldDiff, d.Nodes))
-			} else {
-				equalCount++
-			}
-		}
-		if equalCount > 0 {
-			fmt.Fprintf(&b, "  %s %d fields match\n", checkStyle.Render("✓"), equalCount)
-		}
+	// Full inventories (--packages=all) run to thousands of entries, so
+	// sections only list differences and count the fields that match
+	b.WriteString(r.renderSection("PACKAGES", d.Diffs["package"], d.Nodes, "packages"))
+	b.WriteString(r.renderSection("ENVIRONMENT", d.Diffs["env"], d.Nodes, "variables"))
+	b.WriteString(r.renderSection("SYSTEM", d.Diffs["system"], d.Nodes, "fields"))
+	b.WriteString(r.renderSection("NETWORK", d.Diffs["network"], d.Nodes, "entries"))
+	b.WriteString(r.renderSection("PROJECT", d.Diffs["project"], d.Nodes, "entries"))
+	b.WriteString(r.renderSection("REPOSITORY", d.Diffs["repo"], d.Nodes, "fields"))
+	b.WriteString(r.renderSection("FILESYSTEMS", d.Diffs["filesystem"], d.Nodes, "fields"))
+	b.WriteString(r.renderSection("RESOURCE LIMITS", d.Diffs["limits"], d.Nodes, "limits"))
+	b.WriteString(r.renderSection("KERNEL PARAMETERS", d.Diffs["kernel"], d.Nodes, "parameters"))
+	b.WriteString(r.renderSection("TOOLCHAIN CONFIG", d.Diffs["toolchain"], d.Nodes, "settings"))
+	for _, ecosystem := range snapshot.InventoryEcosystems {
+		b.WriteString(r.renderSection(strings.ToUpper(inventoryTitles[ecosystem]), d.Diffs[ecosystem], d.Nodes, "packages"))
 	}
 
 	// Summary
 	b.WriteString("\n")
 	b.WriteString(dividerStyle.Render(strings.Repeat("─", 40)) + "\n")
-	fmt.Fprintf(&b, "%d different · %d equal · %d redacted\n",
+	fmt.Fprintf(&b, "%d different · %d equal · %d redacted",
 		d.Summary.Different, d.Summary.Equal, d.Summary.Redacted)
+	if d.Summary.Ignored > 0 {
+		fmt.Fprintf(&b, " · %d ignored", d.Summary.Ignored)
+	}
+	b.WriteString("\n")
 
 	if d.Summary.Redacted > 0 {
-		b.WriteString(dimStyle.Render("\nNote: Redacted values not compared. Use --no-redact to include.") + "\n")
+		b.WriteString(dimStyle.Render("\nNote: Redacted values not compared. Snapshot with --redact-mode hmac to compare them by fingerprint.") + "\n")
 	}
 
 	return b.String()
 }
 
+// renderSection lists a diff section's differing fields, followed by the
+// number of fields that match, counted as noun ("3 packages match"). Empty
+// sections render nothing.
+func (r *CLIRenderer) renderSection(title string, fields map[string]*diff.FieldDiff, nodes []string, noun string) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(headerStyle.Render(title) + "\n")
+	equalCount := 0
+	for _, name := range sortedMapKeys(fields) {
+		fieldDiff := fields[name]
+		if r.hidden(fieldDiff) {
+			continue
+		}
+		if !fieldDiff.Status.IsEqual() {
+			b.WriteString(r.renderFieldDiff(name, fieldDiff, nodes))
+		} else {
+			equalCount++
+		}
+	}
+	if equalCount > 0 {
+		fmt.Fprintf(&b, "  %s %d %s match\n", checkStyle.Render("✓"), equalCount, noun)
+	}
+	return b.String()
+}
+
+// hidden reports whether a difference ranks below the severity filter
+func (r *CLIRenderer) hidden(fieldDiff *diff.FieldDiff) bool {
+	return !fieldDiff.Status.IsEqual() && !fieldDiff.Severity.AtLeast(r.MinSeverity)
+}
+
+func severityStyle(s diff.Severity) lipgloss.Style {
+	switch s {
+	case diff.SeverityCritical, diff.SeverityHigh:
+		return crossStyle
+	case diff.SeverityMedium:
+		return warnStyle
+	default:
+		return dimStyle
+	}
+}
+
+// severityLabel pads severity names so the issue list lines up
+func severityLabel(s diff.Severity) string {
+	return fmt.Sprintf("%-8s", strings.ToUpper(string(s)))
+}
+
 func (r *CLIRenderer) renderFieldDiff(name string, fieldDiff *diff.FieldDiff, nodes []string) string {
 	switch fieldDiff.Status {
-	case diff.StatusEqual:
+	case diff.StatusEqual, diff.StatusRedactedEqual:
 		val := formatValue(fieldDiff.NodeValues[nodes[0]])
 		return fmt.Sprintf("  %s %s %s\n",
 			checkStyle.Render("✓"),
@@ -214,16 +388,20 @@ func (r *CLIRenderer) renderFieldDiff(name string, fieldDiff *diff.FieldDiff, no
 			keyStyle.Render(name),
 			redactedStyle.Render(secrets.RedactedValue))
 
-	case diff.StatusDifferent:
+	case diff.StatusDifferent, diff.StatusRedactedDifferent:
+		if fieldDiff.List != nil && len(listNodes(fieldDiff.List, nodes)) > 0 {
+			return r.renderListDiff(name, fieldDiff.List, nodes)
+		}
 		if len(nodes) == 2 {
 			// Two-node diff: show "val1 → val2"
 			val1 := formatValue(fieldDiff.NodeValues[nodes[0]])
 			val2 := formatValue(fieldDiff.NodeValues[nodes[1]])
-			return fmt.Sprintf("  %s %s %s → %s\n",
+			return fmt.Sprintf("  %s %s %s → %s%s\n",
 				crossStyle.Render("✗"),
 				keyStyle.Render(name),
 				valueStyle.Render(val1),
-				valueStyle.Render(val2))
+				valueStyle.Render(val2),
+				deltaMarker(fieldDiff.Delta, nodes))
 		} else {
 			// Multi-node diff: show outliers
 			var line strings.Builder
@@ -249,6 +427,7 @@ func (r *CLIRenderer) renderFieldDiff(name string, fieldDiff *diff.FieldDiff, no
 				}
 				line.WriteString(dimStyle.Render(strings.Join(values, ", ")))
 			}
+			line.WriteString(deltaMarker(fieldDiff.Delta, nodes))
 			line.WriteString("\n")
 			return line.String()
 		}
@@ -256,14 +435,89 @@ func (r *CLIRenderer) renderFieldDiff(name string, fieldDiff *diff.FieldDiff, no
 	return ""
 }
 
+// renderListDiff shows a list variable such as PATH entry by entry rather
+// than as two long strings: a summary line, then each added, removed or
+// moved entry with its position, and entries missing on disk
+func (r *CLIRenderer) renderListDiff(name string, list *diff.ListDiff, nodes []string) string {
+	var b strings.Builder
+
+	var changed []string
+	for _, node := range nodes {
+		if len(list.Changes[node]) > 0 {
+			changed = append(changed, node)
+		}
+	}
+	summary := "entries differ"
+	switch {
+	case len(nodes) == 2 && len(changed) == 1:
+		summary = fmt.Sprintf("%s (%s → %s)", listSummary(list.Changes[changed[0]]), list.Baseline, changed[0])
+	case len(changed) > 0:
+		summary = fmt.Sprintf("differs from %s on %s", list.Baseline, strings.Join(changed, ", "))
+	}
+	fmt.Fprintf(&b, "  %s %s %s\n",
+		crossStyle.Render("✗"),
+		keyStyle.Render(name),
+		dimStyle.Render(summary))
+
+	markers := map[diff.ListChangeKind]string{
+		diff.ListAdded:   checkStyle.Render("+"),
+		diff.ListRemoved: crossStyle.Render("-"),
+		diff.ListMoved:   warnStyle.Render("↕"),
+	}
+	for _, node := range listNodes(list, nodes) {
+		indent := "      "
+		if len(nodes) > 2 {
+			fmt.Fprintf(&b, "      %s\n", dimStyle.Render(node+":"))
+			indent += "  "
+		}
+		for _, change := range list.Changes[node] {
+			fmt.Fprintf(&b, "%s%s %s %s\n", indent, markers[change.Kind],
+				valueStyle.Render(change.Entry), dimStyle.Render(listPosition(change)))
+		}
+		for _, entry := range list.Missing[node] {
+			fmt.Fprintf(&b, "%s%s %s %s\n", indent, warnStyle.Render("!"),
+				valueStyle.Render(entry), dimStyle.Render("not on disk on "+node))
+		}
+	}
+	return b.String()
+}
+
+// deltaMarker renders a version delta as " ↑ minor" (the second node is
+// newer) or " ↓ major" (the first node is newer). With more than two nodes
+// it names the node with the newest version instead.
+func deltaMarker(delta *diff.VersionDelta, nodes []string) string {
+	if delta == nil || delta.Kind == diff.DeltaUnparseable {
+		return ""
+	}
+	if delta.Newer == "" {
+		return dimStyle.Render(" ~ " + string(delta.Kind))
+	}
+	if len(nodes) != 2 {
+		return dimStyle.Render(fmt.Sprintf(" (%s, newest on %s)", delta.Kind, delta.Newer))
+	}
+	arrow := "↑"
+	if delta.Newer == nodes[0] {
+		arrow = "↓"
+	}
+	style := dimStyle
+	if delta.Kind == diff.DeltaMajor {
+		style = warnStyle
+	}
+	return style.Render(fmt.Sprintf(" %s %s", arrow, delta.Kind))
+}
+
 func formatValue(v any) string {
 	if v == nil {
 		return "(missing)"
 	}
-	return fmt.Sprintf("%v", v)
+	s := fmt.Sprintf("%v", v)
+	if _, mac, ok := secrets.ParseFingerprint(s); ok {
+		return "[REDACTED #" + mac[:8] + "]"
+	}
+	return s
 }
 
-func sortedKeys[V any](m map[string]*V) []string {
+func sortedKeys[V any](m map[string]V) []string {
 	keys := make([]string, 0, len(m))
 	for k := range m {
 		keys = append(keys, k)
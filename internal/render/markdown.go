@@ -11,7 +11,10 @@ import (
 )
 
 // MarkdownRenderer renders output as Markdown
-type MarkdownRenderer struct{}
+type MarkdownRenderer struct {
+	// MinSeverity hides differences ranked below it
+	MinSeverity diff.Severity
+}
 
 // NewMarkdown creates a new Markdown renderer
 func NewMarkdown() *MarkdownRenderer {
@@ -25,7 +28,7 @@ func (r *MarkdownRenderer) RenderSnapshot(s *snapshot.Snapshot) string {
 	b.WriteString("# Environment Snapshot\n\n")
 	fmt.Fprintf(&b, "**Host:** %s  \n", s.Hostname)
 	fmt.Fprintf(&b, "**Timestamp:** %s  \n", s.Timestamp)
-	fmt.Fprintf(&b, "**Collected via:** %s\n\n", s.CollectedVia)
+	fmt.Fprintf(&b, "**Collected via:** %s\n\n", r.collectedVia(s))
 
 	// System
 	b.WriteString("## System\n\n")
@@ -40,22 +43,35 @@ func (r *MarkdownRenderer) RenderSnapshot(s *snapshot.Snapshot) string {
 
 	// Runtime
 	b.WriteString("## Runtime\n\n")
-	b.WriteString("| Tool | Version | Path |\n")
-	b.WriteString("|------|---------|------|\n")
+	b.WriteString("| Tool | Version | Path | Shadows |\n")
+	b.WriteString("|------|---------|------|---------|\n")
 	runtimes := sortedKeys(s.Runtime)
 	for _, name := range runtimes {
 		info := s.Runtime[name]
 		if info != nil {
-			fmt.Fprintf(&b, "| %s | %s | %s |\n", name, info.Version, info.Path)
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", name, info.Version, info.Path, strings.Join(shadowedInstalls(info), ", "))
 		}
 	}
 	b.WriteString("\n")
 
+	// Version managers
+	if len(s.VersionManagers) > 0 {
+		b.WriteString("## Version Managers\n\n")
+		b.WriteString("| Runtime | Manager | Pinned | Pin file | Installed |\n")
+		b.WriteString("|---------|---------|--------|----------|-----------|\n")
+		for _, name := range sortedKeys(s.VersionManagers) {
+			info := s.VersionManagers[name]
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+				name, info.Manager, info.Pinned, info.PinFile, strings.Join(info.Installed, ", "))
+		}
+		b.WriteString("\n")
+	}
+
 	// Environment (summary only)
 	b.WriteString("## Environment\n\n")
 	redactedCount := 0
 	for _, v := range s.Env {
-		if v == secrets.RedactedValue {
+		if secrets.IsRedacted(v) {
 			redactedCount++
 		}
 	}
@@ -79,6 +95,106 @@ func (r *MarkdownRenderer) RenderSnapshot(s *snapshot.Snapshot) string {
 		b.WriteString("\n")
 	}
 
+	// Project lockfiles
+	if s.Project != nil {
+		b.WriteString("## Project\n\n")
+		fmt.Fprintf(&b, "**Root:** %s\n\n", s.Project.Root)
+		b.WriteString("| Lockfile | SHA-256 | Direct dependencies |\n")
+		b.WriteString("|----------|---------|---------------------|\n")
+		for _, file := range sortedKeys(s.Project.Lockfiles) {
+			lockfile := s.Project.Lockfiles[file]
+			fmt.Fprintf(&b, "| %s | `%.12s` | %d |\n", file, lockfile.SHA256, len(lockfile.Dependencies))
+		}
+		b.WriteString("\n")
+	}
+
+	// Git checkout
+	if s.Repo != nil {
+		b.WriteString("## Repository\n\n")
+		fmt.Fprintf(&b, "**Root:** %s\n\n", s.Repo.Root)
+		b.WriteString("| Field | Value |\n")
+		b.WriteString("|-------|-------|\n")
+		for _, row := range repoRows(s.Repo) {
+			fmt.Fprintf(&b, "| %s | %s |\n", row[0], row[1])
+		}
+		b.WriteString("\n")
+	}
+
+	// Filesystems
+	if len(s.Filesystems) > 0 {
+		b.WriteString("## Filesystems\n\n")
+		b.WriteString("| Role | Path | Filesystem |\n")
+		b.WriteString("|------|------|------------|\n")
+		for _, role := range snapshot.FilesystemRoles {
+			if mount, ok := s.Filesystems[role]; ok {
+				fmt.Fprintf(&b, "| %s | %s | %s |\n", role, mount.Path, mountSummary(mount))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	// Resource limits
+	if s.Limits != nil {
+		b.WriteString("## Resource Limits\n\n")
+		b.WriteString("| Limit | Value |\n")
+		b.WriteString("|-------|-------|\n")
+		for _, row := range limitRows(s.Limits) {
+			fmt.Fprintf(&b, "| %s | %s |\n", row[0], row[1])
+		}
+		b.WriteString("\n")
+	}
+
+	// Kernel parameters
+	if len(s.Sysctl) > 0 {
+		b.WriteString("## Kernel Parameters\n\n")
+		b.WriteString("| Parameter | Value |\n")
+		b.WriteString("|-----------|-------|\n")
+		for _, key := range sortedKeys(s.Sysctl) {
+			fmt.Fprintf(&b, "| %s | %s |\n", key, s.Sysctl[key])
+		}
+		b.WriteString("\n")
+	}
+
+	// Language package inventories (counts only, they can be long)
+	var inventories []string
+	for _, ecosystem := range snapshot.InventoryEcosystems {
+		if inventory, ok := s.Inventories[ecosystem]; ok {
+			inventories = append(inventories, fmt.Sprintf("| %s | %d |\n", inventoryTitles[ecosystem], len(inventory)))
+		}
+	}
+	if len(inventories) > 0 {
+		b.WriteString("## Package Inventories\n\n")
+		b.WriteString("| Inventory | Installed |\n")
+		b.WriteString("|-----------|-----------|\n")
+		b.WriteString(strings.Join(inventories, ""))
+		b.WriteString("\n")
+	}
+
+	// Toolchain configuration (counts only)
+	if len(s.Toolchain) > 0 {
+		b.WriteString("## Toolchain Configuration\n\n")
+		b.WriteString("| Tool | Settings |\n")
+		b.WriteString("|------|----------|\n")
+		for _, tool := range snapshot.ToolchainTools {
+			if cfg, ok := s.Toolchain[tool]; ok {
+				fmt.Fprintf(&b, "| %s | %d |\n", tool, len(cfg.Settings))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	// Collection errors
+	if len(s.CollectionErrors) > 0 {
+		b.WriteString("## Warnings\n\n")
+		b.WriteString("| Probe | Command | Exit | Message |\n")
+		b.WriteString("|-------|---------|------|---------|\n")
+		for _, e := range s.CollectionErrors {
+			fmt.Fprintf(&b, "| %s | %s | %d | %s |\n",
+				collectionErrorName(e), e.Command, e.ExitCode, e.Message)
+		}
+		b.WriteString("\n")
+	}
+
 	return b.String()
 }
 
@@ -88,26 +204,40 @@ func (r *MarkdownRenderer) RenderDiff(d *diff.Diff) string {
 
 	b.WriteString("# Environment Diff\n\n")
 	fmt.Fprintf(&b, "**Generated:** %s  \n", d.GeneratedAt)
-	fmt.Fprintf(&b, "**Nodes:** %d | **Different:** %d\n\n", len(d.Nodes), d.Summary.Different)
+	fmt.Fprintf(&b, "**Nodes:** %d | **Different:** %d", len(d.Nodes), d.Summary.Different)
+	if d.Summary.Ignored > 0 {
+		fmt.Fprintf(&b, " | **Ignored:** %d", d.Summary.Ignored)
+	}
+	b.WriteString("\n\n")
 
 	// Summary table
 	b.WriteString("## Summary\n\n")
-	b.WriteString("| Node | Status | Issues |\n")
-	b.WriteString("|------|--------|--------|\n")
+	b.WriteString("| Node | Collected via | Status | Issues |\n")
+	b.WriteString("|------|---------------|--------|--------|\n")
 	for _, node := range d.Nodes {
+		via := r.collectedVia(d.Snapshots[node])
 		if err, ok := d.Errors[node]; ok {
-			fmt.Fprintf(&b, "| %s | ❌ error | %s |\n", node, err)
+			fmt.Fprintf(&b, "| %s | %s | ⚠ incomplete | %s |\n", node, via, err)
 		} else {
 			issues := r.getNodeIssues(d, node)
 			if len(issues) == 0 {
-				fmt.Fprintf(&b, "| %s | ✓ ok | — |\n", node)
+				fmt.Fprintf(&b, "| %s | %s | ✓ ok | — |\n", node, via)
 			} else {
-				fmt.Fprintf(&b, "| %s | ⚠ differs | %s |\n", node, strings.Join(issues, ", "))
+				fmt.Fprintf(&b, "| %s | %s | ⚠ differs | %s |\n", node, via, strings.Join(issues, ", "))
 			}
 		}
 	}
 	b.WriteString("\n")
 
+	// Top issues
+	if issues := topIssues(d, r.hidden); len(issues) > 0 {
+		b.WriteString("## Top Issues\n\n")
+		for i, issue := range issues {
+			fmt.Fprintf(&b, "%d. **%s** `%s.%s` — %s\n", i+1, issue.Severity, issue.Section, issue.Field, issue.Reason)
+		}
+		b.WriteString("\n")
+	}
+
 	// Runtime table
 	if r.hasAnyDifferent(d.Diffs["runtime"]) {
 		b.WriteString("## Runtime\n\n")
@@ -132,15 +262,77 @@ func (r *MarkdownRenderer) RenderDiff(d *diff.Diff) string {
 		b.WriteString(r.renderComparisonTable(d, "system"))
 	}
 
+	// Network table
+	if r.hasAnyDifferent(d.Diffs["network"]) {
+		b.WriteString("## Network\n\n")
+		b.WriteString(r.renderComparisonTable(d, "network"))
+	}
+
+	// Project lockfile table
+	if r.hasAnyDifferent(d.Diffs["project"]) {
+		b.WriteString("## Project\n\n")
+		b.WriteString(r.renderComparisonTable(d, "project"))
+	}
+
+	// Git checkout table
+	if r.hasAnyDifferent(d.Diffs["repo"]) {
+		b.WriteString("## Repository\n\n")
+		b.WriteString(r.renderComparisonTable(d, "repo"))
+	}
+
+	// Filesystem table
+	if r.hasAnyDifferent(d.Diffs["filesystem"]) {
+		b.WriteString("## Filesystems\n\n")
+		b.WriteString(r.renderComparisonTable(d, "filesystem"))
+	}
+
+	// Resource limits table
+	if r.hasAnyDifferent(d.Diffs["limits"]) {
+		b.WriteString("## Resource Limits\n\n")
+		b.WriteString(r.renderComparisonTable(d, "limits"))
+	}
+
+	// Kernel parameters table
+	if r.hasAnyDifferent(d.Diffs["kernel"]) {
+		b.WriteString("## Kernel Parameters\n\n")
+		b.WriteString(r.renderComparisonTable(d, "kernel"))
+	}
+
+	// Toolchain configuration table
+	if r.hasAnyDifferent(d.Diffs["toolchain"]) {
+		b.WriteString("## Toolchain Configuration\n\n")
+		b.WriteString(r.renderComparisonTable(d, "toolchain"))
+	}
+
+	// Language package inventory tables
+	for _, ecosystem := range snapshot.InventoryEcosystems {
+		if r.hasAnyDifferent(d.Diffs[ecosystem]) {
+			b.WriteString("## " + inventoryTitles[ecosystem] + "\n\n")
+			b.WriteString(r.renderComparisonTable(d, ecosystem))
+		}
+	}
+
 	return b.String()
 }
 
+// collectedVia describes where a snapshot was taken, linking to its CI job
+func (r *MarkdownRenderer) collectedVia(s *snapshot.Snapshot) string {
+	if s == nil {
+		return "—"
+	}
+	via := executionSummary(s)
+	if s.ExecutionContext != nil && s.ExecutionContext.JobURL != "" {
+		via += fmt.Sprintf(" ([job](%s))", s.ExecutionContext.JobURL)
+	}
+	return via
+}
+
 func (r *MarkdownRenderer) getNodeIssues(d *diff.Diff, node string) []string {
 	var issues []string
 
 	for section, fields := range d.Diffs {
 		for name, fieldDiff := range fields {
-			if fieldDiff.Status == diff.StatusDifferent {
+			if fieldDiff.Status.IsDifferent() && !r.hidden(fieldDiff) {
 				// Check if this node is an outlier
 				for _, outlier := range fieldDiff.Outliers {
 					if outlier == node {
@@ -162,9 +354,17 @@ func (r *MarkdownRenderer) getNodeIssues(d *diff.Diff, node string) []string {
 	return issues
 }
 
+// hidden reports whether a difference ranks below the severity filter
+func (r *MarkdownRenderer) hidden(fieldDiff *diff.FieldDiff) bool {
+	return !fieldDiff.Status.IsEqual() && !fieldDiff.Severity.AtLeast(r.MinSeverity)
+}
+
 func (r *MarkdownRenderer) hasAnyDifferent(fields map[string]*diff.FieldDiff) bool {
 	for _, fieldDiff := range fields {
-		if fieldDiff.Status == diff.StatusDifferent || fieldDiff.Status == diff.StatusRedacted {
+		if r.hidden(fieldDiff) {
+			continue
+		}
+		if fieldDiff.Status.IsDifferent() || fieldDiff.Status == diff.StatusRedacted {
 			return true
 		}
 	}
@@ -194,14 +394,24 @@ func (r *MarkdownRenderer) renderComparisonTable(d *diff.Diff, section string) s
 	b.WriteString("\n")
 
 	// Data rows (only different/redacted fields)
+	var lists []string
 	keys := sortedMapKeys(fields)
 	for _, name := range keys {
 		fieldDiff := fields[name]
-		if fieldDiff.Status == diff.StatusEqual {
+		if fieldDiff.Status.IsEqual() || r.hidden(fieldDiff) {
 			continue
 		}
 
 		fmt.Fprintf(&b, "| %s |", name)
+		if fieldDiff.List != nil && len(listNodes(fieldDiff.List, d.Nodes)) > 0 {
+			// The entries are listed below the table
+			for _, node := range d.Nodes {
+				fmt.Fprintf(&b, " %s |", markdownListCell(fieldDiff, node))
+			}
+			b.WriteString("\n")
+			lists = append(lists, name)
+			continue
+		}
 		for _, node := range d.Nodes {
 			val := formatMarkdownValue(fieldDiff.NodeValues[node])
 			// Bold outliers
@@ -212,7 +422,7 @@ func (r *MarkdownRenderer) renderComparisonTable(d *diff.Diff, section string) s
 					break
 				}
 			}
-			if isOutlier || (fieldDiff.Status == diff.StatusDifferent && len(d.Nodes) == 2) {
+			if isOutlier || (fieldDiff.Status.IsDifferent() && len(d.Nodes) == 2) {
 				val = fmt.Sprintf("**%s**", val)
 			}
 			fmt.Fprintf(&b, " %s |", val)
@@ -221,6 +431,43 @@ func (r *MarkdownRenderer) renderComparisonTable(d *diff.Diff, section string) s
 	}
 	b.WriteString("\n")
 
+	for _, name := range lists {
+		b.WriteString(renderMarkdownList(name, fields[name].List, d.Nodes))
+	}
+
+	return b.String()
+}
+
+// markdownListCell summarizes a node's entries of a list variable for the
+// comparison table
+func markdownListCell(fieldDiff *diff.FieldDiff, node string) string {
+	list := fieldDiff.List
+	switch {
+	case fieldDiff.NodeValues[node] == nil:
+		return "—"
+	case node == list.Baseline:
+		return "_baseline_"
+	case len(list.Changes[node]) > 0:
+		return "**" + listSummary(list.Changes[node]) + "**"
+	default:
+		return "same entries"
+	}
+}
+
+// renderMarkdownList lists the changed and missing entries of a list
+// variable such as PATH relative to the baseline node
+func renderMarkdownList(name string, list *diff.ListDiff, nodes []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s** compared with `%s`:\n\n", name, list.Baseline)
+	for _, node := range listNodes(list, nodes) {
+		for _, change := range list.Changes[node] {
+			fmt.Fprintf(&b, "- %s: %s `%s` (%s)\n", node, change.Kind, change.Entry, listPosition(change))
+		}
+		for _, entry := range list.Missing[node] {
+			fmt.Fprintf(&b, "- %s: `%s` not on disk\n", node, entry)
+		}
+	}
+	b.WriteString("\n")
 	return b.String()
 }
 
@@ -229,6 +476,9 @@ func formatMarkdownValue(v any) string {
 		return "—"
 	}
 	s := fmt.Sprintf("%v", v)
+	if _, mac, ok := secrets.ParseFingerprint(s); ok {
+		return "🔒 `" + mac[:8] + "`"
+	}
 	if s == secrets.RedactedValue {
 		return "🔒"
 	}
@@ -3,6 +3,11 @@
 package render
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/GBerghoff/envdiff/internal/config"
 	"github.com/GBerghoff/envdiff/internal/diff"
 	"github.com/GBerghoff/envdiff/internal/snapshot"
 )
@@ -12,3 +17,206 @@ type Renderer interface {
 	RenderSnapshot(s *snapshot.Snapshot) string
 	RenderDiff(d *diff.Diff) string
 }
+
+// inventoryTitles names each language package inventory for section headers
+var inventoryTitles = map[string]string{
+	snapshot.InventoryPip:   "Python packages (pip)",
+	snapshot.InventoryNpm:   "Global npm packages",
+	snapshot.InventoryGem:   "Ruby gems",
+	snapshot.InventoryCargo: "Cargo installs",
+	snapshot.InventoryGoBin: "Go binaries",
+}
+
+// topIssueLimit is how many differences the headline block shows
+const topIssueLimit = 3
+
+// topIssues returns the highest-ranked differences that the severity filter
+// keeps, up to topIssueLimit
+func topIssues(d *diff.Diff, hidden func(*diff.FieldDiff) bool) []diff.Issue {
+	var issues []diff.Issue
+	for _, issue := range d.TopIssues(0) {
+		if hidden(issue.FieldDiff) {
+			continue
+		}
+		if issues = append(issues, issue); len(issues) == topIssueLimit {
+			break
+		}
+	}
+	return issues
+}
+
+// listSummary counts a node's changes to a list variable,
+// e.g. "2 added, 1 removed, 1 moved"
+func listSummary(changes []diff.ListChange) string {
+	counts := make(map[diff.ListChangeKind]int)
+	for _, change := range changes {
+		counts[change.Kind]++
+	}
+	var parts []string
+	for _, kind := range []diff.ListChangeKind{diff.ListAdded, diff.ListRemoved, diff.ListMoved} {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[kind], kind))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// listPosition describes where a changed entry sits, using indexes into
+// the list: "at 0" when added, "was 4" when removed, "3 → 1" when moved
+func listPosition(change diff.ListChange) string {
+	switch change.Kind {
+	case diff.ListAdded:
+		return fmt.Sprintf("at %d", change.To)
+	case diff.ListRemoved:
+		return fmt.Sprintf("was %d", change.From)
+	default:
+		return fmt.Sprintf("%d → %d", change.From, change.To)
+	}
+}
+
+// listNodes returns the nodes with changed or missing entries, in node order
+func listNodes(list *diff.ListDiff, nodes []string) []string {
+	var shown []string
+	for _, node := range nodes {
+		if len(list.Changes[node]) > 0 || len(list.Missing[node]) > 0 {
+			shown = append(shown, node)
+		}
+	}
+	return shown
+}
+
+// repoRows describes a git checkout as label/value pairs, in display order
+func repoRows(repo *snapshot.RepoInfo) [][2]string {
+	head := repo.Head
+	if len(head) > 12 {
+		head = head[:12]
+	}
+	switch {
+	case head == "":
+		head = "no commits"
+	case repo.Branch != "":
+		head = repo.Branch + " @ " + head
+	default:
+		head = "detached @ " + head
+	}
+	changes := "clean"
+	if repo.Dirty > 0 || repo.Untracked > 0 {
+		changes = fmt.Sprintf("%d changed, %d untracked", repo.Dirty, repo.Untracked)
+	}
+	rows := [][2]string{{"head", head}, {"changes", changes}}
+
+	if len(repo.Submodules) > 0 {
+		notInitialized := 0
+		for _, commit := range repo.Submodules {
+			if commit == "" {
+				notInitialized++
+			}
+		}
+		submodules := fmt.Sprintf("%d", len(repo.Submodules))
+		if notInitialized > 0 {
+			submodules += fmt.Sprintf(" (%d not initialized)", notInitialized)
+		}
+		rows = append(rows, [2]string{"submodules", submodules})
+	}
+	if len(repo.Hooks) > 0 {
+		rows = append(rows, [2]string{"hooks", strings.Join(repo.Hooks, ", ")})
+	}
+	if repo.LFS != nil {
+		lfs := "git-lfs not installed"
+		if repo.LFS.Installed {
+			lfs = fmt.Sprintf("%d files, %d pointers", repo.LFS.Files, repo.LFS.Pointers)
+		}
+		rows = append(rows, [2]string{"lfs", lfs})
+	}
+	return rows
+}
+
+// mountSummary describes a filesystem in one line,
+// e.g. "ext4 on /, 79.4GB free of 252.0GB, case-sensitive"
+func mountSummary(mount *snapshot.Mount) string {
+	parts := []string{fmt.Sprintf("%s on %s", mount.FSType, mount.MountPoint)}
+	parts = append(parts, fmt.Sprintf("%s free of %s",
+		config.FormatSize(mount.FreeBytes), config.FormatSize(mount.TotalBytes)))
+	if mount.TotalInodes > 0 {
+		parts = append(parts, fmt.Sprintf("%d%% inodes free", mount.FreeInodes*100/mount.TotalInodes))
+	}
+	if mount.CaseSensitive != nil {
+		if *mount.CaseSensitive {
+			parts = append(parts, "case-sensitive")
+		} else {
+			parts = append(parts, "case-insensitive")
+		}
+	}
+	for _, option := range mount.Options {
+		if option == "ro" || option == "noexec" {
+			parts = append(parts, option)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// limitRows lists the recorded resource limits, noting for cpus and memory
+// whether a cgroup limit or the host's resources set them
+func limitRows(limits *snapshot.LimitsInfo) [][2]string {
+	var rows [][2]string
+	if limits.CPUs != 0 {
+		rows = append(rows, [2]string{"cpus", fmt.Sprintf("%s (%s)", strconv.FormatFloat(limits.CPUs, 'f', -1, 64), limits.CPUSource)})
+	}
+	if limits.MemoryBytes != 0 {
+		rows = append(rows, [2]string{"memory", fmt.Sprintf("%s (%s)", config.FormatSizeLimit(limits.MemoryBytes), limits.MemorySource)})
+	}
+	if limits.Pids != 0 {
+		rows = append(rows, [2]string{"pids", config.FormatLimit(limits.Pids)})
+	}
+	if limits.OpenFiles != 0 {
+		rows = append(rows, [2]string{"open_files", config.FormatLimit(limits.OpenFiles)})
+	}
+	if limits.Processes != 0 {
+		rows = append(rows, [2]string{"processes", config.FormatLimit(limits.Processes)})
+	}
+	if limits.StackBytes != 0 {
+		rows = append(rows, [2]string{"stack", config.FormatSizeLimit(limits.StackBytes)})
+	}
+	return rows
+}
+
+// executionSummary describes where a snapshot was taken in one line,
+// e.g. "github-actions, docker container, image ubuntu22 20240101.1".
+// Snapshots from before execution contexts were recorded only have
+// CollectedVia.
+func executionSummary(s *snapshot.Snapshot) string {
+	execution := s.ExecutionContext
+	if execution == nil {
+		return s.CollectedVia
+	}
+	var parts []string
+	if execution.CI != "" {
+		parts = append(parts, execution.CI)
+	}
+	if execution.Container != "" {
+		parts = append(parts, execution.Container+" container")
+	}
+	switch {
+	case execution.VM == snapshot.VMWSL:
+		parts = append(parts, "WSL")
+	case execution.VMProduct != "":
+		parts = append(parts, fmt.Sprintf("%s VM (%s)", execution.VM, execution.VMProduct))
+	case execution.VM != "":
+		parts = append(parts, execution.VM+" VM")
+	}
+	if execution.RunnerImage != "" {
+		parts = append(parts, "image "+execution.RunnerImage)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// hasExecutionContext reports whether any compared snapshot was taken in a
+// CI job, container or VM
+func hasExecutionContext(d *diff.Diff) bool {
+	for _, s := range d.Snapshots {
+		if s != nil && s.ExecutionContext != nil {
+			return true
+		}
+	}
+	return false
+}
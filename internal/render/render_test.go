@@ -226,3 +226,206 @@ func TestFormatMarkdownValue(t *testing.T) {
 		}
 	}
 }
+
+func TestRenderDiff_IgnoredCount(t *testing.T) {
+	diffResult := &diff.Diff{
+		Nodes:     []string{"local", "ci"},
+		Summary:   diff.Summary{TotalNodes: 2, Equal: 3, Ignored: 7},
+		Diffs:     map[string]map[string]*diff.FieldDiff{},
+		Errors:    map[string]string{},
+		Snapshots: map[string]*snapshot.Snapshot{},
+	}
+
+	if output := NewCLI().RenderDiff(diffResult); !strings.Contains(output, "7 ignored") {
+		t.Error("CLI output should mention the ignored field count")
+	}
+	if output := NewMarkdown().RenderDiff(diffResult); !strings.Contains(output, "**Ignored:** 7") {
+		t.Error("Markdown output should mention the ignored field count")
+	}
+}
+
+func TestRenderDiff_NetworkSection(t *testing.T) {
+	diffResult := &diff.Diff{
+		Nodes:   []string{"local", "ci"},
+		Summary: diff.Summary{TotalNodes: 2, Different: 1},
+		Diffs: map[string]map[string]*diff.FieldDiff{
+			"network": {
+				"port/5432": {
+					Status: diff.StatusDifferent,
+					NodeValues: map[string]any{
+						"local": "listening",
+						"ci":    nil,
+					},
+				},
+			},
+		},
+		Errors:    map[string]string{},
+		Snapshots: map[string]*snapshot.Snapshot{},
+	}
+
+	cli := NewCLI().RenderDiff(diffResult)
+	if !strings.Contains(cli, "NETWORK") || !strings.Contains(cli, "port/5432") {
+		t.Error("CLI output should contain the network section")
+	}
+	md := NewMarkdown().RenderDiff(diffResult)
+	if !strings.Contains(md, "## Network") || !strings.Contains(md, "port/5432") {
+		t.Error("Markdown output should contain the network section")
+	}
+}
+
+func TestRenderDiff_TopIssuesAndMinSeverity(t *testing.T) {
+	diffResult := &diff.Diff{
+		Nodes:   []string{"local", "ci"},
+		Summary: diff.Summary{TotalNodes: 2, Different: 2},
+		Diffs: map[string]map[string]*diff.FieldDiff{
+			"env": {
+				"NODE_ENV": {
+					Status:     diff.StatusDifferent,
+					NodeValues: map[string]any{"local": "development", "ci": "production"},
+					Severity:   diff.SeverityHigh,
+					Reason:     "changes build or runtime behavior",
+				},
+				"HOSTNAME": {
+					Status:     diff.StatusDifferent,
+					NodeValues: map[string]any{"local": "laptop", "ci": "runner"},
+					Severity:   diff.SeverityLow,
+					Reason:     "host-specific, likely noise",
+				},
+			},
+		},
+		Errors:    map[string]string{},
+		Snapshots: map[string]*snapshot.Snapshot{},
+	}
+
+	renderer := NewCLI()
+	output := renderer.RenderDiff(diffResult)
+	if !strings.Contains(output, "TOP ISSUES") || !strings.Contains(output, "env.NODE_ENV") {
+		t.Error("CLI output should headline the high-severity difference")
+	}
+	if !strings.Contains(output, "HOSTNAME") {
+		t.Error("CLI output should list low-severity differences without a filter")
+	}
+
+	renderer.MinSeverity = diff.SeverityHigh
+	if output := renderer.RenderDiff(diffResult); strings.Contains(output, "HOSTNAME") {
+		t.Error("CLI output should hide differences below --min-severity")
+	}
+	renderer.MinSeverity = diff.SeverityCritical
+	if output := renderer.RenderDiff(diffResult); strings.Contains(output, "TOP ISSUES") {
+		t.Error("CLI output should leave out TOP ISSUES when the filter hides every issue")
+	}
+
+	md := NewMarkdown()
+	md.MinSeverity = diff.SeverityHigh
+	output = md.RenderDiff(diffResult)
+	if !strings.Contains(output, "## Top Issues") {
+		t.Error("Markdown output should contain Top Issues")
+	}
+	if strings.Contains(output, "HOSTNAME") {
+		t.Error("Markdown output should hide differences below MinSeverity")
+	}
+}
+
+func TestDeltaMarker(t *testing.T) {
+	nodes := []string{"local", "ci"}
+	tests := []struct {
+		delta    *diff.VersionDelta
+		expected string
+	}{
+		{&diff.VersionDelta{Kind: diff.DeltaMinor, Newer: "ci"}, "↑ minor"},
+		{&diff.VersionDelta{Kind: diff.DeltaMajor, Newer: "local"}, "↓ major"},
+		{&diff.VersionDelta{Kind: diff.DeltaUnparseable}, ""},
+		{nil, ""},
+	}
+
+	for _, test := range tests {
+		result := deltaMarker(test.delta, nodes)
+		if !strings.Contains(result, test.expected) {
+			t.Errorf("deltaMarker(%+v) = %q, want it to contain %q", test.delta, result, test.expected)
+		}
+		if test.expected == "" && result != "" {
+			t.Errorf("deltaMarker(%+v) = %q, want empty", test.delta, result)
+		}
+	}
+}
+
+func TestRenderDiff_ListVariable(t *testing.T) {
+	diffResult := &diff.Diff{
+		Nodes:   []string{"local", "ci"},
+		Summary: diff.Summary{TotalNodes: 2, Different: 1},
+		Diffs: map[string]map[string]*diff.FieldDiff{
+			"env": {
+				"PATH": {
+					Status: diff.StatusDifferent,
+					NodeValues: map[string]any{
+						"local": "/usr/local/bin:/usr/bin",
+						"ci":    "/opt/ci/bin:/usr/bin:/usr/local/bin",
+					},
+					List: &diff.ListDiff{
+						Separator: ":",
+						Baseline:  "local",
+						Changes: map[string][]diff.ListChange{"ci": {
+							{Kind: diff.ListAdded, Entry: "/opt/ci/bin", From: -1, To: 0},
+							{Kind: diff.ListMoved, Entry: "/usr/bin", From: 1, To: 1},
+						}},
+						Missing: map[string][]string{"ci": {"/opt/ci/bin"}},
+					},
+				},
+			},
+		},
+		Errors:    map[string]string{},
+		Snapshots: map[string]*snapshot.Snapshot{},
+	}
+
+	cli := NewCLI().RenderDiff(diffResult)
+	for _, want := range []string{"1 added, 1 moved (local → ci)", "/opt/ci/bin at 0", "/usr/bin 1 → 1", "not on disk on ci"} {
+		if !strings.Contains(cli, want) {
+			t.Errorf("CLI output should contain %q:\n%s", want, cli)
+		}
+	}
+	if strings.Contains(cli, "/usr/local/bin:/usr/bin") {
+		t.Error("CLI output should not show the whole PATH value")
+	}
+
+	md := NewMarkdown().RenderDiff(diffResult)
+	for _, want := range []string{"| PATH | _baseline_ | **1 added, 1 moved** |", "- ci: added `/opt/ci/bin` (at 0)", "- ci: `/opt/ci/bin` not on disk"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown output should contain %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestRenderDiff_ExecutionContext(t *testing.T) {
+	diffResult := &diff.Diff{
+		Nodes:   []string{"laptop", "ci"},
+		Summary: diff.Summary{TotalNodes: 2},
+		Diffs:   map[string]map[string]*diff.FieldDiff{},
+		Errors:  map[string]string{},
+		Snapshots: map[string]*snapshot.Snapshot{
+			"laptop": {CollectedVia: snapshot.CollectedLocal},
+			"ci": {
+				CollectedVia: snapshot.CIGitHubActions,
+				ExecutionContext: &snapshot.ExecutionContext{
+					CI:          snapshot.CIGitHubActions,
+					Container:   snapshot.ContainerDocker,
+					JobURL:      "https://github.com/acme/app/actions/runs/42",
+					RunnerImage: "ubuntu22 20240101.1",
+				},
+			},
+		},
+	}
+
+	markdown := NewMarkdown().RenderDiff(diffResult)
+	want := "| ci | github-actions, docker container, image ubuntu22 20240101.1 ([job](https://github.com/acme/app/actions/runs/42)) |"
+	if !strings.Contains(markdown, want) {
+		t.Errorf("markdown should describe the CI node, got:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, "| laptop | local |") {
+		t.Errorf("markdown should describe the local node, got:\n%s", markdown)
+	}
+
+	cli := NewCLI().RenderDiff(diffResult)
+	if !strings.Contains(cli, "github-actions, docker container") || !strings.Contains(cli, "actions/runs/42") {
+		t.Errorf("CLI header should describe the CI node, got:\n%s", cli)
+	}
+}
@@ -3,6 +3,8 @@
 package secrets
 
 import (
+	"fmt"
+	"path"
 	"regexp"
 	"strings"
 )
@@ -28,6 +30,7 @@ var secretPatterns = []*regexp.Regexp{
 	regexp.MustCompile(`(?i)cookie`),
 	regexp.MustCompile(`(?i)encryption`),
 	regexp.MustCompile(`(?i)cert`),
+	regexp.MustCompile(`(?i)extraheader`), // git http.extraHeader carries Authorization headers
 }
 
 // RedactedValue is the placeholder for redacted secrets
@@ -35,28 +38,150 @@ const RedactedValue = "[REDACTED]"
 
 // IsSecret checks if an environment variable name looks like a secret
 func IsSecret(name string) bool {
-	for _, pattern := range secretPatterns {
+	_, ok := detectName(name, secretPatterns)
+	return ok
+}
+
+func detectName(name string, patterns []*regexp.Regexp) (string, bool) {
+	for _, pattern := range patterns {
 		if pattern.MatchString(name) {
-			return true
+			return nameRuleID(pattern), true
 		}
 	}
-	return false
+	return "", false
 }
 
-// RedactEnv takes a map of environment variables and redacts secret values
-func RedactEnv(env map[string]string) map[string]string {
+// Rules customizes secret detection, typically from the secrets section
+// of envdiff.yaml
+type Rules struct {
+	Patterns        []string    // extra name patterns (regular expressions)
+	Allow           []string    // names (glob) that are never redacted
+	DisableDefaults bool        // drop the built-in name patterns
+	Entropy         EntropyRule // unset fields fall back to DefaultEntropy
+}
+
+// Redactor redacts secret values in environment maps. A variable is a secret
+// if its name matches a secret pattern or its value matches a value rule.
+// The zero value uses the built-in rules.
+type Redactor struct {
+	// Fingerprinter, when set, replaces secrets with comparable HMAC
+	// fingerprints instead of the [REDACTED] placeholder
+	Fingerprinter *Fingerprinter
+	// Entropy tunes the high-entropy value check. Unset fields fall back
+	// to DefaultEntropy; a negative MinLength disables the check.
+	Entropy EntropyRule
+
+	patterns []*regexp.Regexp // nil means secretPatterns
+	allow    []string
+}
+
+// NewRedactor creates a redactor with custom rules
+func NewRedactor(rules Rules) (*Redactor, error) {
+	r := &Redactor{Entropy: rules.Entropy, allow: rules.Allow}
+
+	r.patterns = []*regexp.Regexp{}
+	if !rules.DisableDefaults {
+		r.patterns = append(r.patterns, secretPatterns...)
+	}
+	for _, p := range rules.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	for _, a := range rules.Allow {
+		if _, err := path.Match(a, ""); err != nil {
+			return nil, fmt.Errorf("invalid secret allow pattern %q: %w", a, err)
+		}
+	}
+	return r, nil
+}
+
+// Detect returns the rule that flags a variable as a secret, if any
+func (r *Redactor) Detect(name, value string) (rule string, ok bool) {
+	if r.allowed(name) {
+		return "", false
+	}
+	patterns := r.patterns
+	if patterns == nil {
+		patterns = secretPatterns
+	}
+	if rule, ok := detectName(name, patterns); ok {
+		return rule, true
+	}
+	return detectValue(value, r.entropy())
+}
+
+// RedactEnv returns a copy of env with secret values redacted and URL
+// credentials scrubbed, and the rule that fired for each changed variable
+func (r *Redactor) RedactEnv(env map[string]string) (map[string]string, map[string]string) {
 	result := make(map[string]string, len(env))
+	rules := make(map[string]string)
 	for k, v := range env {
-		if IsSecret(k) {
-			result[k] = RedactedValue
-		} else {
-			result[k] = v
+		result[k] = v
+		if r.allowed(k) {
+			continue
+		}
+		// Credentials inside URLs are scrubbed first, so a proxy URL with a
+		// password keeps its host instead of being redacted as a whole
+		scrubbed, ok := r.ScrubURLs(k, v)
+		if rule, secret := r.Detect(k, scrubbed); secret {
+			result[k] = r.redact(k, v)
+			rules[k] = rule
+		} else if ok {
+			result[k] = scrubbed
+			rules[k] = URLCredentialsRuleID
 		}
 	}
+	return result, rules
+}
+
+// DetectValue returns the value rule that flags value as a secret, if any.
+// Use it for strings that have no variable name.
+func (r *Redactor) DetectValue(value string) (rule string, ok bool) {
+	return detectValue(value, r.entropy())
+}
+
+// entropy fills unset thresholds from DefaultEntropy
+func (r *Redactor) entropy() EntropyRule {
+	e := r.Entropy
+	if e.MinLength == 0 {
+		e.MinLength = DefaultEntropy.MinLength
+	}
+	if e.MinEntropy == 0 {
+		e.MinEntropy = DefaultEntropy.MinEntropy
+	}
+	return e
+}
+
+func (r *Redactor) allowed(name string) bool {
+	for _, pattern := range r.allow {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Redactor) redact(name, value string) string {
+	if r.Fingerprinter != nil {
+		return r.Fingerprinter.Fingerprint(name, value)
+	}
+	return RedactedValue
+}
+
+// RedactEnv takes a map of environment variables and redacts secret values
+func RedactEnv(env map[string]string) map[string]string {
+	result, _ := (&Redactor{}).RedactEnv(env)
 	return result
 }
 
-// IsRedacted checks if a value is the redacted placeholder
+// IsRedacted checks if a value is the redacted placeholder or a fingerprint
 func IsRedacted(value string) bool {
+	if _, _, ok := ParseFingerprint(value); ok {
+		return true
+	}
 	return strings.TrimSpace(value) == RedactedValue
 }
@@ -66,3 +66,71 @@ func TestIsRedacted(t *testing.T) {
 		t.Error("actual-value should not be detected as redacted")
 	}
 }
+
+func TestNewRedactor_Rules(t *testing.T) {
+	r, err := NewRedactor(Rules{
+		Patterns: []string{`_PASSPHRASE_FILE$`},
+		Allow:    []string{"AUTHOR_NAME", "*_CERT_DIR"},
+	})
+	if err != nil {
+		t.Fatalf("NewRedactor() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		secret bool
+	}{
+		{"SIGNING_PASSPHRASE_FILE", true}, // extra pattern
+		{"DB_PASSWORD", true},             // built-in pattern
+		{"AUTHOR_NAME", false},            // allowed despite (?i)auth
+		{"SSL_CERT_DIR", false},           // allowed by glob despite (?i)cert
+		{"NODE_ENV", false},
+	}
+	for _, tt := range tests {
+		if _, got := r.Detect(tt.name, "value"); got != tt.secret {
+			t.Errorf("Detect(%q) = %v, want %v", tt.name, got, tt.secret)
+		}
+	}
+}
+
+func TestNewRedactor_AllowOverridesValueRules(t *testing.T) {
+	r, err := NewRedactor(Rules{Allow: []string{"PUBLIC_JWT"}})
+	if err != nil {
+		t.Fatalf("NewRedactor() error = %v", err)
+	}
+	if _, ok := r.Detect("PUBLIC_JWT", jwt); ok {
+		t.Error("allowed names should never be redacted")
+	}
+}
+
+func TestNewRedactor_DisableDefaults(t *testing.T) {
+	r, err := NewRedactor(Rules{DisableDefaults: true, Patterns: []string{`^CORP_`}})
+	if err != nil {
+		t.Fatalf("NewRedactor() error = %v", err)
+	}
+	if _, ok := r.Detect("DB_PASSWORD", "hunter2"); ok {
+		t.Error("built-in name patterns should be disabled")
+	}
+	if _, ok := r.Detect("CORP_THING", "x"); !ok {
+		t.Error("custom patterns should still apply")
+	}
+	if rule, ok := r.Detect("HARMLESS", githubToken); !ok || rule != "value:github-token" {
+		t.Errorf("value rules should still apply, got %q", rule)
+	}
+}
+
+func TestNewRedactor_Invalid(t *testing.T) {
+	if _, err := NewRedactor(Rules{Patterns: []string{"("}}); err == nil {
+		t.Error("an invalid pattern should be an error")
+	}
+	if _, err := NewRedactor(Rules{Allow: []string{"["}}); err == nil {
+		t.Error("an invalid allow glob should be an error")
+	}
+}
+
+func TestRedactor_EntropyDisabled(t *testing.T) {
+	r := &Redactor{Entropy: EntropyRule{MinLength: -1}}
+	if _, ok := r.Detect("MY_CONFIG", randomKey); ok {
+		t.Error("a negative MinLength should disable the entropy check")
+	}
+}
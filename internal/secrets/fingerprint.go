@@ -0,0 +1,85 @@
+package secrets
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Environment variables holding the team-shared fingerprint salt
+const (
+	SaltEnv     = "ENVDIFF_SECRET_SALT"
+	SaltFileEnv = "ENVDIFF_SECRET_SALT_FILE"
+)
+
+// MinSaltLength guards against salts short enough to brute-force
+const MinSaltLength = 16
+
+// fingerprintPattern matches "[REDACTED:hmac:<key id>:<mac>]"
+var fingerprintPattern = regexp.MustCompile(`^\[REDACTED:hmac:([0-9a-f]{8}):([0-9a-f]{32})\]$`)
+
+// Fingerprinter replaces secret values with keyed HMAC-SHA256 fingerprints.
+// Snapshots taken with the same salt can be compared without revealing values;
+// without the salt a fingerprint cannot be reversed or checked against a guess.
+type Fingerprinter struct {
+	key   []byte
+	keyID string
+}
+
+// NewFingerprinter creates a fingerprinter keyed by a team-shared salt
+func NewFingerprinter(salt []byte) (*Fingerprinter, error) {
+	if len(salt) < MinSaltLength {
+		return nil, fmt.Errorf("secret salt must be at least %d bytes", MinSaltLength)
+	}
+	id := hmac.New(sha256.New, salt)
+	id.Write([]byte("envdiff key id"))
+	return &Fingerprinter{key: salt, keyID: hex.EncodeToString(id.Sum(nil))[:8]}, nil
+}
+
+// LoadSalt reads the salt from path, or from ENVDIFF_SECRET_SALT or the file
+// named by ENVDIFF_SECRET_SALT_FILE when path is empty
+func LoadSalt(path string) ([]byte, error) {
+	if path == "" {
+		if salt := os.Getenv(SaltEnv); salt != "" {
+			return []byte(salt), nil
+		}
+		path = os.Getenv(SaltFileEnv)
+	}
+	if path == "" {
+		return nil, fmt.Errorf("no secret salt: set %s or %s", SaltEnv, SaltFileEnv)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret salt: %w", err)
+	}
+	return []byte(strings.TrimRight(string(data), "\r\n")), nil
+}
+
+// KeyID identifies the salt without revealing it
+func (f *Fingerprinter) KeyID() string {
+	return f.keyID
+}
+
+// Fingerprint returns the redacted form of a secret. The variable name is
+// part of the MAC, so equal values under different names do not match.
+func (f *Fingerprinter) Fingerprint(name, value string) string {
+	mac := hmac.New(sha256.New, f.key)
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return fmt.Sprintf("[REDACTED:hmac:%s:%s]", f.keyID, hex.EncodeToString(mac.Sum(nil))[:32])
+}
+
+// ParseFingerprint splits a fingerprint into its key id and MAC
+func ParseFingerprint(value string) (keyID, mac string, ok bool) {
+	m := fingerprintPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
@@ -0,0 +1,100 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testFingerprinter(t *testing.T, salt string) *Fingerprinter {
+	t.Helper()
+	f, err := NewFingerprinter([]byte(salt))
+	if err != nil {
+		t.Fatalf("NewFingerprinter() error = %v", err)
+	}
+	return f
+}
+
+func TestFingerprint(t *testing.T) {
+	f := testFingerprinter(t, "team-shared-salt-0123")
+
+	fp := f.Fingerprint("DATABASE_URL", "postgres://user:pw@db/app")
+	if strings.Contains(fp, "postgres") || strings.Contains(fp, "pw") {
+		t.Fatalf("fingerprint leaks the value: %s", fp)
+	}
+	if fp != f.Fingerprint("DATABASE_URL", "postgres://user:pw@db/app") {
+		t.Error("fingerprints of the same value should match")
+	}
+	if fp == f.Fingerprint("DATABASE_URL", "postgres://user:pw@db/other") {
+		t.Error("fingerprints of different values should not match")
+	}
+	if fp == f.Fingerprint("OTHER_URL", "postgres://user:pw@db/app") {
+		t.Error("fingerprints should depend on the variable name")
+	}
+
+	keyID, mac, ok := ParseFingerprint(fp)
+	if !ok {
+		t.Fatalf("ParseFingerprint(%q) failed", fp)
+	}
+	if keyID != f.KeyID() || len(mac) != 32 {
+		t.Errorf("ParseFingerprint() = %q, %q", keyID, mac)
+	}
+	if !IsRedacted(fp) {
+		t.Error("fingerprints should count as redacted")
+	}
+}
+
+func TestFingerprint_SaltChangesKeyID(t *testing.T) {
+	a := testFingerprinter(t, "team-shared-salt-0123")
+	b := testFingerprinter(t, "another-teams-salt-456")
+	if a.KeyID() == b.KeyID() {
+		t.Error("different salts should have different key ids")
+	}
+	if a.Fingerprint("API_KEY", "x") == b.Fingerprint("API_KEY", "x") {
+		t.Error("different salts should give different fingerprints")
+	}
+}
+
+func TestNewFingerprinter_ShortSalt(t *testing.T) {
+	if _, err := NewFingerprinter([]byte("short")); err == nil {
+		t.Error("NewFingerprinter should reject a short salt")
+	}
+}
+
+func TestLoadSalt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "salt")
+	if err := os.WriteFile(path, []byte("from-a-file-0123456\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(SaltEnv, "")
+	t.Setenv(SaltFileEnv, path)
+	salt, err := LoadSalt("")
+	if err != nil || string(salt) != "from-a-file-0123456" {
+		t.Errorf("LoadSalt() = %q, %v; want the file contents without the newline", salt, err)
+	}
+
+	t.Setenv(SaltEnv, "from-the-environment")
+	if salt, _ := LoadSalt(""); string(salt) != "from-the-environment" {
+		t.Errorf("LoadSalt() = %q, want %s to take precedence", salt, SaltEnv)
+	}
+
+	t.Setenv(SaltEnv, "")
+	t.Setenv(SaltFileEnv, "")
+	if _, err := LoadSalt(""); err == nil {
+		t.Error("LoadSalt() should fail without a salt")
+	}
+}
+
+func TestRedactor_Fingerprint(t *testing.T) {
+	r := &Redactor{Fingerprinter: testFingerprinter(t, "team-shared-salt-0123")}
+	result, _ := r.RedactEnv(map[string]string{"API_KEY": "k1", "HOME": "/home/user"})
+
+	if _, _, ok := ParseFingerprint(result["API_KEY"]); !ok {
+		t.Errorf("API_KEY = %q, want a fingerprint", result["API_KEY"])
+	}
+	if result["HOME"] != "/home/user" {
+		t.Errorf("HOME should not be redacted, got %q", result["HOME"])
+	}
+}
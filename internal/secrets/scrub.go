@@ -0,0 +1,106 @@
+package secrets
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// URLCredentialsRuleID is the rule reported when credentials are scrubbed
+// from URLs inside an otherwise harmless value
+const URLCredentialsRuleID = "value:url-credentials"
+
+// urlPattern finds URLs embedded in a value, which may hold several
+// separated by commas, spaces, semicolons or pipes (GOPROXY, NO_PROXY, ...)
+var urlPattern = regexp.MustCompile(`[A-Za-z][A-Za-z0-9+.-]*://[^\s,;|]+`)
+
+// secretParamWords are the words of query parameter names that carry
+// credentials, as in access_token, X-Amz-Signature or apiKey
+var secretParamWords = map[string]bool{
+	"token": true, "key": true, "apikey": true, "secret": true,
+	"password": true, "passwd": true, "pwd": true, "passphrase": true,
+	"sig": true, "signature": true, "auth": true, "authorization": true,
+	"credential": true, "credentials": true,
+}
+
+// secretParam reports whether a query parameter name carries credentials.
+// The name is split into words on _, -, . and lower-to-upper case changes,
+// so monkey, author and design are not mistaken for key, auth and sig.
+func secretParam(name string) bool {
+	var word strings.Builder
+	prevLower := false
+	for _, r := range name {
+		switch {
+		case r == '_' || r == '-' || r == '.':
+			if secretParamWords[word.String()] {
+				return true
+			}
+			word.Reset()
+			prevLower = false
+			continue
+		case unicode.IsUpper(r) && prevLower:
+			if secretParamWords[word.String()] {
+				return true
+			}
+			word.Reset()
+		}
+		prevLower = unicode.IsLower(r) || unicode.IsDigit(r)
+		word.WriteRune(unicode.ToLower(r))
+	}
+	return secretParamWords[word.String()]
+}
+
+// ScrubURLs removes credentials from URLs in value, replacing userinfo and
+// key-like query parameters with [REDACTED]. The rest of the value, such as
+// the proxy host, is kept so it can still be compared.
+func ScrubURLs(value string) (string, bool) {
+	return (&Redactor{}).ScrubURLs("", value)
+}
+
+// ScrubURLs removes credentials from URLs in value. With a Fingerprinter, the
+// credentials are replaced by fingerprints so changed credentials still show.
+func (r *Redactor) ScrubURLs(name, value string) (string, bool) {
+	scrubbed := false
+	result := urlPattern.ReplaceAllStringFunc(value, func(u string) string {
+		clean, changed := r.scrubURL(name, u)
+		scrubbed = scrubbed || changed
+		return clean
+	})
+	return result, scrubbed
+}
+
+func (r *Redactor) scrubURL(name, u string) (string, bool) {
+	schemeEnd := strings.Index(u, "://") + len("://")
+	scheme, rest := u[:schemeEnd], u[schemeEnd:]
+
+	// The authority runs to the first path, query or fragment delimiter;
+	// the last @ inside it ends the userinfo
+	authorityEnd := strings.IndexAny(rest, "/?#")
+	if authorityEnd < 0 {
+		authorityEnd = len(rest)
+	}
+	changed := false
+	if at := strings.LastIndex(rest[:authorityEnd], "@"); at >= 0 && !IsRedacted(rest[:at]) {
+		rest = r.redact(name+"@userinfo", rest[:at]) + rest[at:]
+		changed = true
+	}
+
+	query := strings.Index(rest, "?")
+	if query < 0 {
+		return scheme + rest, changed
+	}
+	fragment := ""
+	if hash := strings.Index(rest[query:], "#"); hash >= 0 {
+		rest, fragment = rest[:query+hash], rest[query+hash:]
+	}
+
+	params := strings.Split(rest[query+1:], "&")
+	for i, param := range params {
+		key, val, ok := strings.Cut(param, "=")
+		if ok && val != "" && !IsRedacted(val) && secretParam(key) {
+			params[i] = key + "=" + r.redact(name+"?"+key, val)
+			changed = true
+		}
+	}
+	return scheme + rest[:query+1] + strings.Join(params, "&") + fragment, changed
+}
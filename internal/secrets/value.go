@@ -0,0 +1,126 @@
+package secrets
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// valueRule identifies a secret by the shape of its value
+type valueRule struct {
+	id      string
+	pattern *regexp.Regexp
+}
+
+// valueRules match well-known credential formats anywhere in a value
+var valueRules = []valueRule{
+	{"github-token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`)},
+	{"aws-access-key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"slack-token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"slack-webhook", regexp.MustCompile(`https://hooks\.slack\.com/(services|workflows|triggers)/[A-Za-z0-9/_-]+`)},
+	{"stripe-key", regexp.MustCompile(`\b[sr]k_(live|test)_[A-Za-z0-9]{16,}`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	{"private-key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY( BLOCK)?-----`)},
+}
+
+// EntropyRule flags long, random-looking tokens. Only runs of token
+// characters (letters, digits, + / = _ -) mixing upper case, lower case and
+// digits are scored, so hex hashes and prose do not trip it. URLs and
+// absolute paths are scored one segment at a time.
+type EntropyRule struct {
+	MinLength  int     // shortest token considered
+	MinEntropy float64 // Shannon entropy in bits per character
+}
+
+// DefaultEntropy catches random base64 and base62 keys of 32+ characters
+var DefaultEntropy = EntropyRule{MinLength: 32, MinEntropy: 4.5}
+
+// EntropyRuleID is the rule reported for high-entropy values
+const EntropyRuleID = "value:entropy"
+
+// tokenSeparator splits values into runs of token characters
+var tokenSeparator = regexp.MustCompile(`[^A-Za-z0-9+/=_-]+`)
+
+// urlSeparator splits a URL into its host, path segments and query values
+var urlSeparator = regexp.MustCompile(`[/:?&=#@]+`)
+
+// DetectValue reports which value rule, if any, flags value as a secret
+func DetectValue(value string) (rule string, ok bool) {
+	return detectValue(value, DefaultEntropy)
+}
+
+func detectValue(value string, entropy EntropyRule) (string, bool) {
+	for _, r := range valueRules {
+		if r.pattern.MatchString(value) {
+			return "value:" + r.id, true
+		}
+	}
+	if entropy.MinLength > 0 && entropy.highEntropy(value) {
+		return EntropyRuleID, true
+	}
+	return "", false
+}
+
+func (e EntropyRule) highEntropy(value string) bool {
+	for _, token := range entropyTokens(value) {
+		if len(token) >= e.MinLength && mixedCase(token) && shannonEntropy(token) >= e.MinEntropy {
+			return true
+		}
+	}
+	return false
+}
+
+// entropyTokens splits value into the tokens scored for entropy. The slashes
+// of a URL or an absolute path separate segments rather than belong to a
+// token, so a CI job link is not one long mixed-case "key".
+func entropyTokens(value string) []string {
+	value = urlPattern.ReplaceAllStringFunc(value, func(u string) string {
+		return urlSeparator.ReplaceAllString(u, " ")
+	})
+	var tokens []string
+	for _, token := range tokenSeparator.Split(value, -1) {
+		if strings.HasPrefix(token, "/") {
+			tokens = append(tokens, strings.Split(token, "/")...)
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// mixedCase reports whether s has an upper case letter, a lower case letter and a digit
+func mixedCase(s string) bool {
+	var upper, lower, digit bool
+	for _, r := range s {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	return upper && lower && digit
+}
+
+// shannonEntropy returns the entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	n := float64(len([]rune(s)))
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// nameRuleID names the name pattern that matched, e.g. "name:(?i)password"
+func nameRuleID(pattern *regexp.Regexp) string {
+	return "name:" + strings.TrimSpace(pattern.String())
+}
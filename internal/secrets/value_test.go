@@ -0,0 +1,112 @@
+package secrets
+
+import (
+	"strings"
+	"testing"
+)
+
+// Token fixtures are assembled at run time so the source never holds
+// anything that looks like a live credential
+var (
+	githubToken  = "ghp_" + strings.Repeat("a1B2", 9)
+	githubPAT    = "github_pat_" + strings.Repeat("x9Y8", 6)
+	awsKey       = "AKIA" + "IOSFODNN7EXAMPLE"
+	slackToken   = "xoxb-" + "1234567890-abcdefghij"
+	slackWebhook = "https://hooks.slack.com/services/" + "T000/B000/XXXXXXXX"
+	stripeKey    = "sk_live_" + strings.Repeat("4eC39HqL", 3)
+	jwt          = "eyJhbGciOiJIUzI1NiJ9" + ".eyJzdWIiOiIxMjM0NTY3ODkwIn0" + ".dozjgNryP4J3jVmNHl0w5N_XgL0n3I9PlFUP0THsR8U"
+	pemBlock     = "-----BEGIN RSA " + "PRIVATE KEY-----\nMIIE...\n-----END RSA PRIVATE KEY-----"
+	randomKey    = "q8Zr2Lx0Vb7Nw4Kt1Hs9Gd3Fj6Mp5Cy8Ae2Ru"
+)
+
+func TestDetectValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		rule  string
+	}{
+		{"github token", githubToken, "value:github-token"},
+		{"github fine-grained token", githubPAT, "value:github-token"},
+		{"aws key inside config", "region=us-east-1;key=" + awsKey, "value:aws-access-key"},
+		{"slack token", slackToken, "value:slack-token"},
+		{"slack webhook", slackWebhook, "value:slack-webhook"},
+		{"stripe key", stripeKey, "value:stripe-key"},
+		{"jwt", "Bearer " + jwt, "value:jwt"},
+		{"pem block", pemBlock, "value:private-key"},
+		{"random key", randomKey, EntropyRuleID},
+		{"path", "/usr/local/lib/python3.12/site-packages:/opt/homebrew/bin", ""},
+		{"ci job url", "https://github.com/GBerghoff/envdiff/actions/runs/7123456789", ""},
+		{"ci job url with attempt", "https://github.com/GBerghoff/envdiff/actions/runs/7123456789/attempts/1", ""},
+		{"deep path", "/home/runner/work/GBerghoff/envdiff/actions/runs/7123456789", ""},
+		{"key in url path", "https://api.example.com/v1/" + randomKey + "/status", EntropyRuleID},
+		{"git sha", "9fceb02d0ae598e95dc970b74767f19372d61af8", ""},
+		{"uuid", "3f2504e0-4f89-11d3-9a0c-0305e82c3301", ""},
+		{"prose", "The quick brown fox jumps over the lazy dog", ""},
+		{"short mixed", "Ab1Cd2Ef3", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ok := DetectValue(tt.value)
+			if rule != tt.rule || ok != (tt.rule != "") {
+				t.Errorf("DetectValue() = %q, %v; want %q", rule, ok, tt.rule)
+			}
+		})
+	}
+}
+
+func TestEntropyRule_Thresholds(t *testing.T) {
+	strict := &Redactor{Entropy: EntropyRule{MinLength: 64, MinEntropy: 4.5}}
+	if _, ok := strict.Detect("MY_CONFIG", randomKey); ok {
+		t.Error("a 38-character key should pass a 64-character minimum")
+	}
+
+	loose := &Redactor{Entropy: EntropyRule{MinLength: 8, MinEntropy: 2.5}}
+	if _, ok := loose.Detect("MY_CONFIG", "Ab1Cd2Ef3"); !ok {
+		t.Error("a loose entropy rule should flag a short mixed token")
+	}
+}
+
+func TestShannonEntropy(t *testing.T) {
+	if h := shannonEntropy("aaaa"); h != 0 {
+		t.Errorf("shannonEntropy(aaaa) = %v, want 0", h)
+	}
+	if h := shannonEntropy("abcd"); h != 2 {
+		t.Errorf("shannonEntropy(abcd) = %v, want 2", h)
+	}
+}
+
+func TestRedactor_RecordsRule(t *testing.T) {
+	env := map[string]string{
+		"GITHUB_PAT":    githubToken,
+		"SLACK_WEBHOOK": slackWebhook,
+		"MY_CONFIG":     awsKey,
+		"DB_PASSWORD":   "hunter2",
+		"EDITOR":        "vim",
+	}
+
+	result, rules := (&Redactor{}).RedactEnv(env)
+
+	want := map[string]string{
+		"GITHUB_PAT":    "value:github-token",
+		"SLACK_WEBHOOK": "value:slack-webhook",
+		"MY_CONFIG":     "value:aws-access-key",
+		"DB_PASSWORD":   "name:(?i)password",
+	}
+	for name, rule := range want {
+		if result[name] != RedactedValue {
+			t.Errorf("%s should be redacted, got %q", name, result[name])
+		}
+		if rules[name] != rule {
+			t.Errorf("rule for %s = %q, want %q", name, rules[name], rule)
+		}
+	}
+	if result["EDITOR"] != "vim" || rules["EDITOR"] != "" {
+		t.Errorf("EDITOR should not be redacted")
+	}
+	for name, rule := range rules {
+		if strings.Contains(rule, env[name]) {
+			t.Errorf("rule for %s leaks the value: %q", name, rule)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -16,43 +17,268 @@ const SchemaVersion = "1"
 type RuntimeInfo struct {
 	Version string `json:"version"`
 	Path    string `json:"path"`
+	// Installations lists every matching binary on PATH in PATH order.
+	// The first is the one that runs, the rest are shadowed by it.
+	Installations []Installation `json:"installations,omitempty"`
+}
+
+// Installation is one binary of a runtime found on PATH
+type Installation struct {
+	Path      string `json:"path"`
+	Target    string `json:"target,omitempty"` // resolved symlink target, when different from Path
+	Version   string `json:"version"`
+	PathIndex int    `json:"path_index"` // position of its directory in PATH
+}
+
+// VersionManagerInfo describes how a runtime is managed: the version manager
+// that provides the active install and the version the project pins
+type VersionManagerInfo struct {
+	Manager   string   `json:"manager,omitempty"`   // nvm, asdf, ...; empty for a system install
+	Installed []string `json:"installed,omitempty"` // versions the manager has installed
+	Pinned    string   `json:"pinned,omitempty"`    // version requested by a pin file
+	PinFile   string   `json:"pin_file,omitempty"`  // e.g. .nvmrc, relative to the project root
 }
 
 // SystemInfo contains OS and hardware information
 type SystemInfo struct {
-	OS        string `json:"os"`
-	OSVersion string `json:"os_version"`
-	Arch      string `json:"arch"`
-	Kernel    string `json:"kernel"`
-	CPUCores  int    `json:"cpu_cores"`
-	MemoryGB  int    `json:"memory_gb"`
-	Hostname  string `json:"hostname"`
+	OS          string `json:"os"`
+	OSVersion   string `json:"os_version"`
+	Arch        string `json:"arch"`
+	Kernel      string `json:"kernel"`
+	CPUCores    int    `json:"cpu_cores"`
+	MemoryGB    int    `json:"memory_gb"`
+	MemoryBytes int64  `json:"memory_bytes,omitempty"` // exact host memory, which MemoryGB rounds down
+	Hostname    string `json:"hostname"`
 }
 
 // PackageInfo contains package manager and installed packages
 type PackageInfo struct {
 	Manager string            `json:"manager"`
 	Items   map[string]string `json:"items"`
+	Arch    map[string]string `json:"arch,omitempty"`   // only when read from the package database
+	Source  string            `json:"source,omitempty"` // database file, empty when queried via the package manager
+}
+
+// Inventory maps package names to installed versions for one language
+// package manager
+type Inventory map[string]string
+
+// Language package ecosystems collected into Snapshot.Inventories
+const (
+	InventoryPip   = "pip"   // pip list for the active python3
+	InventoryNpm   = "npm"   // global npm packages
+	InventoryGem   = "gem"   // installed gems
+	InventoryCargo = "cargo" // cargo install --list
+	InventoryGoBin = "gobin" // Go binaries in $GOBIN
+)
+
+// InventoryEcosystems lists every inventory ecosystem
+var InventoryEcosystems = []string{InventoryPip, InventoryNpm, InventoryGem, InventoryCargo, InventoryGoBin}
+
+// ToolConfig is one toolchain's configuration, keyed by setting name
+type ToolConfig struct {
+	Settings   map[string]string `json:"settings"`
+	Origins    map[string]string `json:"origins,omitempty"`    // setting -> file it was read from (git only)
+	Redactions map[string]string `json:"redactions,omitempty"` // setting -> rule that redacted it
 }
 
+// Toolchains collected into Snapshot.Toolchain
+const (
+	ToolchainGo  = "go"  // go env -json
+	ToolchainNpm = "npm" // npm config ls -l --json
+	ToolchainPip = "pip" // pip config list for the active python3
+	ToolchainGit = "git" // git config --list --show-origin
+)
+
+// ToolchainTools lists every toolchain whose configuration is collected
+var ToolchainTools = []string{ToolchainGo, ToolchainNpm, ToolchainPip, ToolchainGit}
+
+// ProjectInfo describes the dependency lockfiles of the checked-out project
+type ProjectInfo struct {
+	Root      string               `json:"root"`
+	Lockfiles map[string]*Lockfile `json:"lockfiles"` // keyed by file name, e.g. package-lock.json
+}
+
+// Lockfile is a lockfile or dependency manifest and the versions it
+// resolves for the project's direct dependencies
+type Lockfile struct {
+	SHA256       string            `json:"sha256"`
+	Dependencies map[string]string `json:"dependencies,omitempty"` // name -> resolved version
+}
+
+// RepoInfo describes the state of the git checkout the snapshot was taken in
+type RepoInfo struct {
+	Root       string            `json:"root"`
+	Head       string            `json:"head"`                 // commit hash, empty before the first commit
+	Branch     string            `json:"branch,omitempty"`     // empty when HEAD is detached
+	Dirty      int               `json:"dirty"`                // tracked files with staged or unstaged changes
+	Untracked  int               `json:"untracked"`            // files neither tracked nor ignored
+	DirtyHash  string            `json:"dirty_hash,omitempty"` // SHA-256 of git diff HEAD, empty when no tracked file changed
+	Submodules map[string]string `json:"submodules,omitempty"` // path -> checked-out commit, empty when not initialized
+	Hooks      []string          `json:"hooks,omitempty"`      // installed hooks, without the .sample ones
+	LFS        *LFSInfo          `json:"lfs,omitempty"`        // only when .gitattributes uses the lfs filter
+	Config     map[string]string `json:"config,omitempty"`     // effective values of the git settings that affect checkouts
+	Redactions map[string]string `json:"redactions,omitempty"` // config key -> rule that redacted its value
+}
+
+// CanonicalGitKey lowercases the section and variable name of a git config
+// key, as git reports them, keeping the case of a subsection such as the
+// base URL in url.<base>.insteadOf. RepoInfo.Config is keyed this way.
+func CanonicalGitKey(key string) string {
+	first, last := strings.Index(key, "."), strings.LastIndex(key, ".")
+	if first < 0 || first == last {
+		return strings.ToLower(key)
+	}
+	return strings.ToLower(key[:first]) + key[first:last] + strings.ToLower(key[last:])
+}
+
+// LFSInfo describes the Git LFS files of a checkout
+type LFSInfo struct {
+	Installed bool `json:"installed"` // git-lfs is on PATH
+	Files     int  `json:"files"`     // files tracked by LFS
+	Pointers  int  `json:"pointers"`  // files still holding the pointer, not the content
+}
+
+// Mount describes the filesystem holding a directory builds depend on
+type Mount struct {
+	Path          string   `json:"path"` // directory probed, e.g. the value of $TMPDIR
+	MountPoint    string   `json:"mount_point"`
+	FSType        string   `json:"fs_type"`
+	Options       []string `json:"options,omitempty"` // mount options such as noexec, sorted
+	TotalBytes    uint64   `json:"total_bytes"`
+	FreeBytes     uint64   `json:"free_bytes"` // available to unprivileged users
+	TotalInodes   uint64   `json:"total_inodes,omitempty"`
+	FreeInodes    uint64   `json:"free_inodes,omitempty"`
+	CaseSensitive *bool    `json:"case_sensitive,omitempty"` // nil when the directory is not writable
+}
+
+// Filesystem roles, the keys of Snapshot.Filesystems
+const (
+	FilesystemProject = "project" // project root
+	FilesystemTmp     = "tmp"     // $TMPDIR, or the system default
+	FilesystemHome    = "home"    // $HOME
+	FilesystemDocker  = "docker"  // docker data root
+)
+
+// FilesystemRoles lists every filesystem role in display order
+var FilesystemRoles = []string{FilesystemProject, FilesystemTmp, FilesystemHome, FilesystemDocker}
+
+// LimitsInfo holds the resources processes can actually use. CPUs and
+// memory are the cgroup limits when lower than the host's, so a container
+// capped at 2 CPUs on a 16 core host reports 2.
+type LimitsInfo struct {
+	CgroupVersion int     `json:"cgroup_version,omitempty"` // 1 or 2, 0 when no cgroup limits were readable
+	CPUs          float64 `json:"cpus"`
+	CPUSource     string  `json:"cpu_source"` // LimitCgroup or LimitHost
+	MemoryBytes   int64   `json:"memory_bytes"`
+	MemorySource  string  `json:"memory_source"`
+	Pids          int64   `json:"pids"`        // cgroup pids.max
+	OpenFiles     int64   `json:"open_files"`  // soft RLIMIT_NOFILE, ulimit -n
+	Processes     int64   `json:"processes"`   // soft RLIMIT_NPROC, ulimit -u
+	StackBytes    int64   `json:"stack_bytes"` // soft RLIMIT_STACK, ulimit -s
+}
+
+// Where an effective limit comes from
+const (
+	LimitCgroup = "cgroup"
+	LimitHost   = "host"
+)
+
+// Unlimited marks a limit that is not set
+const Unlimited int64 = -1
+
 // NetworkInfo contains network-related information
 type NetworkInfo struct {
 	Hosts          map[string]string `json:"hosts"`
 	ListeningPorts []int             `json:"listening_ports"`
 }
 
+// CollectionError records a collector or probe that failed while the
+// snapshot was taken, so "not installed" can be told apart from "probe crashed"
+type CollectionError struct {
+	Collector string `json:"collector"`
+	Probe     string `json:"probe,omitempty"`
+	Command   string `json:"command,omitempty"`
+	ExitCode  int    `json:"exit_code,omitempty"`
+	Stderr    string `json:"stderr,omitempty"`
+	TimedOut  bool   `json:"timed_out,omitempty"`
+	Message   string `json:"message"`
+}
+
+// ExecutionContext describes where a snapshot was taken: the container or
+// virtual machine it ran in and the CI job that ran it
+type ExecutionContext struct {
+	Container   string `json:"container,omitempty"`    // ContainerDocker, ContainerPodman or ContainerKubernetes
+	VM          string `json:"vm,omitempty"`           // VMWSL or the hypervisor, e.g. kvm, vmware
+	VMProduct   string `json:"vm_product,omitempty"`   // DMI product name
+	CI          string `json:"ci,omitempty"`           // CIGitHubActions, CIGitLab, CIJenkins or CIBuildkite
+	JobURL      string `json:"job_url,omitempty"`      // link to the CI job or run
+	RunnerImage string `json:"runner_image,omitempty"` // CI runner or job image
+}
+
+// Container runtimes
+const (
+	ContainerDocker     = "docker"
+	ContainerPodman     = "podman"
+	ContainerKubernetes = "kubernetes"
+)
+
+// VMWSL marks the Windows Subsystem for Linux
+const VMWSL = "wsl"
+
+// CI providers
+const (
+	CIGitHubActions = "github-actions"
+	CIGitLab        = "gitlab-ci"
+	CIJenkins       = "jenkins"
+	CIBuildkite     = "buildkite"
+)
+
+// CollectedLocal is CollectedVia for a snapshot taken outside any CI job,
+// container or virtual machine
+const CollectedLocal = "local"
+
+// Via summarizes the context in one word for CollectedVia: the CI provider,
+// else the container runtime, else the VM, else CollectedLocal
+func (c *ExecutionContext) Via() string {
+	switch {
+	case c == nil:
+		return CollectedLocal
+	case c.CI != "":
+		return c.CI
+	case c.Container != "":
+		return c.Container
+	case c.VM != "":
+		return c.VM
+	default:
+		return CollectedLocal
+	}
+}
+
 // Snapshot represents a complete environment snapshot
 type Snapshot struct {
-	SchemaVersion string                   `json:"schema_version"`
-	SnapshotID    string                   `json:"snapshot_id"`
-	Timestamp     string                   `json:"timestamp"`
-	Hostname      string                   `json:"hostname"`
-	CollectedVia  string                   `json:"collected_via"`
-	System        SystemInfo               `json:"system"`
-	Runtime       map[string]*RuntimeInfo  `json:"runtime"`
-	Env           map[string]string        `json:"env"`
-	Packages      *PackageInfo             `json:"packages,omitempty"`
-	Network       *NetworkInfo             `json:"network,omitempty"`
+	SchemaVersion    string                         `json:"schema_version"`
+	SnapshotID       string                         `json:"snapshot_id"`
+	Timestamp        string                         `json:"timestamp"`
+	Hostname         string                         `json:"hostname"`
+	CollectedVia     string                         `json:"collected_via"`
+	ExecutionContext *ExecutionContext              `json:"execution_context,omitempty"`
+	System           SystemInfo                     `json:"system"`
+	Runtime          map[string]*RuntimeInfo        `json:"runtime"`
+	VersionManagers  map[string]*VersionManagerInfo `json:"version_managers,omitempty"` // keyed by runtime
+	Env              map[string]string              `json:"env"`
+	Redactions       map[string]string              `json:"redactions,omitempty"`    // variable -> rule that redacted it
+	MissingPaths     map[string][]string            `json:"missing_paths,omitempty"` // list variable -> entries not on disk
+	Packages         *PackageInfo                   `json:"packages,omitempty"`
+	Inventories      map[string]Inventory           `json:"inventories,omitempty"` // keyed by ecosystem
+	Toolchain        map[string]*ToolConfig         `json:"toolchain,omitempty"`   // keyed by tool
+	Project          *ProjectInfo                   `json:"project,omitempty"`
+	Repo             *RepoInfo                      `json:"repo,omitempty"`
+	Filesystems      map[string]*Mount              `json:"filesystems,omitempty"` // keyed by role
+	Limits           *LimitsInfo                    `json:"limits,omitempty"`
+	Sysctl           map[string]string              `json:"sysctl,omitempty"` // kernel parameter -> value
+	Network          *NetworkInfo                   `json:"network,omitempty"`
+	CollectionErrors []CollectionError              `json:"collection_errors,omitempty"`
 }
 
 // New creates a new Snapshot with default values
@@ -60,12 +286,28 @@ func New() *Snapshot {
 	return &Snapshot{
 		SchemaVersion: SchemaVersion,
 		Timestamp:     time.Now().UTC().Format(time.RFC3339),
-		CollectedVia:  "local",
+		CollectedVia:  CollectedLocal,
 		Runtime:       make(map[string]*RuntimeInfo),
 		Env:           make(map[string]string),
 	}
 }
 
+// AddError records a collection failure. Callers running probes
+// concurrently must serialize calls themselves.
+func (s *Snapshot) AddError(e CollectionError) {
+	s.CollectionErrors = append(s.CollectionErrors, e)
+}
+
+// ProbeError returns the recorded failure for a collector probe, if any
+func (s *Snapshot) ProbeError(collector, probe string) *CollectionError {
+	for i := range s.CollectionErrors {
+		if s.CollectionErrors[i].Collector == collector && s.CollectionErrors[i].Probe == probe {
+			return &s.CollectionErrors[i]
+		}
+	}
+	return nil
+}
+
 // ComputeID generates the snapshot_id from content hash
 func (s *Snapshot) ComputeID() error {
 	// Temporarily clear the ID to get consistent hash
@@ -134,3 +134,17 @@ func TestFromJSON_InvalidData(t *testing.T) {
 		t.Error("FromJSON() should return error for invalid JSON")
 	}
 }
+
+func TestCanonicalGitKey(t *testing.T) {
+	tests := map[string]string{
+		"Core.AutoCRLF":                      "core.autocrlf",
+		"Remote.Origin.URL":                  "remote.Origin.url",
+		"url.https://Example.com/.insteadOf": "url.https://Example.com/.insteadof",
+		"core":                               "core",
+	}
+	for key, want := range tests {
+		if got := CanonicalGitKey(key); got != want {
+			t.Errorf("CanonicalGitKey(%q) = %q, want %q", key, got, want)
+		}
+	}
+}